@@ -0,0 +1,194 @@
+package attachment
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/umtdemr/go-todo/logger"
+	"github.com/umtdemr/go-todo/server"
+	"github.com/umtdemr/go-todo/user"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+// multipartMemory is the size of the upload kept in memory. The rest of the file is written to a temporary file
+const multipartMemory = 1 << 20
+
+// multipartOverhead is the room left in the request body for the other parts and the boundaries of the form
+const multipartOverhead = 1 << 20
+
+type APIRoute struct {
+	Route   string
+	Service *Service
+}
+
+func NewAttachmentAPIRoute(service *Service) *APIRoute {
+	return &APIRoute{Route: "attachment", Service: service}
+}
+
+// RegisterRoutes registers the routes for the attachment API
+func (s *APIRoute) RegisterRoutes(router *mux.Router, userService user.Service) {
+	router.Handle("/attachments/usage", userService.AuthMiddleware(http.HandlerFunc(s.handleUsage)))
+	router.Handle("/todo/{id}/attachments", userService.AuthMiddleware(http.HandlerFunc(s.handleListAndUpload)))
+	router.Handle("/todo/{id}/attachments/{attachmentId}", userService.AuthMiddleware(http.HandlerFunc(s.handleDownloadAndDelete)))
+}
+
+// respondWithAttachmentError responds with the fields that caused the error if the error is an AttachmentError
+func respondWithAttachmentError(w http.ResponseWriter, msg string, err error) {
+	var e AttachmentError
+	if errors.As(err, &e) {
+		server.RespondWithErrorFields(w, fmt.Sprintf("validation error: %v", e.Error()), http.StatusBadRequest, e.fields)
+		return
+	}
+	server.RespondWithError(w, fmt.Sprintf("%s: %s", msg, err), http.StatusBadRequest)
+}
+
+// parseIds parses the todo id and the attachment id if the path has it
+func parseIds(r *http.Request, withAttachment bool) (int, int, error) {
+	vars := mux.Vars(r)
+	todoId, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return 0, 0, server.ErrInvalidRequest.With("need a numeric value for the id")
+	}
+
+	if !withAttachment {
+		return todoId, 0, nil
+	}
+
+	attachmentId, err := strconv.Atoi(vars["attachmentId"])
+	if err != nil {
+		return 0, 0, server.ErrInvalidRequest.With("need a numeric value for the attachment id")
+	}
+	return todoId, attachmentId, nil
+}
+
+// handleListAndUpload lists the attachments of the todo with GET and uploads a file with POST
+func (s *APIRoute) handleListAndUpload(w http.ResponseWriter, r *http.Request) {
+	// only GET and POST methods are allowed
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		err := server.ErrNotValidMethod.With("only GET and POST methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	todoId, _, parseErr := parseIds(r, false)
+	if parseErr != nil {
+		server.RespondWithError(w, parseErr.Error(), http.StatusBadRequest)
+		return
+	}
+
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+
+	if r.Method == http.MethodGet {
+		attachments, err := s.Service.GetTodoAttachments(todoId, authenticatedUser.Id)
+		if err != nil {
+			respondWithAttachmentError(w, "error while getting the attachments", err)
+			return
+		}
+		server.RespondOK(w, attachments)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.Service.MaxFileSize+multipartOverhead)
+	if err := r.ParseMultipartForm(multipartMemory); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondWithAttachmentError(w, "", ErrFileTooLarge)
+			return
+		}
+		respondWithAttachmentError(w, "", ErrFileRequired.With(err.Error()))
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		respondWithAttachmentError(w, "", ErrFileRequired)
+		return
+	}
+	defer file.Close()
+
+	uploadData := &UploadData{
+		Filename:    header.Filename,
+		ContentType: header.Header.Get("Content-Type"),
+		Size:        header.Size,
+	}
+
+	created, err := s.Service.AddAttachment(todoId, authenticatedUser.Id, uploadData, file)
+	if err != nil {
+		respondWithAttachmentError(w, "error while uploading the attachment", err)
+		return
+	}
+	server.RespondCreated(w, created)
+}
+
+// handleDownloadAndDelete streams the file of the attachment with GET and deletes the attachment with DELETE
+func (s *APIRoute) handleDownloadAndDelete(w http.ResponseWriter, r *http.Request) {
+	// only GET and DELETE methods are allowed
+	if r.Method != http.MethodGet && r.Method != http.MethodDelete {
+		err := server.ErrNotValidMethod.With("only GET and DELETE methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	todoId, attachmentId, parseErr := parseIds(r, true)
+	if parseErr != nil {
+		server.RespondWithError(w, parseErr.Error(), http.StatusBadRequest)
+		return
+	}
+
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+
+	if r.Method == http.MethodDelete {
+		removed, err := s.Service.RemoveAttachment(attachmentId, todoId, authenticatedUser.Id)
+		if err != nil {
+			respondWithAttachmentError(w, "error while deleting the attachment", err)
+			return
+		}
+		server.RespondNoContent(w, removed)
+		return
+	}
+
+	a, file, err := s.Service.OpenAttachment(attachmentId, todoId, authenticatedUser.Id)
+	if err != nil {
+		respondWithAttachmentError(w, "error while fetching the attachment", err)
+		return
+	}
+	defer file.Close()
+
+	// the file is always downloaded instead of being shown, so that uploaded HTML can't run on this origin
+	w.Header().Set("Content-Type", a.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(a.Size, 10))
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename})
+	if disposition == "" {
+		disposition = "attachment"
+	}
+	w.Header().Set("Content-Disposition", disposition)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(w, file); err != nil {
+		log := logger.Get()
+		log.Error().Err(err).Int("attachmentId", a.Id).Msg("Couldn't stream the attachment")
+	}
+}
+
+// handleUsage responds with the storage used by the attachments of the user
+func (s *APIRoute) handleUsage(w http.ResponseWriter, r *http.Request) {
+	// only GET methods are allowed
+	if r.Method != http.MethodGet {
+		err := server.ErrNotValidMethod.With("only GET methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+	usage, err := s.Service.GetUsage(authenticatedUser.Id)
+	if err != nil {
+		server.RespondWithError(w, fmt.Sprintf("error while getting the usage: %s", err), http.StatusBadRequest)
+		return
+	}
+	server.RespondOK(w, usage)
+}
@@ -0,0 +1,106 @@
+package attachment
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// BlobStore keeps the files of the attachments. Keys are generated by the service and only contain
+// letters, numbers and slashes
+type BlobStore interface {
+	// Put stores size bytes read from r under the key
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens the file with the key. It returns ErrBlobNotFound if there is no such file
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the file with the key. Deleting a file that doesn't exist is not an error
+	Delete(ctx context.Context, key string) error
+}
+
+type Attachment struct {
+	Id int `json:"id"`
+	// TodoId is nil after the todo is deleted permanently until the file is cleaned up
+	TodoId      *int      `json:"todoId"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"contentType"`
+	Size        int64     `json:"size"`
+	StorageKey  string    `json:"-"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type CreateAttachmentData struct {
+	TodoId      int
+	Filename    string
+	ContentType string
+	Size        int64
+	StorageKey  string
+}
+
+// Usage is the storage used by the attachments of a user in bytes
+type Usage struct {
+	Used  int64 `json:"used"`
+	Quota int64 `json:"quota"`
+}
+
+const (
+	DefaultMaxFileSize int64 = 10 << 20
+	DefaultQuota       int64 = 100 << 20
+)
+
+const maxFilenameLength = 255
+
+// attachmentColumns are the columns selected for scanning an attachment with ScanAttachment
+const attachmentColumns = `id, todo_id, filename, content_type, size, storage_key, created_at`
+
+func ScanAttachment(row pgx.Row) (*Attachment, error) {
+	a := new(Attachment)
+	err := row.Scan(&a.Id, &a.TodoId, &a.Filename, &a.ContentType, &a.Size, &a.StorageKey, &a.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// normalizeFilename drops the directories some clients send with the name and checks its length
+func normalizeFilename(filename string) (string, error) {
+	filename = strings.TrimSpace(filepath.Base(strings.ReplaceAll(filename, `\`, "/")))
+	if filename == "." || filename == "/" {
+		filename = ""
+	}
+
+	if nameLength := utf8.RuneCountInString(filename); nameLength < 1 || nameLength > maxFilenameLength {
+		return "", ErrFilenameNotValid
+	}
+	return filename, nil
+}
+
+// detectContentType uses the content type sent by the client if it is specific, then the extension of the file
+// and finally the first bytes of the file
+func detectContentType(declared string, filename string, head []byte) string {
+	if mediaType, _, err := mime.ParseMediaType(declared); err == nil && mediaType != "application/octet-stream" {
+		return declared
+	}
+
+	if byExtension := mime.TypeByExtension(filepath.Ext(filename)); byExtension != "" {
+		return byExtension
+	}
+	return http.DetectContentType(head)
+}
+
+// newStorageKey returns a random key under the directory of the user
+func newStorageKey(userId int64) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("users/%d/%s", userId, hex.EncodeToString(random)), nil
+}
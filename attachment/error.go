@@ -0,0 +1,79 @@
+package attachment
+
+type errKind int
+
+const (
+	_ errKind = iota
+	fileRequired
+	fileEmpty
+	fileTooLarge
+	filenameNotValid
+	quotaExceeded
+	todoNotFound
+	attachmentNotFound
+	blobNotFound
+	todoReadOnly
+)
+
+type AttachmentError struct {
+	kind   errKind
+	info   string
+	fields []string
+}
+
+type Fields []string
+
+func (e AttachmentError) With(info string) AttachmentError {
+	err := e
+	err.info = info
+	return err
+}
+
+func (e AttachmentError) message() string {
+	switch e.kind {
+	case fileRequired:
+		return "file need to be sent as multipart form data"
+	case fileEmpty:
+		return "file is empty"
+	case fileTooLarge:
+		return "file is too large"
+	case filenameNotValid:
+		return "file name length should be between 1 and 255"
+	case quotaExceeded:
+		return "attachment storage quota is exceeded"
+	case todoNotFound:
+		return "todo not found"
+	case attachmentNotFound:
+		return "attachment not found"
+	case blobNotFound:
+		return "file of the attachment not found"
+	case todoReadOnly:
+		return "todo is shared with you as a viewer"
+	}
+	return "error in attachment"
+}
+
+func (e AttachmentError) Error() string {
+	if e.info != "" {
+		return e.message() + ": " + e.info
+	}
+	return e.message()
+}
+
+// Is reports whether the target is an AttachmentError of the same kind so that errors.Is can be used
+func (e AttachmentError) Is(target error) bool {
+	t, ok := target.(AttachmentError)
+	return ok && t.kind == e.kind
+}
+
+var (
+	ErrFileRequired       = AttachmentError{kind: fileRequired, fields: Fields{"file"}}
+	ErrFileEmpty          = AttachmentError{kind: fileEmpty, fields: Fields{"file"}}
+	ErrFileTooLarge       = AttachmentError{kind: fileTooLarge, fields: Fields{"file"}}
+	ErrFilenameNotValid   = AttachmentError{kind: filenameNotValid, fields: Fields{"file"}}
+	ErrQuotaExceeded      = AttachmentError{kind: quotaExceeded, fields: Fields{"file"}}
+	ErrTodoNotFound       = AttachmentError{kind: todoNotFound}
+	ErrAttachmentNotFound = AttachmentError{kind: attachmentNotFound}
+	ErrBlobNotFound       = AttachmentError{kind: blobNotFound}
+	ErrTodoReadOnly       = AttachmentError{kind: todoReadOnly}
+)
@@ -0,0 +1,167 @@
+package attachment
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type IRepository interface {
+	CreateAttachment(data *CreateAttachmentData, userId int64, quota int64) (*Attachment, error)
+	GetTodoAttachments(todoId int) ([]Attachment, error)
+	GetAttachment(attachmentId int, todoId int) (*Attachment, error)
+	RemoveAttachment(attachmentId int, todoId int) (*Attachment, error)
+	GetUsage(userId int64) (int64, error)
+	GetDetachedAttachments() ([]Attachment, error)
+	DeleteAttachment(attachmentId int) error
+}
+
+type Repository struct {
+	DB *pgxpool.Pool
+}
+
+func NewAttachmentRepository(dbConn *pgxpool.Pool) (*Repository, error) {
+	return &Repository{dbConn}, nil
+}
+
+func (store *Repository) Init() error {
+	return store.CreateAttachmentTable()
+}
+
+// CreateAttachmentTable creates the attachment table. The todo id is set to null when the todo is deleted
+// permanently, so that the file can still be found and deleted from the blob store
+func (store *Repository) CreateAttachmentTable() error {
+	queries := []string{
+		`CREATE TABLE IF NOT EXISTS "attachment" (
+			id serial PRIMARY KEY,
+			user_id integer NOT NULL REFERENCES "user"(id) ON DELETE CASCADE,
+			todo_id integer REFERENCES "todo"(id) ON DELETE SET NULL,
+			filename varchar(255) NOT NULL,
+			content_type varchar(255) NOT NULL,
+			size bigint NOT NULL,
+			storage_key text NOT NULL UNIQUE,
+			created_at timestamp DEFAULT now()
+		)`,
+		`CREATE INDEX IF NOT EXISTS attachment_todo_id_idx ON "attachment" (todo_id)`,
+		`CREATE INDEX IF NOT EXISTS attachment_user_id_idx ON "attachment" (user_id)`,
+	}
+
+	for _, query := range queries {
+		if _, err := store.DB.Exec(context.Background(), query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateAttachment saves the attachment if the total size of the attachments of the user stays within the quota.
+// The row of the user is locked so that concurrent uploads can't exceed the quota together
+func (store *Repository) CreateAttachment(data *CreateAttachmentData, userId int64, quota int64) (*Attachment, error) {
+	ctx := context.Background()
+	tx, err := store.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	args := pgx.NamedArgs{
+		"todoId":      data.TodoId,
+		"userId":      userId,
+		"filename":    data.Filename,
+		"contentType": data.ContentType,
+		"size":        data.Size,
+		"storageKey":  data.StorageKey,
+	}
+
+	if _, err := tx.Exec(ctx, `SELECT id FROM "user" WHERE id = @userId FOR UPDATE`, args); err != nil {
+		return nil, err
+	}
+
+	var used int64
+	usageQuery := `SELECT COALESCE(SUM(size), 0) FROM "attachment" WHERE user_id = @userId`
+	if err := tx.QueryRow(ctx, usageQuery, args).Scan(&used); err != nil {
+		return nil, err
+	}
+
+	if used+data.Size > quota {
+		return nil, ErrQuotaExceeded
+	}
+
+	query := `INSERT INTO "attachment"(user_id, todo_id, filename, content_type, size, storage_key)
+		VALUES (@userId, @todoId, @filename, @contentType, @size, @storageKey)
+		RETURNING ` + attachmentColumns
+
+	created, err := ScanAttachment(tx.QueryRow(ctx, query, args))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// GetTodoAttachments returns the attachments of the todo uploaded by any of the users who can see the todo
+func (store *Repository) GetTodoAttachments(todoId int) ([]Attachment, error) {
+	query := `SELECT ` + attachmentColumns + ` FROM "attachment" WHERE todo_id = @todoId ORDER BY created_at, id`
+	args := pgx.NamedArgs{"todoId": todoId}
+
+	return store.queryAttachments(query, args)
+}
+
+func (store *Repository) GetAttachment(attachmentId int, todoId int) (*Attachment, error) {
+	query := `SELECT ` + attachmentColumns + ` FROM "attachment" WHERE id = @attachmentId AND todo_id = @todoId`
+	args := pgx.NamedArgs{"attachmentId": attachmentId, "todoId": todoId}
+
+	return ScanAttachment(store.DB.QueryRow(context.Background(), query, args))
+}
+
+func (store *Repository) RemoveAttachment(attachmentId int, todoId int) (*Attachment, error) {
+	query := `DELETE FROM "attachment" WHERE id = @attachmentId AND todo_id = @todoId RETURNING ` + attachmentColumns
+	args := pgx.NamedArgs{"attachmentId": attachmentId, "todoId": todoId}
+
+	return ScanAttachment(store.DB.QueryRow(context.Background(), query, args))
+}
+
+// GetUsage returns the total size of the attachments of the user in bytes
+func (store *Repository) GetUsage(userId int64) (int64, error) {
+	query := `SELECT COALESCE(SUM(size), 0) FROM "attachment" WHERE user_id = @userId`
+	args := pgx.NamedArgs{"userId": userId}
+
+	var used int64
+	err := store.DB.QueryRow(context.Background(), query, args).Scan(&used)
+	return used, err
+}
+
+// GetDetachedAttachments returns the attachments whose todos are deleted permanently
+func (store *Repository) GetDetachedAttachments() ([]Attachment, error) {
+	query := `SELECT ` + attachmentColumns + ` FROM "attachment" WHERE todo_id IS NULL ORDER BY id`
+	return store.queryAttachments(query, pgx.NamedArgs{})
+}
+
+func (store *Repository) DeleteAttachment(attachmentId int) error {
+	query := `DELETE FROM "attachment" WHERE id = @attachmentId`
+	args := pgx.NamedArgs{"attachmentId": attachmentId}
+
+	_, err := store.DB.Exec(context.Background(), query, args)
+	return err
+}
+
+func (store *Repository) queryAttachments(query string, args pgx.NamedArgs) ([]Attachment, error) {
+	rows, err := store.DB.Query(context.Background(), query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := []Attachment{}
+	for rows.Next() {
+		a, err := ScanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, *a)
+	}
+	return attachments, rows.Err()
+}
@@ -0,0 +1,204 @@
+package attachment
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/umtdemr/go-todo/logger"
+	"github.com/umtdemr/go-todo/share"
+	"github.com/umtdemr/go-todo/todo"
+	"io"
+)
+
+// TodoGetter is used to check the role of the user on the todo. It is implemented by the todo service
+type TodoGetter interface {
+	GetTodo(todoId int, userId int64) (*todo.Todo, error)
+}
+
+type Service struct {
+	Repository IRepository
+	Store      BlobStore
+	Todos      TodoGetter
+	// MaxFileSize is the maximum size of a single file in bytes
+	MaxFileSize int64
+	// Quota is the maximum total size of the attachments of a user in bytes
+	Quota int64
+}
+
+func NewAttachmentService(repo IRepository, store BlobStore, todos TodoGetter) *Service {
+	return &Service{
+		Repository:  repo,
+		Store:       store,
+		Todos:       todos,
+		MaxFileSize: DefaultMaxFileSize,
+		Quota:       DefaultQuota,
+	}
+}
+
+// UploadData describes a file uploaded to a todo. ContentType is the type sent by the client and can be empty
+type UploadData struct {
+	Filename    string
+	ContentType string
+	Size        int64
+}
+
+// checkTodo makes sure that the user has at least the needed role on the todo. Everyone who can see the todo can see
+// its attachments, but only the users who can change the todo can add or remove them
+func (service *Service) checkTodo(todoId int, userId int64, needed share.Role) error {
+	t, err := service.Todos.GetTodo(todoId, userId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrTodoNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if !t.Role.Includes(needed) {
+		return ErrTodoReadOnly
+	}
+	return nil
+}
+
+// AddAttachment stores the file and attaches it to the todo. The file is deleted again if it can't be saved
+func (service *Service) AddAttachment(todoId int, userId int64, data *UploadData, file io.Reader) (*Attachment, error) {
+	filename, err := normalizeFilename(data.Filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if data.Size <= 0 {
+		return nil, ErrFileEmpty
+	}
+
+	if data.Size > service.MaxFileSize {
+		return nil, ErrFileTooLarge
+	}
+
+	if err := service.checkTodo(todoId, userId, share.RoleEditor); err != nil {
+		return nil, err
+	}
+
+	// the quota is checked before storing the file to avoid uploading files that can't be saved.
+	// It is checked again while saving since other uploads can finish in between
+	used, err := service.Repository.GetUsage(userId)
+	if err != nil {
+		return nil, err
+	}
+	if used+data.Size > service.Quota {
+		return nil, ErrQuotaExceeded
+	}
+
+	reader := bufio.NewReaderSize(file, 512)
+	head, _ := reader.Peek(512)
+	contentType := detectContentType(data.ContentType, filename, head)
+
+	key, err := newStorageKey(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	if err := service.Store.Put(ctx, key, reader, data.Size, contentType); err != nil {
+		return nil, err
+	}
+
+	created, err := service.Repository.CreateAttachment(&CreateAttachmentData{
+		TodoId:      todoId,
+		Filename:    filename,
+		ContentType: contentType,
+		Size:        data.Size,
+		StorageKey:  key,
+	}, userId, service.Quota)
+	if err != nil {
+		if deleteErr := service.Store.Delete(ctx, key); deleteErr != nil {
+			log := logger.Get()
+			log.Error().Err(deleteErr).Str("key", key).Msg("Couldn't delete the file of the unsaved attachment")
+		}
+		return nil, err
+	}
+	return created, nil
+}
+
+func (service *Service) GetTodoAttachments(todoId int, userId int64) ([]Attachment, error) {
+	if err := service.checkTodo(todoId, userId, share.RoleViewer); err != nil {
+		return nil, err
+	}
+	return service.Repository.GetTodoAttachments(todoId)
+}
+
+// OpenAttachment returns the attachment with its file. The caller needs to close the file
+func (service *Service) OpenAttachment(attachmentId int, todoId int, userId int64) (*Attachment, io.ReadCloser, error) {
+	if err := service.checkTodo(todoId, userId, share.RoleViewer); err != nil {
+		return nil, nil, err
+	}
+
+	a, err := service.Repository.GetAttachment(attachmentId, todoId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, err := service.Store.Get(context.Background(), a.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return a, file, nil
+}
+
+// RemoveAttachment deletes the attachment and its file
+func (service *Service) RemoveAttachment(attachmentId int, todoId int, userId int64) (*Attachment, error) {
+	if err := service.checkTodo(todoId, userId, share.RoleEditor); err != nil {
+		return nil, err
+	}
+
+	a, err := service.Repository.RemoveAttachment(attachmentId, todoId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := service.Store.Delete(context.Background(), a.StorageKey); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// GetUsage returns the storage used by the attachments of the user and the quota
+func (service *Service) GetUsage(userId int64) (*Usage, error) {
+	used, err := service.Repository.GetUsage(userId)
+	if err != nil {
+		return nil, err
+	}
+	return &Usage{Used: used, Quota: service.Quota}, nil
+}
+
+// RemoveDetachedAttachments deletes the files of the attachments whose todos are deleted permanently.
+// Attachments whose files can't be deleted are kept to be tried again on the next call
+func (service *Service) RemoveDetachedAttachments() (int64, error) {
+	detached, err := service.Repository.GetDetachedAttachments()
+	if err != nil {
+		return 0, err
+	}
+
+	var removed int64
+	var firstErr error
+	for _, a := range detached {
+		if err := service.Store.Delete(context.Background(), a.StorageKey); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		if err := service.Repository.DeleteAttachment(a.Id); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, firstErr
+}
@@ -0,0 +1,254 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/umtdemr/go-todo/share"
+	"github.com/umtdemr/go-todo/todo"
+	"io"
+	"strings"
+	"testing"
+)
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) CreateAttachment(data *CreateAttachmentData, userId int64, quota int64) (*Attachment, error) {
+	args := m.Called(data, userId, quota)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Attachment), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) GetTodoAttachments(todoId int) ([]Attachment, error) {
+	args := m.Called(todoId)
+	return args.Get(0).([]Attachment), args.Error(1)
+}
+
+func (m *MockRepository) GetAttachment(attachmentId int, todoId int) (*Attachment, error) {
+	args := m.Called(attachmentId, todoId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Attachment), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) RemoveAttachment(attachmentId int, todoId int) (*Attachment, error) {
+	args := m.Called(attachmentId, todoId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Attachment), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) GetUsage(userId int64) (int64, error) {
+	args := m.Called(userId)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepository) GetDetachedAttachments() ([]Attachment, error) {
+	args := m.Called()
+	return args.Get(0).([]Attachment), args.Error(1)
+}
+
+func (m *MockRepository) DeleteAttachment(attachmentId int) error {
+	args := m.Called(attachmentId)
+	return args.Error(0)
+}
+
+type MockTodoGetter struct {
+	mock.Mock
+}
+
+func (m *MockTodoGetter) GetTodo(todoId int, userId int64) (*todo.Todo, error) {
+	args := m.Called(todoId, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*todo.Todo), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+// memoryStore is a BlobStore keeping the files in memory. Keys listed in failing can't be deleted
+type memoryStore struct {
+	files   map[string][]byte
+	failing map[string]bool
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{files: map[string][]byte{}, failing: map[string]bool{}}
+}
+
+func (m *memoryStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	content, err := io.ReadAll(io.LimitReader(r, size))
+	if err != nil {
+		return err
+	}
+	m.files[key] = content
+	return nil
+}
+
+func (m *memoryStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	content, ok := m.files[key]
+	if !ok {
+		return nil, ErrBlobNotFound
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+func (m *memoryStore) Delete(ctx context.Context, key string) error {
+	if m.failing[key] {
+		return errors.New("storage is not reachable")
+	}
+	delete(m.files, key)
+	return nil
+}
+
+func TestAddAttachment(t *testing.T) {
+	pngHeader := "\x89PNG\r\n\x1a\n"
+
+	tests := []struct {
+		name                string
+		data                UploadData
+		content             string
+		setupMock           func(repo *MockRepository, todos *MockTodoGetter)
+		expectedError       error
+		expectedContentType string
+		expectedFiles       int
+	}{
+		{
+			name:          "Filename is empty",
+			data:          UploadData{Filename: " ", Size: 1},
+			content:       "x",
+			setupMock:     func(repo *MockRepository, todos *MockTodoGetter) {},
+			expectedError: ErrFilenameNotValid,
+		},
+		{
+			name:          "File is too large",
+			data:          UploadData{Filename: "video.mp4", Size: 101},
+			setupMock:     func(repo *MockRepository, todos *MockTodoGetter) {},
+			expectedError: ErrFileTooLarge,
+		},
+		{
+			name:    "Todo doesn't exist",
+			data:    UploadData{Filename: "receipt.pdf", Size: 1},
+			content: "x",
+			setupMock: func(repo *MockRepository, todos *MockTodoGetter) {
+				todos.On("GetTodo", 5, int64(1)).Return(nil, pgx.ErrNoRows)
+			},
+			expectedError: ErrTodoNotFound,
+		},
+		{
+			name:    "Todo is shared with the user as a viewer",
+			data:    UploadData{Filename: "receipt.pdf", Size: 1},
+			content: "x",
+			setupMock: func(repo *MockRepository, todos *MockTodoGetter) {
+				todos.On("GetTodo", 5, int64(1)).Return(&todo.Todo{Id: 5, Role: share.RoleViewer}, nil)
+			},
+			expectedError: ErrTodoReadOnly,
+		},
+		{
+			name:    "Quota is exceeded",
+			data:    UploadData{Filename: "receipt.pdf", Size: 50},
+			content: strings.Repeat("x", 50),
+			setupMock: func(repo *MockRepository, todos *MockTodoGetter) {
+				todos.On("GetTodo", 5, int64(1)).Return(&todo.Todo{Id: 5, Role: share.RoleEditor}, nil)
+				repo.On("GetUsage", int64(1)).Return(int64(960), nil)
+			},
+			expectedError: ErrQuotaExceeded,
+		},
+		{
+			name:    "Quota is exceeded by a concurrent upload",
+			data:    UploadData{Filename: "receipt.pdf", Size: 50},
+			content: strings.Repeat("x", 50),
+			setupMock: func(repo *MockRepository, todos *MockTodoGetter) {
+				todos.On("GetTodo", 5, int64(1)).Return(&todo.Todo{Id: 5, Role: share.RoleEditor}, nil)
+				repo.On("GetUsage", int64(1)).Return(int64(900), nil)
+				repo.On("CreateAttachment", mock.Anything, int64(1), int64(1000)).Return(nil, ErrQuotaExceeded)
+			},
+			expectedError: ErrQuotaExceeded,
+			// the stored file is deleted again
+			expectedFiles: 0,
+		},
+		{
+			name:    "Content type is detected from the file",
+			data:    UploadData{Filename: `C:\Users\me\screenshot`, ContentType: "application/octet-stream", Size: int64(len(pngHeader))},
+			content: pngHeader,
+			setupMock: func(repo *MockRepository, todos *MockTodoGetter) {
+				todos.On("GetTodo", 5, int64(1)).Return(&todo.Todo{Id: 5, Role: share.RoleEditor}, nil)
+				repo.On("GetUsage", int64(1)).Return(int64(0), nil)
+				repo.On("CreateAttachment", mock.MatchedBy(func(data *CreateAttachmentData) bool {
+					return data.Filename == "screenshot" && data.TodoId == 5 && strings.HasPrefix(data.StorageKey, "users/1/")
+				}), int64(1), int64(1000)).Return(&Attachment{Id: 1}, nil)
+			},
+			expectedContentType: "image/png",
+			expectedFiles:       1,
+		},
+		{
+			name:    "Content type is sent by the client",
+			data:    UploadData{Filename: "notes.txt", ContentType: "text/markdown", Size: 4},
+			content: "# hi",
+			setupMock: func(repo *MockRepository, todos *MockTodoGetter) {
+				todos.On("GetTodo", 5, int64(1)).Return(&todo.Todo{Id: 5, Role: share.RoleEditor}, nil)
+				repo.On("GetUsage", int64(1)).Return(int64(0), nil)
+				repo.On("CreateAttachment", mock.Anything, int64(1), int64(1000)).Return(&Attachment{Id: 2}, nil)
+			},
+			expectedContentType: "text/markdown",
+			expectedFiles:       1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockTodos := new(MockTodoGetter)
+			store := newMemoryStore()
+			service := NewAttachmentService(mockRepo, store, mockTodos)
+			service.MaxFileSize = 100
+			service.Quota = 1000
+			tc.setupMock(mockRepo, mockTodos)
+
+			_, err := service.AddAttachment(5, 1, &tc.data, strings.NewReader(tc.content))
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+			} else {
+				assert.Nil(t, err)
+				created := mockRepo.Calls[len(mockRepo.Calls)-1].Arguments.Get(0).(*CreateAttachmentData)
+				assert.Equal(t, tc.expectedContentType, created.ContentType)
+				assert.Equal(t, tc.content, string(store.files[created.StorageKey]))
+			}
+			assert.Len(t, store.files, tc.expectedFiles)
+			mockRepo.AssertExpectations(t)
+			mockTodos.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRemoveDetachedAttachments(t *testing.T) {
+	mockRepo := new(MockRepository)
+	store := newMemoryStore()
+	service := NewAttachmentService(mockRepo, store, new(MockTodoGetter))
+
+	store.files["users/1/a"] = []byte("a")
+	store.files["users/1/b"] = []byte("b")
+	store.failing["users/1/b"] = true
+
+	mockRepo.On("GetDetachedAttachments").Return([]Attachment{
+		{Id: 1, StorageKey: "users/1/a"},
+		{Id: 2, StorageKey: "users/1/b"},
+	}, nil)
+	// the attachment whose file couldn't be deleted is kept to be tried again
+	mockRepo.On("DeleteAttachment", 1).Return(nil)
+
+	removed, err := service.RemoveDetachedAttachments()
+	assert.NotNil(t, err)
+	assert.Equal(t, int64(1), removed)
+	assert.NotContains(t, store.files, "users/1/a")
+	assert.Contains(t, store.files, "users/1/b")
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,200 @@
+package comment
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/umtdemr/go-todo/server"
+	"github.com/umtdemr/go-todo/user"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+type APIRoute struct {
+	Route   string
+	Service *Service
+}
+
+func NewCommentAPIRoute(service *Service) *APIRoute {
+	return &APIRoute{Route: "comment", Service: service}
+}
+
+// RegisterRoutes registers the routes for the comment API
+func (s *APIRoute) RegisterRoutes(router *mux.Router, userService user.Service) {
+	router.Handle("/todo/{id}/comments", userService.AuthMiddleware(http.HandlerFunc(s.handleListAndAdd)))
+	router.Handle("/todo/{id}/comments/{commentId}", userService.AuthMiddleware(http.HandlerFunc(s.handleUpdateAndDelete)))
+	router.Handle("/todo/{id}/comments/{commentId}/history", userService.AuthMiddleware(http.HandlerFunc(s.handleHistory)))
+}
+
+// respondWithCommentError responds with the fields that caused the error if the error is a CommentError
+func respondWithCommentError(w http.ResponseWriter, msg string, err error) {
+	var e CommentError
+	if errors.As(err, &e) {
+		server.RespondWithErrorFields(w, fmt.Sprintf("validation error: %v", e.Error()), http.StatusBadRequest, e.fields)
+		return
+	}
+	server.RespondWithError(w, fmt.Sprintf("%s: %s", msg, err), http.StatusBadRequest)
+}
+
+// parseIds parses the todo id and the comment id if the path has it
+func parseIds(r *http.Request, withComment bool) (int, int, error) {
+	vars := mux.Vars(r)
+	todoId, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return 0, 0, server.ErrInvalidRequest.With("need a numeric value for the id")
+	}
+
+	if !withComment {
+		return todoId, 0, nil
+	}
+
+	commentId, err := strconv.Atoi(vars["commentId"])
+	if err != nil {
+		return 0, 0, server.ErrInvalidRequest.With("need a numeric value for the comment id")
+	}
+	return todoId, commentId, nil
+}
+
+// parseListOptions parses the limit and the cursor of the comment list
+func parseListOptions(query url.Values) (*ListOptions, error) {
+	options := &ListOptions{Limit: DefaultListLimit}
+
+	if limitValue := query.Get("limit"); limitValue != "" {
+		limit, err := strconv.Atoi(limitValue)
+		if err != nil || limit < 1 {
+			return nil, ErrLimitNotValid
+		}
+		options.Limit = min(limit, MaxListLimit)
+	}
+
+	if cursor := query.Get("cursor"); cursor != "" {
+		after, err := ParseCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		options.After = after
+	}
+	return options, nil
+}
+
+// handleListAndAdd lists the comments of the todo with GET and adds a comment with POST
+func (s *APIRoute) handleListAndAdd(w http.ResponseWriter, r *http.Request) {
+	// only GET and POST methods are allowed
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		err := server.ErrNotValidMethod.With("only GET and POST methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	todoId, _, parseErr := parseIds(r, false)
+	if parseErr != nil {
+		server.RespondWithError(w, parseErr.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// get the authenticated user from the context
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+
+	if r.Method == http.MethodGet {
+		options, err := parseListOptions(r.URL.Query())
+		if err != nil {
+			respondWithCommentError(w, "error while parsing the list options", err)
+			return
+		}
+
+		page, err := s.Service.ListComments(todoId, authenticatedUser.Id, options)
+		if err != nil {
+			respondWithCommentError(w, "error while getting the comments", err)
+			return
+		}
+
+		if page.NextCursor != nil {
+			query := r.URL.Query()
+			query.Set("cursor", *page.NextCursor)
+			next := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+			w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.String()))
+		}
+		server.RespondOK(w, page)
+		return
+	}
+
+	var createData CreateCommentData
+	if err := server.DecodeBody(r, &createData); err != nil {
+		server.RespondWithError(w, fmt.Sprintf("parsing error: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	created, err := s.Service.CreateComment(todoId, authenticatedUser, &createData)
+	if err != nil {
+		respondWithCommentError(w, "error while creating the comment", err)
+		return
+	}
+	server.RespondCreated(w, created)
+}
+
+// handleUpdateAndDelete edits the comment with POST and deletes it with DELETE
+func (s *APIRoute) handleUpdateAndDelete(w http.ResponseWriter, r *http.Request) {
+	// only POST and DELETE methods are allowed
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		err := server.ErrNotValidMethod.With("only POST and DELETE methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	todoId, commentId, parseErr := parseIds(r, true)
+	if parseErr != nil {
+		server.RespondWithError(w, parseErr.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// get the authenticated user from the context
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+
+	if r.Method == http.MethodDelete {
+		removed, err := s.Service.RemoveComment(commentId, todoId, authenticatedUser.Id)
+		if err != nil {
+			respondWithCommentError(w, "error while deleting the comment", err)
+			return
+		}
+		server.RespondNoContent(w, removed)
+		return
+	}
+
+	var updateData UpdateCommentData
+	if err := server.DecodeBody(r, &updateData); err != nil {
+		server.RespondWithError(w, fmt.Sprintf("error while parsing: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	updated, err := s.Service.UpdateComment(commentId, todoId, authenticatedUser.Id, &updateData)
+	if err != nil {
+		respondWithCommentError(w, "error while updating the comment", err)
+		return
+	}
+	server.RespondOK(w, updated)
+}
+
+// handleHistory responds with the previous versions of the comment
+func (s *APIRoute) handleHistory(w http.ResponseWriter, r *http.Request) {
+	// only GET methods are allowed
+	if r.Method != http.MethodGet {
+		err := server.ErrNotValidMethod.With("only GET methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	todoId, commentId, parseErr := parseIds(r, true)
+	if parseErr != nil {
+		server.RespondWithError(w, parseErr.Error(), http.StatusBadRequest)
+		return
+	}
+
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+	revisions, err := s.Service.GetHistory(commentId, todoId, authenticatedUser.Id)
+	if err != nil {
+		respondWithCommentError(w, "error while getting the history", err)
+		return
+	}
+	server.RespondOK(w, revisions)
+}
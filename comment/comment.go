@@ -0,0 +1,90 @@
+package comment
+
+import (
+	"github.com/jackc/pgx/v5"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Author is the user who wrote the comment. The email of the user is not shown to the others
+type Author struct {
+	Id       int64  `json:"id"`
+	Username string `json:"username"`
+}
+
+type Comment struct {
+	Id     int    `json:"id"`
+	TodoId int    `json:"todoId"`
+	Author Author `json:"author"`
+	Body   string `json:"body"`
+	// EditedAt is the time of the last edit. It is nil if the comment has never been edited
+	EditedAt  *time.Time `json:"editedAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// Revision is a previous version of the body of an edited comment
+type Revision struct {
+	Body string `json:"body"`
+	// WrittenAt is when this version was written, not when it was replaced
+	WrittenAt time.Time `json:"writtenAt"`
+}
+
+type CreateCommentData struct {
+	Body string `json:"body"`
+}
+
+type UpdateCommentData struct {
+	Body *string `json:"body,omitempty"`
+}
+
+// CommentPage is a page of the comments of a todo, oldest first. NextCursor is nil on the last page
+type CommentPage struct {
+	Items      []Comment `json:"items"`
+	NextCursor *string   `json:"next_cursor"`
+}
+
+// ListOptions are the pagination options of the comments. After is the id of the last comment of the previous page
+type ListOptions struct {
+	Limit int
+	After int
+}
+
+const (
+	DefaultListLimit = 20
+	MaxListLimit     = 100
+)
+
+const maxBodyLength = 5000
+
+// commentColumns are the columns selected for scanning a comment with ScanComment.
+// They need the comment as c and its author as u
+const commentColumns = `c.id, c.todo_id, c.author_id, u.username, c.body, c.edited_at, c.created_at`
+
+func ScanComment(row pgx.Row) (*Comment, error) {
+	c := new(Comment)
+	err := row.Scan(&c.Id, &c.TodoId, &c.Author.Id, &c.Author.Username, &c.Body, &c.EditedAt, &c.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// normalizeBody trims the body and checks its length
+func normalizeBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if bodyLength := utf8.RuneCountInString(body); bodyLength < 1 || bodyLength > maxBodyLength {
+		return "", ErrBodyLength
+	}
+	return body, nil
+}
+
+// ParseCursor parses the cursor of the next page
+func ParseCursor(cursor string) (int, error) {
+	after, err := strconv.Atoi(cursor)
+	if err != nil || after < 1 {
+		return 0, ErrCursorNotValid
+	}
+	return after, nil
+}
@@ -0,0 +1,57 @@
+package comment
+
+type errKind int
+
+const (
+	_ errKind = iota
+	bodyLength
+	noFieldToUpdate
+	cursorNotValid
+	limitNotValid
+	todoNotFound
+	commentNotFound
+	notAuthor
+)
+
+type CommentError struct {
+	kind   errKind
+	fields []string
+}
+
+type Fields []string
+
+func (e CommentError) Error() string {
+	switch e.kind {
+	case bodyLength:
+		return "comment length should be between 1 and 5000"
+	case noFieldToUpdate:
+		return "no field is provided"
+	case cursorNotValid:
+		return "cursor is not valid"
+	case limitNotValid:
+		return "limit needs to be a positive number"
+	case todoNotFound:
+		return "todo not found"
+	case commentNotFound:
+		return "comment not found"
+	case notAuthor:
+		return "only the author can change the comment"
+	}
+	return "error in comment"
+}
+
+// Is reports whether the target is a CommentError of the same kind so that errors.Is can be used
+func (e CommentError) Is(target error) bool {
+	t, ok := target.(CommentError)
+	return ok && t.kind == e.kind
+}
+
+var (
+	ErrBodyLength      = CommentError{kind: bodyLength, fields: Fields{"body"}}
+	ErrNoFieldToUpdate = CommentError{kind: noFieldToUpdate}
+	ErrCursorNotValid  = CommentError{kind: cursorNotValid, fields: Fields{"cursor"}}
+	ErrLimitNotValid   = CommentError{kind: limitNotValid, fields: Fields{"limit"}}
+	ErrTodoNotFound    = CommentError{kind: todoNotFound}
+	ErrCommentNotFound = CommentError{kind: commentNotFound}
+	ErrNotAuthor       = CommentError{kind: notAuthor}
+)
@@ -0,0 +1,187 @@
+package comment
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type IRepository interface {
+	CreateComment(todoId int, authorId int64, body string) (*Comment, error)
+	GetComments(todoId int, options *ListOptions) ([]Comment, error)
+	GetComment(commentId int, todoId int) (*Comment, error)
+	UpdateComment(commentId int, todoId int, body string) (*Comment, error)
+	RemoveComment(commentId int, todoId int) (*Comment, error)
+	GetRevisions(commentId int) ([]Revision, error)
+}
+
+type Repository struct {
+	DB *pgxpool.Pool
+}
+
+func NewCommentRepository(dbConn *pgxpool.Pool) (*Repository, error) {
+	return &Repository{dbConn}, nil
+}
+
+func (store *Repository) Init() error {
+	return store.CreateCommentTables()
+}
+
+// CreateCommentTables creates the comment table and the table keeping the previous versions of the edited comments
+func (store *Repository) CreateCommentTables() error {
+	queries := []string{
+		`CREATE TABLE IF NOT EXISTS "comment" (
+			id serial PRIMARY KEY,
+			todo_id integer NOT NULL REFERENCES "todo"(id) ON DELETE CASCADE,
+			author_id integer NOT NULL REFERENCES "user"(id) ON DELETE CASCADE,
+			body text NOT NULL,
+			edited_at timestamp,
+			created_at timestamp DEFAULT now()
+		)`,
+		`CREATE INDEX IF NOT EXISTS comment_todo_id_idx ON "comment" (todo_id, id)`,
+		`CREATE TABLE IF NOT EXISTS "comment_revision" (
+			id serial PRIMARY KEY,
+			comment_id integer NOT NULL REFERENCES "comment"(id) ON DELETE CASCADE,
+			body text NOT NULL,
+			written_at timestamp NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS comment_revision_comment_id_idx ON "comment_revision" (comment_id)`,
+	}
+
+	for _, query := range queries {
+		if _, err := store.DB.Exec(context.Background(), query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (store *Repository) CreateComment(todoId int, authorId int64, body string) (*Comment, error) {
+	query := `WITH c AS (
+			INSERT INTO "comment"(todo_id, author_id, body) VALUES (@todoId, @authorId, @body) RETURNING *
+		)
+		SELECT ` + commentColumns + ` FROM c JOIN "user" u ON u.id = c.author_id`
+	args := pgx.NamedArgs{
+		"todoId":   todoId,
+		"authorId": authorId,
+		"body":     body,
+	}
+
+	return ScanComment(store.DB.QueryRow(context.Background(), query, args))
+}
+
+// GetComments returns the comments of the todo after the given comment, oldest first
+func (store *Repository) GetComments(todoId int, options *ListOptions) ([]Comment, error) {
+	query := `SELECT ` + commentColumns + ` FROM "comment" c JOIN "user" u ON u.id = c.author_id
+		WHERE c.todo_id = @todoId AND c.id > @after
+		ORDER BY c.id
+		LIMIT @limit`
+	args := pgx.NamedArgs{
+		"todoId": todoId,
+		"after":  options.After,
+		"limit":  options.Limit,
+	}
+
+	rows, err := store.DB.Query(context.Background(), query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []Comment{}
+	for rows.Next() {
+		c, err := ScanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, *c)
+	}
+	return comments, rows.Err()
+}
+
+func (store *Repository) GetComment(commentId int, todoId int) (*Comment, error) {
+	query := `SELECT ` + commentColumns + ` FROM "comment" c JOIN "user" u ON u.id = c.author_id
+		WHERE c.id = @commentId AND c.todo_id = @todoId`
+	args := pgx.NamedArgs{"commentId": commentId, "todoId": todoId}
+
+	return ScanComment(store.DB.QueryRow(context.Background(), query, args))
+}
+
+// UpdateComment saves the current body as a revision and replaces it with the new body in a transaction
+func (store *Repository) UpdateComment(commentId int, todoId int, body string) (*Comment, error) {
+	ctx := context.Background()
+	tx, err := store.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	args := pgx.NamedArgs{
+		"commentId": commentId,
+		"todoId":    todoId,
+		"body":      body,
+	}
+
+	// the comment is locked so that concurrent edits keep all the revisions
+	var previous Revision
+	currentQuery := `SELECT body, COALESCE(edited_at, created_at) FROM "comment"
+		WHERE id = @commentId AND todo_id = @todoId FOR UPDATE`
+	if err := tx.QueryRow(ctx, currentQuery, args).Scan(&previous.Body, &previous.WrittenAt); err != nil {
+		return nil, err
+	}
+
+	revisionQuery := `INSERT INTO "comment_revision"(comment_id, body, written_at) VALUES (@commentId, @previousBody, @writtenAt)`
+	args["previousBody"] = previous.Body
+	args["writtenAt"] = previous.WrittenAt
+	if _, err := tx.Exec(ctx, revisionQuery, args); err != nil {
+		return nil, err
+	}
+
+	query := `WITH c AS (
+			UPDATE "comment" SET body = @body, edited_at = now() WHERE id = @commentId AND todo_id = @todoId RETURNING *
+		)
+		SELECT ` + commentColumns + ` FROM c JOIN "user" u ON u.id = c.author_id`
+
+	updated, err := ScanComment(tx.QueryRow(ctx, query, args))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// RemoveComment deletes the comment with its revisions
+func (store *Repository) RemoveComment(commentId int, todoId int) (*Comment, error) {
+	query := `WITH c AS (
+			DELETE FROM "comment" WHERE id = @commentId AND todo_id = @todoId RETURNING *
+		)
+		SELECT ` + commentColumns + ` FROM c JOIN "user" u ON u.id = c.author_id`
+	args := pgx.NamedArgs{"commentId": commentId, "todoId": todoId}
+
+	return ScanComment(store.DB.QueryRow(context.Background(), query, args))
+}
+
+// GetRevisions returns the previous versions of the comment, oldest first
+func (store *Repository) GetRevisions(commentId int) ([]Revision, error) {
+	query := `SELECT body, written_at FROM "comment_revision" WHERE comment_id = @commentId ORDER BY id`
+	args := pgx.NamedArgs{"commentId": commentId}
+
+	rows, err := store.DB.Query(context.Background(), query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []Revision{}
+	for rows.Next() {
+		var revision Revision
+		if err := rows.Scan(&revision.Body, &revision.WrittenAt); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, rows.Err()
+}
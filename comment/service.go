@@ -0,0 +1,147 @@
+package comment
+
+import (
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/umtdemr/go-todo/todo"
+	"github.com/umtdemr/go-todo/user"
+	"strconv"
+)
+
+// TodoGetter is used to check that the user can see the todo. It is implemented by the todo service
+type TodoGetter interface {
+	GetTodo(todoId int, userId int64) (*todo.Todo, error)
+}
+
+type Service struct {
+	Repository IRepository
+	Todos      TodoGetter
+}
+
+func NewCommentService(repo IRepository, todos TodoGetter) *Service {
+	return &Service{Repository: repo, Todos: todos}
+}
+
+// checkTodo makes sure that the todo is visible to the user. Only the users who can see a todo can read and
+// write its comments
+func (service *Service) checkTodo(todoId int, userId int64) error {
+	if _, err := service.Todos.GetTodo(todoId, userId); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrTodoNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// CreateComment adds a comment to the todo written by the authenticated user
+func (service *Service) CreateComment(todoId int, author *user.VisibleUser, data *CreateCommentData) (*Comment, error) {
+	body, err := normalizeBody(data.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := service.checkTodo(todoId, author.Id); err != nil {
+		return nil, err
+	}
+	return service.Repository.CreateComment(todoId, author.Id, body)
+}
+
+// ListComments returns a page of the comments of the todo, oldest first
+func (service *Service) ListComments(todoId int, userId int64, options *ListOptions) (*CommentPage, error) {
+	if err := service.checkTodo(todoId, userId); err != nil {
+		return nil, err
+	}
+
+	// one more comment is fetched to know if there is a next page
+	pageOptions := *options
+	pageOptions.Limit = options.Limit + 1
+	comments, err := service.Repository.GetComments(todoId, &pageOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &CommentPage{Items: comments}
+	if len(comments) > options.Limit {
+		page.Items = comments[:options.Limit]
+		cursor := strconv.Itoa(page.Items[len(page.Items)-1].Id)
+		page.NextCursor = &cursor
+	}
+	return page, nil
+}
+
+// getOwnComment returns the comment if the user is its author
+func (service *Service) getOwnComment(commentId int, todoId int, userId int64) (*Comment, error) {
+	if err := service.checkTodo(todoId, userId); err != nil {
+		return nil, err
+	}
+
+	c, err := service.Repository.GetComment(commentId, todoId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrCommentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Author.Id != userId {
+		return nil, ErrNotAuthor
+	}
+	return c, nil
+}
+
+// UpdateComment changes the body of the comment. The previous body is kept in the history
+func (service *Service) UpdateComment(commentId int, todoId int, userId int64, data *UpdateCommentData) (*Comment, error) {
+	if data.Body == nil {
+		return nil, ErrNoFieldToUpdate
+	}
+
+	body, err := normalizeBody(*data.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := service.getOwnComment(commentId, todoId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	// nothing is added to the history if the body doesn't change
+	if current.Body == body {
+		return current, nil
+	}
+
+	updated, err := service.Repository.UpdateComment(commentId, todoId, body)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrCommentNotFound
+	}
+	return updated, err
+}
+
+// RemoveComment deletes the comment of the user
+func (service *Service) RemoveComment(commentId int, todoId int, userId int64) (*Comment, error) {
+	if _, err := service.getOwnComment(commentId, todoId, userId); err != nil {
+		return nil, err
+	}
+
+	removed, err := service.Repository.RemoveComment(commentId, todoId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrCommentNotFound
+	}
+	return removed, err
+}
+
+// GetHistory returns the previous versions of the comment, oldest first. Everyone who can see the todo can see it
+func (service *Service) GetHistory(commentId int, todoId int, userId int64) ([]Revision, error) {
+	if err := service.checkTodo(todoId, userId); err != nil {
+		return nil, err
+	}
+
+	if _, err := service.Repository.GetComment(commentId, todoId); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrCommentNotFound
+		}
+		return nil, err
+	}
+	return service.Repository.GetRevisions(commentId)
+}
@@ -0,0 +1,217 @@
+package comment
+
+import (
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/umtdemr/go-todo/todo"
+	"github.com/umtdemr/go-todo/user"
+	"strings"
+	"testing"
+)
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) CreateComment(todoId int, authorId int64, body string) (*Comment, error) {
+	args := m.Called(todoId, authorId, body)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Comment), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) GetComments(todoId int, options *ListOptions) ([]Comment, error) {
+	args := m.Called(todoId, options)
+	return args.Get(0).([]Comment), args.Error(1)
+}
+
+func (m *MockRepository) GetComment(commentId int, todoId int) (*Comment, error) {
+	args := m.Called(commentId, todoId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Comment), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) UpdateComment(commentId int, todoId int, body string) (*Comment, error) {
+	args := m.Called(commentId, todoId, body)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Comment), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) RemoveComment(commentId int, todoId int) (*Comment, error) {
+	args := m.Called(commentId, todoId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Comment), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) GetRevisions(commentId int) ([]Revision, error) {
+	args := m.Called(commentId)
+	return args.Get(0).([]Revision), args.Error(1)
+}
+
+type MockTodoGetter struct {
+	mock.Mock
+}
+
+func (m *MockTodoGetter) GetTodo(todoId int, userId int64) (*todo.Todo, error) {
+	args := m.Called(todoId, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*todo.Todo), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func TestCreateComment(t *testing.T) {
+	author := &user.VisibleUser{Id: 1, Username: "umit"}
+
+	tests := []struct {
+		name          string
+		data          CreateCommentData
+		setupMock     func(repo *MockRepository, todos *MockTodoGetter)
+		expectedError error
+	}{
+		{
+			name:          "Body is empty",
+			data:          CreateCommentData{Body: "  "},
+			setupMock:     func(repo *MockRepository, todos *MockTodoGetter) {},
+			expectedError: ErrBodyLength,
+		},
+		{
+			name:          "Body is too long",
+			data:          CreateCommentData{Body: strings.Repeat("a", maxBodyLength+1)},
+			setupMock:     func(repo *MockRepository, todos *MockTodoGetter) {},
+			expectedError: ErrBodyLength,
+		},
+		{
+			name: "User can't see the todo",
+			data: CreateCommentData{Body: "done?"},
+			setupMock: func(repo *MockRepository, todos *MockTodoGetter) {
+				todos.On("GetTodo", 3, int64(1)).Return(nil, pgx.ErrNoRows)
+			},
+			expectedError: ErrTodoNotFound,
+		},
+		{
+			name: "Comment is created by the authenticated user",
+			data: CreateCommentData{Body: " done? "},
+			setupMock: func(repo *MockRepository, todos *MockTodoGetter) {
+				todos.On("GetTodo", 3, int64(1)).Return(&todo.Todo{Id: 3}, nil)
+				repo.On("CreateComment", 3, int64(1), "done?").Return(&Comment{Id: 1, Author: Author{Id: 1, Username: "umit"}}, nil)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockTodos := new(MockTodoGetter)
+			service := NewCommentService(mockRepo, mockTodos)
+			tc.setupMock(mockRepo, mockTodos)
+
+			created, err := service.CreateComment(3, author, &tc.data)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+			} else {
+				assert.Nil(t, err)
+				assert.Equal(t, "umit", created.Author.Username)
+			}
+			mockRepo.AssertExpectations(t)
+			mockTodos.AssertExpectations(t)
+		})
+	}
+}
+
+func TestListComments(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockTodos := new(MockTodoGetter)
+	service := NewCommentService(mockRepo, mockTodos)
+
+	mockTodos.On("GetTodo", 3, int64(1)).Return(&todo.Todo{Id: 3}, nil)
+	// one more comment than the limit is fetched to know that there is a next page
+	mockRepo.On("GetComments", 3, &ListOptions{Limit: 3, After: 10}).Return([]Comment{{Id: 11}, {Id: 12}, {Id: 15}}, nil)
+
+	page, err := service.ListComments(3, 1, &ListOptions{Limit: 2, After: 10})
+	assert.Nil(t, err)
+	assert.Len(t, page.Items, 2)
+	assert.Equal(t, "12", *page.NextCursor)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUpdateComment(t *testing.T) {
+	newBody := "done now"
+	sameBody := "done?"
+
+	tests := []struct {
+		name          string
+		userId        int64
+		body          *string
+		setupMock     func(repo *MockRepository)
+		expectedError error
+	}{
+		{
+			name:          "Body isn't sent",
+			userId:        1,
+			setupMock:     func(repo *MockRepository) {},
+			expectedError: ErrNoFieldToUpdate,
+		},
+		{
+			name:   "User isn't the author",
+			userId: 2,
+			body:   &newBody,
+			setupMock: func(repo *MockRepository) {
+				repo.On("GetComment", 5, 3).Return(&Comment{Id: 5, Author: Author{Id: 1}, Body: "done?"}, nil)
+			},
+			expectedError: ErrNotAuthor,
+		},
+		{
+			name:   "Comment doesn't exist",
+			userId: 1,
+			body:   &newBody,
+			setupMock: func(repo *MockRepository) {
+				repo.On("GetComment", 5, 3).Return(nil, pgx.ErrNoRows)
+			},
+			expectedError: ErrCommentNotFound,
+		},
+		{
+			name:   "Body doesn't change",
+			userId: 1,
+			body:   &sameBody,
+			setupMock: func(repo *MockRepository) {
+				repo.On("GetComment", 5, 3).Return(&Comment{Id: 5, Author: Author{Id: 1}, Body: "done?"}, nil)
+			},
+		},
+		{
+			name:   "Body is edited",
+			userId: 1,
+			body:   &newBody,
+			setupMock: func(repo *MockRepository) {
+				repo.On("GetComment", 5, 3).Return(&Comment{Id: 5, Author: Author{Id: 1}, Body: "done?"}, nil)
+				repo.On("UpdateComment", 5, 3, "done now").Return(&Comment{Id: 5, Body: "done now"}, nil)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockTodos := new(MockTodoGetter)
+			service := NewCommentService(mockRepo, mockTodos)
+			mockTodos.On("GetTodo", 3, tc.userId).Return(&todo.Todo{Id: 3}, nil).Maybe()
+			tc.setupMock(mockRepo)
+
+			_, err := service.UpdateComment(5, 3, tc.userId, &UpdateCommentData{Body: tc.body})
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+			} else {
+				assert.Nil(t, err)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -5,15 +5,24 @@ import (
 	"fmt"
 	"github.com/gorilla/mux"
 	"github.com/spf13/viper"
+	"github.com/umtdemr/go-todo/attachment"
+	"github.com/umtdemr/go-todo/comment"
 	"github.com/umtdemr/go-todo/email"
 	"github.com/umtdemr/go-todo/logger"
+	"github.com/umtdemr/go-todo/project"
 	"github.com/umtdemr/go-todo/server"
+	"github.com/umtdemr/go-todo/share"
+	"github.com/umtdemr/go-todo/sharelink"
+	"github.com/umtdemr/go-todo/tag"
+	"github.com/umtdemr/go-todo/timetrack"
 	"github.com/umtdemr/go-todo/todo"
 	"github.com/umtdemr/go-todo/user"
+	"github.com/umtdemr/go-todo/workspace"
 	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
+	"time"
 )
 
 func RunSwagger(router *mux.Router) {
@@ -24,6 +33,25 @@ func RunSwagger(router *mux.Router) {
 	router.PathPrefix("/swaggerui/").Handler(http.StripPrefix("/swaggerui/", fs))
 }
 
+// newBlobStore creates the storage of the attachments. Files are kept on the local disk unless s3 is configured
+func newBlobStore() (attachment.BlobStore, error) {
+	if viper.GetString("ATTACHMENT_STORAGE") == "s3" {
+		return attachment.NewS3Store(attachment.S3Config{
+			Endpoint:  viper.GetString("S3_ENDPOINT"),
+			Region:    viper.GetString("S3_REGION"),
+			Bucket:    viper.GetString("S3_BUCKET"),
+			AccessKey: viper.GetString("S3_ACCESS_KEY"),
+			SecretKey: viper.GetString("S3_SECRET_KEY"),
+		})
+	}
+
+	dir := viper.GetString("ATTACHMENT_DIR")
+	if dir == "" {
+		dir = filepath.Join("data", "attachments")
+	}
+	return attachment.NewLocalStore(dir)
+}
+
 func main() {
 	viper.SetConfigFile(".env")
 	errReadConfig := viper.ReadInConfig()
@@ -53,7 +81,7 @@ func main() {
 	if err != nil {
 		log.Fatal().Err(err).Msg("Couldn't connect to the db")
 	}
-	defer store.DB.Close(context.Background())
+	defer store.DB.Close()
 
 	email.Init()
 
@@ -63,21 +91,144 @@ func main() {
 		log.Fatal().Msg("Couldn't create user table")
 	}
 
+	// todos and projects reference the workspaces, so their tables are created first
+	workspaceRepository, err := workspace.NewWorkspaceRepository(store.DB)
+
+	if workspaceRepoInitErr := workspaceRepository.Init(); workspaceRepoInitErr != nil {
+		log.Fatal().Msg("Couldn't create workspace tables")
+	}
+
+	projectRepository, err := project.NewProjectRepository(store.DB)
+
+	if projectRepoInitErr := projectRepository.Init(); projectRepoInitErr != nil {
+		log.Fatal().Msg("Couldn't create project table")
+	}
+
+	tagRepository, err := tag.NewTagRepository(store.DB)
+
+	if tagRepoInitErr := tagRepository.Init(); tagRepoInitErr != nil {
+		log.Fatal().Msg("Couldn't create tag table")
+	}
+
 	todoRepository, err := todo.NewTodoRepository(store.DB)
 
 	if todoRepoInitErr := todoRepository.Init(); todoRepoInitErr != nil {
 		log.Fatal().Msg("Couldn't create todo table")
 	}
 
+	// the share tables and the access functions used by the todo queries need the todo and project tables
+	shareRepository, err := share.NewShareRepository(store.DB)
+
+	if shareRepoInitErr := shareRepository.Init(); shareRepoInitErr != nil {
+		log.Fatal().Msg("Couldn't create share tables")
+	}
+
+	shareLinkRepository, err := sharelink.NewShareLinkRepository(store.DB)
+
+	if shareLinkRepoInitErr := shareLinkRepository.Init(); shareLinkRepoInitErr != nil {
+		log.Fatal().Msg("Couldn't create share link table")
+	}
+
+	attachmentRepository, err := attachment.NewAttachmentRepository(store.DB)
+
+	if attachmentRepoInitErr := attachmentRepository.Init(); attachmentRepoInitErr != nil {
+		log.Fatal().Msg("Couldn't create attachment table")
+	}
+
+	commentRepository, err := comment.NewCommentRepository(store.DB)
+
+	if commentRepoInitErr := commentRepository.Init(); commentRepoInitErr != nil {
+		log.Fatal().Msg("Couldn't create comment tables")
+	}
+
+	timeTrackRepository, err := timetrack.NewTimeTrackRepository(store.DB)
+
+	if timeTrackRepoInitErr := timeTrackRepository.Init(); timeTrackRepoInitErr != nil {
+		log.Fatal().Msg("Couldn't create time entry table")
+	}
+
+	blobStore, err := newBlobStore()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Couldn't create the attachment storage")
+	}
+
 	apiServer := server.NewAPIServer(":8080")
 
 	userService := user.NewUserService(userRepository)
 	userAPIRoute := user.NewAPIRoute(*userService)
 	userAPIRoute.RegisterAPIRoutes(apiServer.Router)
 
-	todoService := todo.NewTodoService(todoRepository)
+	workspaceService := workspace.NewWorkspaceService(workspaceRepository)
+	if invitationDays := viper.GetInt("WORKSPACE_INVITATION_DAYS"); invitationDays > 0 {
+		workspaceService.InvitationTTL = time.Duration(invitationDays) * 24 * time.Hour
+	}
+	workspaceAPIRoute := workspace.NewWorkspaceAPIRoute(workspaceService)
+	workspaceAPIRoute.RegisterRoutes(apiServer.Router, *userService)
+
+	projectService := project.NewProjectService(projectRepository)
+	todoService := todo.NewTodoService(todoRepository, projectService)
+
+	attachmentService := attachment.NewAttachmentService(attachmentRepository, blobStore, todoService)
+	if maxFileSize := viper.GetInt64("ATTACHMENT_MAX_SIZE_MB"); maxFileSize > 0 {
+		attachmentService.MaxFileSize = maxFileSize << 20
+	}
+	if quota := viper.GetInt64("ATTACHMENT_QUOTA_MB"); quota > 0 {
+		attachmentService.Quota = quota << 20
+	}
+	// files of the attachments are deleted when their todos are deleted permanently
+	todoService.Attachments = attachmentService
+	// assignees are emailed with the addresses and the preferences of the users
+	todoService.Users = userService
+	// members of a workspace can be assigned to the todos in it
+	todoService.Workspaces = workspaceService
+
+	timeTrackService := timetrack.NewTimeTrackService(timeTrackRepository, todoService, projectService)
+	// timers of the todos moved to the trash are stopped
+	todoService.Timers = timeTrackService
+	projectService.Timers = timeTrackService
+
+	// the background jobs are started after the todo service is wired. Each run acquires a connection of its own
+	// from the pool, so it doesn't run in the session of a request
+
+	// todos in the trash are deleted permanently after the retention period
+	trashRetention := todo.DefaultTrashRetention
+	if retentionDays := viper.GetInt("TRASH_RETENTION_DAYS"); retentionDays > 0 {
+		trashRetention = time.Duration(retentionDays) * 24 * time.Hour
+	}
+	go todoService.RunTrashPurge(context.Background(), trashRetention, time.Hour)
+
+	// done todos are archived automatically only if it is configured
+	if autoArchiveDays := viper.GetInt("AUTO_ARCHIVE_DAYS"); autoArchiveDays > 0 {
+		go todoService.RunAutoArchive(context.Background(), time.Duration(autoArchiveDays)*24*time.Hour, time.Hour)
+	}
+
 	todoAPIRoute := todo.NewTodoAPIRoute(todoService)
-	todoAPIRoute.RegisterRoutes(apiServer.Router, *userService)
+	todoAPIRoute.RegisterRoutes(apiServer.Router, *userService, workspaceService)
+
+	projectAPIRoute := project.NewProjectAPIRoute(projectService)
+	projectAPIRoute.RegisterRoutes(apiServer.Router, *userService, workspaceService)
+
+	tagService := tag.NewTagService(tagRepository)
+	tagAPIRoute := tag.NewTagAPIRoute(tagService)
+	tagAPIRoute.RegisterRoutes(apiServer.Router, *userService)
+
+	attachmentAPIRoute := attachment.NewAttachmentAPIRoute(attachmentService)
+	attachmentAPIRoute.RegisterRoutes(apiServer.Router, *userService)
+
+	commentService := comment.NewCommentService(commentRepository, todoService)
+	commentAPIRoute := comment.NewCommentAPIRoute(commentService)
+	commentAPIRoute.RegisterRoutes(apiServer.Router, *userService)
+
+	shareService := share.NewShareService(shareRepository, userService)
+	shareAPIRoute := share.NewShareAPIRoute(shareService)
+	shareAPIRoute.RegisterRoutes(apiServer.Router, *userService)
+
+	shareLinkService := sharelink.NewShareLinkService(shareLinkRepository, todoService, projectService)
+	shareLinkAPIRoute := sharelink.NewShareLinkAPIRoute(shareLinkService)
+	shareLinkAPIRoute.RegisterRoutes(apiServer.Router, *userService)
+
+	timeTrackAPIRoute := timetrack.NewTimeTrackAPIRoute(timeTrackService)
+	timeTrackAPIRoute.RegisterRoutes(apiServer.Router, *userService)
 
 	RunSwagger(apiServer.Router)
 	log.Info().Msg("Server is running")
@@ -0,0 +1,180 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/umtdemr/go-todo/server"
+	"github.com/umtdemr/go-todo/user"
+	"github.com/umtdemr/go-todo/workspace"
+	"net/http"
+	"strconv"
+)
+
+type APIRoute struct {
+	Route   string
+	Service *Service
+}
+
+func NewProjectAPIRoute(service *Service) *APIRoute {
+	return &APIRoute{Route: "project", Service: service}
+}
+
+// RegisterRoutes registers the routes for the project API.
+// The list and the create routes work in the active workspace that is resolved by the workspace service
+func (s *APIRoute) RegisterRoutes(router *mux.Router, userService user.Service, workspaceService *workspace.Service) {
+	router.Handle("/project", userService.AuthMiddleware(workspaceService.Middleware(http.HandlerFunc(s.handleList))))
+	router.Handle("/project/list", userService.AuthMiddleware(workspaceService.Middleware(http.HandlerFunc(s.handleList))))
+	router.Handle("/project/create", userService.AuthMiddleware(workspaceService.Middleware(http.HandlerFunc(s.handleAdd))))
+	router.Handle("/project/update", userService.AuthMiddleware(http.HandlerFunc(s.handleUpdate)))
+	router.Handle("/project/{id}", userService.AuthMiddleware(http.HandlerFunc(s.handleFetchAndDelete)))
+}
+
+// respondWithProjectError responds with the fields that caused the error if the error is a ProjectError
+func respondWithProjectError(w http.ResponseWriter, msg string, err error) {
+	var e ProjectError
+	if errors.As(err, &e) {
+		server.RespondWithErrorFields(w, fmt.Sprintf("validation error: %v", e.Error()), http.StatusBadRequest, e.fields)
+		return
+	}
+	server.RespondWithError(w, fmt.Sprintf("%s: %s", msg, err), http.StatusBadRequest)
+}
+
+// handleList handles the list request
+func (s *APIRoute) handleList(w http.ResponseWriter, r *http.Request) {
+	includeArchived := false
+	if value := r.URL.Query().Get("include_archived"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			err := server.ErrInvalidRequest.With("include_archived needs to be a boolean")
+			server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		includeArchived = parsed
+	}
+
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+	// only the projects of the active workspace are listed
+	var workspaceId *int
+	if ws := workspace.FromContext(r.Context()); ws != nil {
+		workspaceId = &ws.Id
+	}
+
+	projects, err := s.Service.GetAllProjects(authenticatedUser.Id, workspaceId, includeArchived)
+
+	if err != nil {
+		server.RespondWithError(w, fmt.Sprintf("error while getting list: %s", err), http.StatusBadRequest)
+		return
+	}
+	server.RespondOK(w, projects)
+}
+
+// handleAdd handles the add request
+func (s *APIRoute) handleAdd(w http.ResponseWriter, r *http.Request) {
+	// only POST methods are allowed
+	if r.Method != http.MethodPost {
+		err := server.ErrNotValidMethod.With("only POST methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var createData CreateProjectData
+
+	err := server.DecodeBody(r, &createData)
+
+	if err != nil {
+		server.RespondWithError(w, fmt.Sprintf("parsing error: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// get the authenticated user from the context
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+
+	// projects created in a workspace belong to it
+	if ws := workspace.FromContext(r.Context()); ws != nil {
+		createData.WorkspaceId = &ws.Id
+	}
+
+	createdProject, createErr := s.Service.CreateProject(&createData, authenticatedUser.Id)
+	if createErr != nil {
+		respondWithProjectError(w, "error while creating the project", createErr)
+		return
+	}
+
+	server.RespondCreated(w, createdProject)
+}
+
+// handleUpdate handles the update request. It is used for renaming and archiving projects
+func (s *APIRoute) handleUpdate(w http.ResponseWriter, r *http.Request) {
+	// only POST methods are allowed
+	if r.Method != http.MethodPost {
+		err := server.ErrNotValidMethod.With("only POST methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var updateData UpdateProjectData
+
+	err := server.DecodeBody(r, &updateData)
+
+	if err != nil {
+		server.RespondWithError(w, fmt.Sprintf("error while parsing: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// get the authenticated user from the context
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+
+	updatedProject, updateErr := s.Service.UpdateProject(&updateData, authenticatedUser.Id)
+	if updateErr != nil {
+		respondWithProjectError(w, "Error while updating", updateErr)
+		return
+	}
+
+	server.RespondOK(w, updatedProject)
+}
+
+// handleFetchAndDelete handles the fetch and delete requests
+func (s *APIRoute) handleFetchAndDelete(w http.ResponseWriter, r *http.Request) {
+	// only GET and DELETE methods are allowed
+	if r.Method != http.MethodGet && r.Method != http.MethodDelete {
+		err := server.ErrNotValidMethod.With("only GET and DELETE methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// parse the ID from the path variables
+	projectId, parseErr := strconv.Atoi(mux.Vars(r)["id"])
+
+	if parseErr != nil {
+		err := server.ErrInvalidRequest.With("need a numeric value for the id")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// get the authenticated user from the context
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+
+	if r.Method == http.MethodDelete {
+		// todos are moved to the inbox unless they are asked to be deleted
+		action := TodoActionMove
+		if value := r.URL.Query().Get("todos"); value != "" {
+			action = TodoAction(value)
+		}
+
+		removedProject, removeErr := s.Service.RemoveProject(projectId, authenticatedUser.Id, action)
+		if removeErr != nil {
+			respondWithProjectError(w, "error while deleting", removeErr)
+			return
+		}
+		server.RespondNoContent(w, removedProject)
+		return
+	}
+
+	fetchedProject, err := s.Service.GetProject(projectId, authenticatedUser.Id)
+	if err != nil {
+		respondWithProjectError(w, "error while fetching", err)
+		return
+	}
+	server.RespondOK(w, fetchedProject)
+}
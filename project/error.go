@@ -0,0 +1,62 @@
+package project
+
+type errKind int
+
+const (
+	_ errKind = iota
+	nameLength
+	idRequired
+	noFieldToUpdate
+	todoActionNotValid
+	projectNotFound
+	notOwner
+	workflowNotValid
+	estimateUnitNotValid
+)
+
+type ProjectError struct {
+	kind   errKind
+	fields []string
+}
+
+type Fields []string
+
+func (e ProjectError) Error() string {
+	switch e.kind {
+	case nameLength:
+		return "name length should be between 1 and 255"
+	case idRequired:
+		return "id is required"
+	case noFieldToUpdate:
+		return "no field is provided"
+	case todoActionNotValid:
+		return "todos should be either move or delete"
+	case projectNotFound:
+		return "project not found"
+	case notOwner:
+		return "only the owner can change the project"
+	case workflowNotValid:
+		return "workflow needs 2 to 20 statuses with unique names of at most 50 characters, " +
+			"non-negative WIP limits and transitions to its other statuses"
+	case estimateUnitNotValid:
+		return "estimate unit should be either minutes or points"
+	}
+	return "error in project"
+}
+
+// Is reports whether the target is a ProjectError of the same kind so that errors.Is can be used
+func (e ProjectError) Is(target error) bool {
+	t, ok := target.(ProjectError)
+	return ok && t.kind == e.kind
+}
+
+var (
+	ErrNameLength           = ProjectError{kind: nameLength, fields: Fields{"name"}}
+	ErrIdRequired           = ProjectError{kind: idRequired, fields: Fields{"id"}}
+	ErrNoFieldToUpdate      = ProjectError{kind: noFieldToUpdate}
+	ErrTodoActionNotValid   = ProjectError{kind: todoActionNotValid, fields: Fields{"todos"}}
+	ErrProjectNotFound      = ProjectError{kind: projectNotFound}
+	ErrNotOwner             = ProjectError{kind: notOwner}
+	ErrWorkflowNotValid     = ProjectError{kind: workflowNotValid, fields: Fields{"workflow"}}
+	ErrEstimateUnitNotValid = ProjectError{kind: estimateUnitNotValid, fields: Fields{"estimateUnit"}}
+)
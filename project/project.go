@@ -0,0 +1,99 @@
+package project
+
+import (
+	"github.com/jackc/pgx/v5"
+	"github.com/umtdemr/go-todo/share"
+	"time"
+)
+
+type Project struct {
+	Id        int       `json:"id"`
+	Name      string    `json:"name"`
+	Archived  bool      `json:"archived"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// Role is the role of the user on the project. Projects shared with the user have the role of the share
+	Role share.Role `json:"role"`
+	// WorkspaceId is the workspace that the project belongs to. It is nil for the personal projects
+	WorkspaceId *int `json:"workspaceId"`
+	// Workflow is the statuses of the todos in the project. It is the default workflow unless the project has its own
+	Workflow *Workflow `json:"workflow"`
+	// EstimateUnit is the unit of the estimates of the todos in the project
+	EstimateUnit string `json:"estimateUnit"`
+	// Estimate is the sum of the estimates of the todos in the project, which are not in the trash
+	Estimate EstimateSum `json:"estimate"`
+}
+
+// EstimateSum is the sum of the estimates in a unit. Remaining only sums the estimates of the todos which are not done,
+// and Count is the number of the todos with an estimate
+type EstimateSum struct {
+	Unit      string `json:"unit"`
+	Total     int    `json:"total"`
+	Remaining int    `json:"remaining"`
+	Count     int    `json:"count"`
+}
+
+const (
+	EstimateUnitMinutes = "minutes"
+	EstimateUnitPoints  = "points"
+)
+
+type CreateProjectData struct {
+	Name string `json:"name"`
+	// EstimateUnit is minutes unless it is sent
+	EstimateUnit string `json:"estimateUnit,omitempty"`
+	// WorkspaceId is the active workspace of the request, not a part of the body
+	WorkspaceId *int `json:"-"`
+}
+
+type UpdateProjectData struct {
+	Id       *int    `json:"id,omitempty"`
+	Name     *string `json:"name,omitempty"`
+	Archived *bool   `json:"archived,omitempty"`
+	// Workflow replaces the workflow of the project. Todos in the statuses that are removed are moved to the first status
+	Workflow *Workflow `json:"workflow,omitempty"`
+	// EstimateUnit changes the unit of the estimates. The estimates of the todos are kept as they are
+	EstimateUnit *string `json:"estimateUnit,omitempty"`
+}
+
+// TodoAction is what happens to the todos of a project when the project is deleted
+type TodoAction string
+
+const (
+	// TodoActionMove moves the todos of the project to the inbox
+	TodoActionMove TodoAction = "move"
+	// TodoActionDelete moves the todos of the project to the trash with the project
+	TodoActionDelete TodoAction = "delete"
+)
+
+// projectColumns are the columns selected for scanning a project with ScanProject.
+// The role is selected for the user in the userId argument, so the queries using it need to have it.
+// project_role can't see a project that is being inserted, so the creator is checked first.
+// The estimates are summed over the todos which are not in the trash
+const projectColumns = `id, name, archived, created_at, updated_at,
+	CASE WHEN user_id = @userId THEN 'owner' ELSE project_role(id, @userId) END AS role, workspace_id, workflow,
+	estimate_unit,
+	(SELECT COALESCE(SUM(t.estimate), 0) FROM "todo" t
+		WHERE t.project_id = "project".id AND t.deleted_at IS NULL) AS estimate_total,
+	(SELECT COALESCE(SUM(t.estimate), 0) FROM "todo" t
+		WHERE t.project_id = "project".id AND t.deleted_at IS NULL AND NOT t.done) AS estimate_remaining,
+	(SELECT COUNT(t.estimate) FROM "todo" t
+		WHERE t.project_id = "project".id AND t.deleted_at IS NULL) AS estimate_count`
+
+func ScanProject(row pgx.Row) (*Project, error) {
+	p := new(Project)
+	var role string
+	err := row.Scan(
+		&p.Id, &p.Name, &p.Archived, &p.CreatedAt, &p.UpdatedAt, &role, &p.WorkspaceId, &p.Workflow,
+		&p.EstimateUnit, &p.Estimate.Total, &p.Estimate.Remaining, &p.Estimate.Count,
+	)
+	if err != nil {
+		return nil, err
+	}
+	p.Role = share.Role(role)
+	p.Estimate.Unit = p.EstimateUnit
+	if p.Workflow == nil {
+		p.Workflow = DefaultWorkflow()
+	}
+	return p, nil
+}
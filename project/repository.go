@@ -0,0 +1,245 @@
+package project
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"strings"
+	"time"
+)
+
+type IRepository interface {
+	CreateProject(data *CreateProjectData, userId int64) (*Project, error)
+	GetAllProjects(userId int64, workspaceId *int, includeArchived bool) ([]Project, error)
+	GetProject(projectId int, userId int64) (*Project, error)
+	UpdateProject(data *UpdateProjectData, userId int64) (*Project, error)
+	RemoveProject(projectId int, userId int64, trashTodos bool) (*Project, error)
+}
+
+type Repository struct {
+	DB *pgxpool.Pool
+}
+
+func NewProjectRepository(dbConn *pgxpool.Pool) (*Repository, error) {
+	return &Repository{dbConn}, nil
+}
+
+func (store *Repository) Init() error {
+	if err := store.CreateProjectTable(); err != nil {
+		return err
+	}
+	return store.MigrateProjectTable()
+}
+
+func (store *Repository) CreateProjectTable() error {
+	query := `CREATE TABLE IF NOT EXISTS "project" (
+		id serial PRIMARY KEY,
+		user_id integer NOT NULL REFERENCES "user"(id) ON DELETE CASCADE,
+		name varchar(255) NOT NULL,
+		archived boolean DEFAULT false,
+		created_at timestamp DEFAULT now(),
+		updated_at timestamp DEFAULT now()
+	)`
+
+	_, err := store.DB.Exec(context.Background(), query)
+	return err
+}
+
+// projectMigrations are applied in order after the project table is created.
+// The workspace table needs to be created before them
+var projectMigrations = []string{
+	`ALTER TABLE "project" ADD COLUMN IF NOT EXISTS workspace_id integer REFERENCES "workspace"(id)`,
+	`CREATE INDEX IF NOT EXISTS project_workspace_id_idx ON "project" (workspace_id)`,
+	`ALTER TABLE "project" ADD COLUMN IF NOT EXISTS workflow jsonb`,
+	`ALTER TABLE "project" ADD COLUMN IF NOT EXISTS estimate_unit varchar(10) NOT NULL DEFAULT 'minutes'`,
+}
+
+// MigrateProjectTable adds the columns and indexes that were introduced after the first version of the project table
+func (store *Repository) MigrateProjectTable() error {
+	for _, migration := range projectMigrations {
+		if _, err := store.DB.Exec(context.Background(), migration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (store *Repository) CreateProject(data *CreateProjectData, userId int64) (*Project, error) {
+	query := `INSERT INTO "project"(name, user_id, workspace_id, estimate_unit)
+		VALUES (@name, @userId, @workspaceId, @estimateUnit) RETURNING ` + projectColumns
+	args := pgx.NamedArgs{
+		"name":         data.Name,
+		"userId":       userId,
+		"workspaceId":  data.WorkspaceId,
+		"estimateUnit": data.EstimateUnit,
+	}
+
+	return ScanProject(store.DB.QueryRow(context.Background(), query, args))
+}
+
+// visibleCondition matches the projects of the user, the projects shared with the user
+// and the projects in the workspaces of the user
+const visibleCondition = `(user_id = @userId OR id IN (SELECT project_id FROM "project_share" WHERE user_id = @userId)
+	OR workspace_id IN (SELECT workspace_id FROM "workspace_member" WHERE user_id = @userId))`
+
+// personalCondition matches the projects that are not in one of the workspaces of the user.
+// Projects shared from the workspaces that the user isn't a member of are listed with the personal ones
+const personalCondition = `(workspace_id IS NULL
+	OR workspace_id NOT IN (SELECT workspace_id FROM "workspace_member" WHERE user_id = @userId))`
+
+// GetAllProjects returns the projects in the workspace, or the personal projects of the user
+// together with the projects shared with the user if the workspace is nil
+func (store *Repository) GetAllProjects(userId int64, workspaceId *int, includeArchived bool) ([]Project, error) {
+	query := `SELECT ` + projectColumns + ` FROM "project" WHERE ` + visibleCondition
+	args := pgx.NamedArgs{"userId": userId}
+	if workspaceId != nil {
+		query += ` AND workspace_id = @workspaceId`
+		args["workspaceId"] = *workspaceId
+	} else {
+		query += ` AND ` + personalCondition
+	}
+	if !includeArchived {
+		query += ` AND archived = false`
+	}
+	query += ` ORDER BY name, id`
+
+	rows, err := store.DB.Query(context.Background(), query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	projects := []Project{}
+
+	for rows.Next() {
+		p, err := ScanProject(rows)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, *p)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return projects, nil
+}
+
+// GetProject returns the project if it belongs to the user, is shared with the user or is in a workspace of the user
+func (store *Repository) GetProject(projectId int, userId int64) (*Project, error) {
+	query := `SELECT ` + projectColumns + ` FROM "project" WHERE id = @projectId AND ` + visibleCondition
+	args := pgx.NamedArgs{
+		"projectId": projectId,
+		"userId":    userId,
+	}
+
+	return ScanProject(store.DB.QueryRow(context.Background(), query, args))
+}
+
+// UpdateProject changes the project only if the user owns it. Workspace admins own the projects in the workspace
+func (store *Repository) UpdateProject(data *UpdateProjectData, userId int64) (*Project, error) {
+	var updates []string
+	args := pgx.NamedArgs{
+		"projectId": *data.Id,
+		"userId":    userId,
+		"updatedAt": time.Now(),
+	}
+
+	if data.Name != nil {
+		updates = append(updates, "name = @name")
+		args["name"] = *data.Name
+	}
+
+	if data.Archived != nil {
+		updates = append(updates, "archived = @archived")
+		args["archived"] = *data.Archived
+	}
+
+	if data.Workflow != nil {
+		updates = append(updates, "workflow = @workflow")
+		args["workflow"] = data.Workflow
+	}
+
+	if data.EstimateUnit != nil {
+		updates = append(updates, "estimate_unit = @estimateUnit")
+		args["estimateUnit"] = *data.EstimateUnit
+	}
+
+	if len(updates) == 0 {
+		return nil, ErrNoFieldToUpdate
+	}
+
+	query := fmt.Sprintf(
+		`UPDATE "project" SET %s, updated_at = @updatedAt WHERE id = @projectId AND project_role(id, @userId) = 'owner' RETURNING %s`,
+		strings.Join(updates, ", "),
+		projectColumns,
+	)
+
+	// todos keep their statuses if the new workflow has them. The done todos are moved to the new terminal status,
+	// and the others are moved to the first status if their status is removed or became the terminal one
+	if data.Workflow != nil {
+		statuses := make([]string, len(data.Workflow.Statuses))
+		for i, status := range data.Workflow.Statuses {
+			statuses[i] = status.Name
+		}
+		args["statuses"] = statuses
+		args["initial"] = data.Workflow.Initial()
+		args["terminal"] = data.Workflow.Terminal()
+
+		query = fmt.Sprintf(
+			`WITH updated AS (
+				UPDATE "project" SET %s, updated_at = @updatedAt WHERE id = @projectId AND project_role(id, @userId) = 'owner'
+				RETURNING *
+			), remapped AS (
+				UPDATE "todo" SET status = CASE
+					WHEN done THEN @terminal
+					WHEN status = ANY(@statuses::text[]) AND status <> @terminal THEN status
+					ELSE @initial
+				END
+				WHERE project_id IN (SELECT id FROM updated)
+			)
+			SELECT %s FROM updated "project"`,
+			strings.Join(updates, ", "),
+			projectColumns,
+		)
+	}
+
+	return ScanProject(store.DB.QueryRow(context.Background(), query, args))
+}
+
+// RemoveProject deletes the project if the user owns it. Its todos are moved to the trash in the same transaction
+// if trashTodos is set, otherwise they are moved to the inbox by the foreign key
+func (store *Repository) RemoveProject(projectId int, userId int64, trashTodos bool) (*Project, error) {
+	ctx := context.Background()
+	tx, err := store.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	args := pgx.NamedArgs{
+		"projectId": projectId,
+		"userId":    userId,
+	}
+
+	if trashTodos {
+		// the project is only deleted below if the user owns it, otherwise the transaction is rolled back
+		query := `UPDATE "todo" SET deleted_at = now() WHERE project_id = @projectId AND deleted_at IS NULL`
+		if _, err := tx.Exec(ctx, query, args); err != nil {
+			return nil, err
+		}
+	}
+
+	query := `DELETE FROM "project" WHERE id = @projectId AND project_role(id, @userId) = 'owner' RETURNING ` + projectColumns
+	p, err := ScanProject(tx.QueryRow(ctx, query, args))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
@@ -0,0 +1,131 @@
+package project
+
+import (
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/umtdemr/go-todo/logger"
+	"github.com/umtdemr/go-todo/share"
+	"unicode/utf8"
+)
+
+// TimerStopper stops the running timers of the todos in the trash. It is implemented by the time tracking service
+type TimerStopper interface {
+	StopTrashedTimers() (int64, error)
+}
+
+type Service struct {
+	Repository IRepository
+	// Timers is optional. The timers of the todos trashed with a project keep running if it isn't set
+	Timers TimerStopper
+}
+
+func NewProjectService(repo IRepository) *Service {
+	return &Service{Repository: repo}
+}
+
+// GetAllProjects lists the projects in the workspace. The personal projects are listed if the workspace is nil
+func (service *Service) GetAllProjects(userId int64, workspaceId *int, includeArchived bool) ([]Project, error) {
+	return service.Repository.GetAllProjects(userId, workspaceId, includeArchived)
+}
+
+func (service *Service) CreateProject(data *CreateProjectData, userId int64) (*Project, error) {
+	if err := validateName(data.Name); err != nil {
+		return nil, err
+	}
+
+	if data.EstimateUnit == "" {
+		data.EstimateUnit = EstimateUnitMinutes
+	}
+	if err := validateEstimateUnit(data.EstimateUnit); err != nil {
+		return nil, err
+	}
+	return service.Repository.CreateProject(data, userId)
+}
+
+func (service *Service) GetProject(projectId int, userId int64) (*Project, error) {
+	project, err := service.Repository.GetProject(projectId, userId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrProjectNotFound
+	}
+	return project, err
+}
+
+// UpdateProject renames, archives or unarchives a project, or changes its workflow or its estimate unit
+func (service *Service) UpdateProject(data *UpdateProjectData, userId int64) (*Project, error) {
+	if data.Id == nil {
+		return nil, ErrIdRequired
+	}
+
+	if data.Name != nil {
+		if err := validateName(*data.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	if data.Workflow != nil {
+		if err := data.Workflow.normalize(); err != nil {
+			return nil, err
+		}
+	}
+
+	if data.EstimateUnit != nil {
+		if err := validateEstimateUnit(*data.EstimateUnit); err != nil {
+			return nil, err
+		}
+	}
+
+	project, err := service.Repository.UpdateProject(data, userId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// the project may still be shared with the user
+		if _, getErr := service.GetProject(*data.Id, userId); getErr == nil {
+			return nil, ErrNotOwner
+		}
+		return nil, ErrProjectNotFound
+	}
+	return project, err
+}
+
+// RemoveProject deletes a project. Its todos are either moved to the inbox or to the trash according to the action
+func (service *Service) RemoveProject(projectId int, userId int64, action TodoAction) (*Project, error) {
+	if action != TodoActionMove && action != TodoActionDelete {
+		return nil, ErrTodoActionNotValid
+	}
+
+	// make sure that the project belongs to the user before touching its todos
+	p, err := service.GetProject(projectId, userId)
+	if err != nil {
+		return nil, err
+	}
+	if p.Role != share.RoleOwner {
+		return nil, ErrNotOwner
+	}
+
+	removed, err := service.Repository.RemoveProject(projectId, userId, action == TodoActionDelete)
+	if err != nil {
+		return nil, err
+	}
+
+	// timers left running on the trashed todos are stopped by the next removal if this fails
+	if action == TodoActionDelete && service.Timers != nil {
+		if _, err := service.Timers.StopTrashedTimers(); err != nil {
+			log := logger.Get()
+			log.Error().Err(err).Msg("Couldn't stop the timers of the removed todos")
+		}
+	}
+	return removed, nil
+}
+
+// validateEstimateUnit checks that the estimates are either in minutes or in points
+func validateEstimateUnit(unit string) error {
+	if unit != EstimateUnitMinutes && unit != EstimateUnitPoints {
+		return ErrEstimateUnitNotValid
+	}
+	return nil
+}
+
+func validateName(name string) error {
+	if nameLength := utf8.RuneCountInString(name); nameLength < 1 || nameLength > 255 {
+		return ErrNameLength
+	}
+	return nil
+}
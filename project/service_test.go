@@ -0,0 +1,217 @@
+package project
+
+import (
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/umtdemr/go-todo/share"
+	"strings"
+	"testing"
+)
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) CreateProject(data *CreateProjectData, userId int64) (*Project, error) {
+	args := m.Called(data, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Project), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) GetAllProjects(userId int64, workspaceId *int, includeArchived bool) ([]Project, error) {
+	args := m.Called(userId, workspaceId, includeArchived)
+	if args.Get(0) != nil {
+		return args.Get(0).([]Project), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) GetProject(projectId int, userId int64) (*Project, error) {
+	args := m.Called(projectId, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Project), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) UpdateProject(data *UpdateProjectData, userId int64) (*Project, error) {
+	args := m.Called(data, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Project), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) RemoveProject(projectId int, userId int64, trashTodos bool) (*Project, error) {
+	args := m.Called(projectId, userId, trashTodos)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Project), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+type MockTimerStopper struct {
+	mock.Mock
+}
+
+func (m *MockTimerStopper) StopTrashedTimers() (int64, error) {
+	args := m.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestCreateProject(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewProjectService(mockRepo)
+
+	tests := []struct {
+		name          string
+		input         *CreateProjectData
+		setupMock     func()
+		expectedError error
+	}{
+		{
+			name:          "Name is empty",
+			input:         &CreateProjectData{},
+			setupMock:     func() {},
+			expectedError: ErrNameLength,
+		},
+		{
+			name:          "Name is longer than 255",
+			input:         &CreateProjectData{Name: strings.Repeat("p", 256)},
+			setupMock:     func() {},
+			expectedError: ErrNameLength,
+		},
+		{
+			name:          "Estimate unit is not valid",
+			input:         &CreateProjectData{Name: "Work", EstimateUnit: "hours"},
+			setupMock:     func() {},
+			expectedError: ErrEstimateUnitNotValid,
+		},
+		{
+			name:  "Estimates are in minutes by default",
+			input: &CreateProjectData{Name: "Work"},
+			setupMock: func() {
+				mockRepo.On("CreateProject", &CreateProjectData{Name: "Work", EstimateUnit: EstimateUnitMinutes}, int64(1)).
+					Return(&Project{}, nil)
+			},
+			expectedError: nil,
+		},
+		{
+			name:  "Valid input",
+			input: &CreateProjectData{Name: "Work", EstimateUnit: EstimateUnitPoints},
+			setupMock: func() {
+				mockRepo.On("CreateProject", mock.Anything, int64(1)).Return(&Project{}, nil)
+			},
+			expectedError: nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.setupMock()
+			_, err := service.CreateProject(tc.input, 1)
+			assert.Equal(t, tc.expectedError, err)
+
+			mockRepo.ExpectedCalls = nil
+		})
+	}
+}
+
+func TestRemoveProject(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockTimers := new(MockTimerStopper)
+	service := NewProjectService(mockRepo)
+	service.Timers = mockTimers
+
+	tests := []struct {
+		name                string
+		action              TodoAction
+		setupMock           func()
+		expectedError       error
+		expectTodosRemoved  bool
+		expectProjectRemove bool
+		expectTimersStopped bool
+	}{
+		{
+			name:          "Action is not valid",
+			action:        "archive",
+			setupMock:     func() {},
+			expectedError: ErrTodoActionNotValid,
+		},
+		{
+			name:   "Project doesn't exist",
+			action: TodoActionMove,
+			setupMock: func() {
+				mockRepo.On("GetProject", 1, int64(1)).Return(nil, pgx.ErrNoRows)
+			},
+			expectedError: ErrProjectNotFound,
+		},
+		{
+			name:   "Project is shared with the user",
+			action: TodoActionDelete,
+			setupMock: func() {
+				mockRepo.On("GetProject", 1, int64(1)).Return(&Project{Id: 1, Role: share.RoleEditor}, nil)
+			},
+			expectedError: ErrNotOwner,
+		},
+		{
+			name:   "Todos are moved to the inbox",
+			action: TodoActionMove,
+			setupMock: func() {
+				mockRepo.On("GetProject", 1, int64(1)).Return(&Project{Id: 1, Role: share.RoleOwner}, nil)
+				mockRepo.On("RemoveProject", 1, int64(1), false).Return(&Project{Id: 1}, nil)
+			},
+			expectProjectRemove: true,
+		},
+		{
+			name:   "Todos are deleted with the project",
+			action: TodoActionDelete,
+			setupMock: func() {
+				mockRepo.On("GetProject", 1, int64(1)).Return(&Project{Id: 1, Role: share.RoleOwner}, nil)
+				mockRepo.On("RemoveProject", 1, int64(1), true).Return(&Project{Id: 1}, nil)
+				mockTimers.On("StopTrashedTimers").Return(int64(1), nil)
+			},
+			expectTodosRemoved:  true,
+			expectProjectRemove: true,
+			expectTimersStopped: true,
+		},
+		{
+			name:   "Todos aren't trashed if the project can't be deleted",
+			action: TodoActionDelete,
+			setupMock: func() {
+				mockRepo.On("GetProject", 1, int64(1)).Return(&Project{Id: 1, Role: share.RoleOwner}, nil)
+				mockRepo.On("RemoveProject", 1, int64(1), true).Return(nil, pgx.ErrTxClosed)
+			},
+			expectedError:       pgx.ErrTxClosed,
+			expectTodosRemoved:  true,
+			expectProjectRemove: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.setupMock()
+			_, err := service.RemoveProject(1, 1, tc.action)
+			assert.Equal(t, tc.expectedError, err)
+
+			if tc.expectProjectRemove {
+				mockRepo.AssertCalled(t, "RemoveProject", 1, int64(1), tc.expectTodosRemoved)
+			} else {
+				mockRepo.AssertNotCalled(t, "RemoveProject", mock.Anything, mock.Anything, mock.Anything)
+			}
+			if tc.expectTimersStopped {
+				mockTimers.AssertCalled(t, "StopTrashedTimers")
+			} else {
+				mockTimers.AssertNotCalled(t, "StopTrashedTimers")
+			}
+
+			mockRepo.ExpectedCalls = nil
+			mockRepo.Calls = nil
+			mockTimers.ExpectedCalls = nil
+			mockTimers.Calls = nil
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package project
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Status is a column of the board of a project
+type Status struct {
+	Name string `json:"name"`
+	// WipLimit is the maximum count of todos that should be in the status at once. 0 means no limit
+	WipLimit int `json:"wipLimit"`
+	// Next is the statuses that the todos in this status can be moved to. They can be moved to any status if it is empty
+	Next []string `json:"next,omitempty"`
+}
+
+// Workflow is the statuses that the todos of a project go through. New todos start in the first status,
+// and the todos in the last status are done
+type Workflow struct {
+	Statuses []Status `json:"statuses"`
+}
+
+const (
+	minStatusCount      = 2
+	maxStatusCount      = 20
+	maxStatusNameLength = 50
+)
+
+// DefaultWorkflow is the workflow of the projects that don't have their own and of the todos without a project.
+// Todos can be moved between any of its statuses
+func DefaultWorkflow() *Workflow {
+	return &Workflow{Statuses: []Status{
+		{Name: "backlog"},
+		{Name: "in_progress"},
+		{Name: "review"},
+		{Name: "done"},
+	}}
+}
+
+// Initial returns the status that the new todos start in
+func (w *Workflow) Initial() string {
+	return w.Statuses[0].Name
+}
+
+// Terminal returns the status of the done todos
+func (w *Workflow) Terminal() string {
+	return w.Statuses[len(w.Statuses)-1].Name
+}
+
+// Reopened returns the status that the todos are moved to when they are not done anymore.
+// It is the first transition of the terminal status, or the first status if the terminal status can move anywhere
+func (w *Workflow) Reopened() string {
+	if next := w.Statuses[len(w.Statuses)-1].Next; len(next) > 0 {
+		return next[0]
+	}
+	return w.Initial()
+}
+
+// Status returns the status with the name, or nil if the workflow doesn't have it
+func (w *Workflow) Status(name string) *Status {
+	for i := range w.Statuses {
+		if w.Statuses[i].Name == name {
+			return &w.Statuses[i]
+		}
+	}
+	return nil
+}
+
+// CanMove reports whether a todo can be moved from a status to another.
+// Todos in a status that the workflow doesn't have anymore can be moved anywhere
+func (w *Workflow) CanMove(from string, to string) bool {
+	if from == to {
+		return true
+	}
+	status := w.Status(from)
+	return status == nil || len(status.Next) == 0 || slices.Contains(status.Next, to)
+}
+
+// normalize trims the names of the statuses and checks that the workflow can be used
+func (w *Workflow) normalize() error {
+	if len(w.Statuses) < minStatusCount || len(w.Statuses) > maxStatusCount {
+		return ErrWorkflowNotValid
+	}
+
+	seen := make(map[string]bool, len(w.Statuses))
+	for i := range w.Statuses {
+		status := &w.Statuses[i]
+		status.Name = strings.TrimSpace(status.Name)
+		if nameLength := utf8.RuneCountInString(status.Name); nameLength < 1 || nameLength > maxStatusNameLength {
+			return ErrWorkflowNotValid
+		}
+		if seen[status.Name] || status.WipLimit < 0 {
+			return ErrWorkflowNotValid
+		}
+		seen[status.Name] = true
+	}
+
+	for i := range w.Statuses {
+		status := &w.Statuses[i]
+		for j, next := range status.Next {
+			status.Next[j] = strings.TrimSpace(next)
+			if !seen[status.Next[j]] || status.Next[j] == status.Name {
+				return ErrWorkflowNotValid
+			}
+		}
+	}
+	return nil
+}
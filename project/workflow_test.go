@@ -0,0 +1,66 @@
+package project
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestWorkflowNormalize(t *testing.T) {
+	tests := []struct {
+		name          string
+		workflow      Workflow
+		expectedError error
+	}{
+		{
+			name:          "Workflow needs at least two statuses",
+			workflow:      Workflow{Statuses: []Status{{Name: "done"}}},
+			expectedError: ErrWorkflowNotValid,
+		},
+		{
+			name:          "Status names are unique",
+			workflow:      Workflow{Statuses: []Status{{Name: "todo"}, {Name: " todo "}}},
+			expectedError: ErrWorkflowNotValid,
+		},
+		{
+			name:          "Transition goes to a status that doesn't exist",
+			workflow:      Workflow{Statuses: []Status{{Name: "todo", Next: []string{"doing"}}, {Name: "done"}}},
+			expectedError: ErrWorkflowNotValid,
+		},
+		{
+			name:          "WIP limit is negative",
+			workflow:      Workflow{Statuses: []Status{{Name: "todo", WipLimit: -1}, {Name: "done"}}},
+			expectedError: ErrWorkflowNotValid,
+		},
+		{
+			name:     "Names are trimmed",
+			workflow: Workflow{Statuses: []Status{{Name: " todo ", Next: []string{" done"}}, {Name: "done", WipLimit: 3}}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.workflow.normalize()
+			assert.Equal(t, tc.expectedError, err)
+			if tc.expectedError == nil {
+				assert.Equal(t, "todo", tc.workflow.Initial())
+				assert.Equal(t, []string{"done"}, tc.workflow.Statuses[0].Next)
+			}
+		})
+	}
+}
+
+func TestWorkflowCanMove(t *testing.T) {
+	workflow := &Workflow{Statuses: []Status{
+		{Name: "backlog", Next: []string{"doing"}},
+		{Name: "doing"},
+		{Name: "done", Next: []string{"doing"}},
+	}}
+
+	assert.True(t, workflow.CanMove("backlog", "doing"))
+	assert.False(t, workflow.CanMove("backlog", "done"))
+	// statuses without transitions can be moved anywhere
+	assert.True(t, workflow.CanMove("doing", "backlog"))
+	assert.True(t, workflow.CanMove("removed", "done"))
+	assert.Equal(t, "done", workflow.Terminal())
+	assert.Equal(t, "doing", workflow.Reopened())
+}
@@ -0,0 +1,145 @@
+package share
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/umtdemr/go-todo/server"
+	"github.com/umtdemr/go-todo/user"
+	"net/http"
+	"strconv"
+)
+
+type APIRoute struct {
+	Route   string
+	Service *Service
+}
+
+func NewShareAPIRoute(service *Service) *APIRoute {
+	return &APIRoute{Route: "share", Service: service}
+}
+
+// RegisterRoutes registers the routes for the share API of both todos and projects
+func (s *APIRoute) RegisterRoutes(router *mux.Router, userService user.Service) {
+	router.Handle("/todo/{id}/shares", userService.AuthMiddleware(s.handleListAndAdd(KindTodo)))
+	router.Handle("/todo/{id}/shares/{userId}", userService.AuthMiddleware(s.handleUpdateAndDelete(KindTodo)))
+	router.Handle("/project/{id}/shares", userService.AuthMiddleware(s.handleListAndAdd(KindProject)))
+	router.Handle("/project/{id}/shares/{userId}", userService.AuthMiddleware(s.handleUpdateAndDelete(KindProject)))
+}
+
+// respondWithShareError responds with the fields that caused the error if the error is a ShareError
+func respondWithShareError(w http.ResponseWriter, msg string, err error) {
+	var e ShareError
+	if errors.As(err, &e) {
+		server.RespondWithErrorFields(w, fmt.Sprintf("validation error: %v", e.Error()), http.StatusBadRequest, e.fields)
+		return
+	}
+	server.RespondWithError(w, fmt.Sprintf("%s: %s", msg, err), http.StatusBadRequest)
+}
+
+// parseIds parses the id of the todo or the project and the id of the member if the path has it
+func parseIds(r *http.Request, withMember bool) (int, int64, error) {
+	vars := mux.Vars(r)
+	targetId, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return 0, 0, server.ErrInvalidRequest.With("need a numeric value for the id")
+	}
+
+	if !withMember {
+		return targetId, 0, nil
+	}
+
+	memberId, err := strconv.ParseInt(vars["userId"], 10, 64)
+	if err != nil {
+		return 0, 0, server.ErrInvalidRequest.With("need a numeric value for the user id")
+	}
+	return targetId, memberId, nil
+}
+
+// handleListAndAdd lists the shares with GET and shares with a new user with POST
+func (s *APIRoute) handleListAndAdd(kind Kind) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// only GET and POST methods are allowed
+		if r.Method != http.MethodGet && r.Method != http.MethodPost {
+			err := server.ErrNotValidMethod.With("only GET and POST methods are allowed")
+			server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		targetId, _, parseErr := parseIds(r, false)
+		if parseErr != nil {
+			server.RespondWithError(w, parseErr.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// get the authenticated user from the context
+		authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+
+		if r.Method == http.MethodGet {
+			shares, err := s.Service.GetShares(kind, targetId, authenticatedUser.Id)
+			if err != nil {
+				respondWithShareError(w, "error while getting the shares", err)
+				return
+			}
+			server.RespondOK(w, shares)
+			return
+		}
+
+		var createData CreateShareData
+		if err := server.DecodeBody(r, &createData); err != nil {
+			server.RespondWithError(w, fmt.Sprintf("parsing error: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		created, err := s.Service.CreateShare(kind, targetId, authenticatedUser.Id, &createData)
+		if err != nil {
+			respondWithShareError(w, "error while sharing", err)
+			return
+		}
+		server.RespondCreated(w, created)
+	}
+}
+
+// handleUpdateAndDelete changes the role of the member with POST and revokes the share with DELETE
+func (s *APIRoute) handleUpdateAndDelete(kind Kind) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// only POST and DELETE methods are allowed
+		if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+			err := server.ErrNotValidMethod.With("only POST and DELETE methods are allowed")
+			server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		targetId, memberId, parseErr := parseIds(r, true)
+		if parseErr != nil {
+			server.RespondWithError(w, parseErr.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// get the authenticated user from the context
+		authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+
+		if r.Method == http.MethodDelete {
+			removed, err := s.Service.RemoveShare(kind, targetId, memberId, authenticatedUser.Id)
+			if err != nil {
+				respondWithShareError(w, "error while revoking the share", err)
+				return
+			}
+			server.RespondNoContent(w, removed)
+			return
+		}
+
+		var updateData UpdateShareData
+		if err := server.DecodeBody(r, &updateData); err != nil {
+			server.RespondWithError(w, fmt.Sprintf("error while parsing: %s", err), http.StatusBadRequest)
+			return
+		}
+
+		updated, err := s.Service.UpdateShare(kind, targetId, memberId, authenticatedUser.Id, &updateData)
+		if err != nil {
+			respondWithShareError(w, "error while updating the share", err)
+			return
+		}
+		server.RespondOK(w, updated)
+	}
+}
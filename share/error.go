@@ -0,0 +1,73 @@
+package share
+
+type errKind int
+
+const (
+	_ errKind = iota
+	roleNotValid
+	userRequired
+	userNotFound
+	alreadyOwner
+	noFieldToUpdate
+	todoNotFound
+	projectNotFound
+	shareNotFound
+	notOwner
+)
+
+type ShareError struct {
+	kind   errKind
+	fields []string
+}
+
+type Fields []string
+
+func (e ShareError) Error() string {
+	switch e.kind {
+	case roleNotValid:
+		return "role should be either viewer or editor"
+	case userRequired:
+		return "username or email of the user is required"
+	case userNotFound:
+		return "user not found"
+	case alreadyOwner:
+		return "user already owns it"
+	case noFieldToUpdate:
+		return "no field is provided"
+	case todoNotFound:
+		return "todo not found"
+	case projectNotFound:
+		return "project not found"
+	case shareNotFound:
+		return "share not found"
+	case notOwner:
+		return "only the owner can change the shares"
+	}
+	return "error in share"
+}
+
+// Is reports whether the target is a ShareError of the same kind so that errors.Is can be used
+func (e ShareError) Is(target error) bool {
+	t, ok := target.(ShareError)
+	return ok && t.kind == e.kind
+}
+
+var (
+	ErrRoleNotValid    = ShareError{kind: roleNotValid, fields: Fields{"role"}}
+	ErrUserRequired    = ShareError{kind: userRequired, fields: Fields{"user"}}
+	ErrUserNotFound    = ShareError{kind: userNotFound, fields: Fields{"user"}}
+	ErrAlreadyOwner    = ShareError{kind: alreadyOwner, fields: Fields{"user"}}
+	ErrNoFieldToUpdate = ShareError{kind: noFieldToUpdate}
+	ErrTodoNotFound    = ShareError{kind: todoNotFound}
+	ErrProjectNotFound = ShareError{kind: projectNotFound}
+	ErrShareNotFound   = ShareError{kind: shareNotFound}
+	ErrNotOwner        = ShareError{kind: notOwner}
+)
+
+// notFound returns the error for a todo or a project that doesn't exist or isn't visible to the user
+func notFound(kind Kind) ShareError {
+	if kind == KindProject {
+		return ErrProjectNotFound
+	}
+	return ErrTodoNotFound
+}
@@ -0,0 +1,198 @@
+package share
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type IRepository interface {
+	GetRole(kind Kind, targetId int, userId int64) (Role, error)
+	GetShares(kind Kind, targetId int) ([]Share, error)
+	SaveShare(kind Kind, targetId int, memberId int64, role Role) (*Share, error)
+	UpdateShare(kind Kind, targetId int, memberId int64, role Role) (*Share, error)
+	RemoveShare(kind Kind, targetId int, memberId int64) (*Share, error)
+}
+
+type Repository struct {
+	DB *pgxpool.Pool
+}
+
+func NewShareRepository(dbConn *pgxpool.Pool) (*Repository, error) {
+	return &Repository{dbConn}, nil
+}
+
+// Init creates the share tables and the functions that the todo and the project queries use for checking
+// the access of a user. It needs to run after the workspace, the todo and the project tables are created
+func (store *Repository) Init() error {
+	for _, query := range shareQueries {
+		if _, err := store.DB.Exec(context.Background(), query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// shareQueries create the share tables and the access functions. They need to be idempotent since they run on every start
+var shareQueries = []string{
+	`CREATE TABLE IF NOT EXISTS "project_share" (
+		project_id integer NOT NULL REFERENCES "project"(id) ON DELETE CASCADE,
+		user_id integer NOT NULL REFERENCES "user"(id) ON DELETE CASCADE,
+		role varchar(10) NOT NULL CHECK (role IN ('viewer', 'editor')),
+		created_at timestamp DEFAULT now(),
+		PRIMARY KEY (project_id, user_id)
+	)`,
+	`CREATE INDEX IF NOT EXISTS project_share_user_id_idx ON "project_share" (user_id)`,
+	`CREATE TABLE IF NOT EXISTS "todo_share" (
+		todo_id integer NOT NULL REFERENCES "todo"(id) ON DELETE CASCADE,
+		user_id integer NOT NULL REFERENCES "user"(id) ON DELETE CASCADE,
+		role varchar(10) NOT NULL CHECK (role IN ('viewer', 'editor')),
+		created_at timestamp DEFAULT now(),
+		PRIMARY KEY (todo_id, user_id)
+	)`,
+	`CREATE INDEX IF NOT EXISTS todo_share_user_id_idx ON "todo_share" (user_id)`,
+	// the role of a user on a project is the highest role from owning it, its workspace and its shares.
+	// Workspace owners and admins own the projects in the workspace, and the members can edit them
+	`CREATE OR REPLACE FUNCTION project_role(p_id integer, u_id bigint) RETURNS text AS $$
+		WITH roles AS (
+			SELECT 'owner' AS role FROM "project" p WHERE p.id = p_id AND p.user_id = u_id
+			UNION ALL
+			SELECT CASE wm.role WHEN 'member' THEN 'editor' ELSE 'owner' END
+			FROM "project" p JOIN "workspace_member" wm ON wm.workspace_id = p.workspace_id
+			WHERE p.id = p_id AND wm.user_id = u_id
+			UNION ALL
+			SELECT ps.role FROM "project_share" ps WHERE ps.project_id = p_id AND ps.user_id = u_id
+		)
+		SELECT role FROM roles ORDER BY CASE role WHEN 'owner' THEN 3 WHEN 'editor' THEN 2 ELSE 1 END DESC LIMIT 1
+	$$ LANGUAGE sql STABLE`,
+	// the role of a user on a todo is the highest role the user has on the todo, on one of its parents,
+	// on the project of one of them or on their workspace. Owners of a project own the todos in it
+	`CREATE OR REPLACE FUNCTION todo_role(t_id integer, u_id bigint) RETURNS text AS $$
+		WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id, user_id, project_id, workspace_id FROM "todo" WHERE id = t_id
+			UNION
+			SELECT parent.id, parent.parent_id, parent.user_id, parent.project_id, parent.workspace_id
+			FROM "todo" parent JOIN ancestors ON parent.id = ancestors.parent_id
+		),
+		roles AS (
+			SELECT 'owner' AS role FROM ancestors WHERE ancestors.user_id = u_id
+			UNION ALL
+			SELECT 'owner' FROM ancestors JOIN "project" p ON p.id = ancestors.project_id WHERE p.user_id = u_id
+			UNION ALL
+			SELECT ts.role FROM ancestors JOIN "todo_share" ts ON ts.todo_id = ancestors.id WHERE ts.user_id = u_id
+			UNION ALL
+			SELECT ps.role FROM ancestors JOIN "project_share" ps ON ps.project_id = ancestors.project_id WHERE ps.user_id = u_id
+			UNION ALL
+			SELECT CASE wm.role WHEN 'member' THEN 'editor' ELSE 'owner' END
+			FROM ancestors JOIN "workspace_member" wm ON wm.workspace_id = ancestors.workspace_id WHERE wm.user_id = u_id
+		)
+		SELECT role FROM roles ORDER BY CASE role WHEN 'owner' THEN 3 WHEN 'editor' THEN 2 ELSE 1 END DESC LIMIT 1
+	$$ LANGUAGE sql STABLE`,
+	// shared_todo_ids returns the todos that are visible to a user without being owned by the user
+	`CREATE OR REPLACE FUNCTION shared_todo_ids(u_id bigint) RETURNS TABLE (id integer) AS $$
+		WITH RECURSIVE shared AS (
+			SELECT t.id FROM "todo" t
+			WHERE t.project_id IN (SELECT p.id FROM "project" p WHERE p.user_id = u_id)
+				OR t.project_id IN (SELECT ps.project_id FROM "project_share" ps WHERE ps.user_id = u_id)
+				OR t.id IN (SELECT ts.todo_id FROM "todo_share" ts WHERE ts.user_id = u_id)
+				OR t.workspace_id IN (SELECT wm.workspace_id FROM "workspace_member" wm WHERE wm.user_id = u_id)
+			UNION
+			SELECT child.id FROM "todo" child JOIN shared ON child.parent_id = shared.id
+		)
+		SELECT shared.id FROM shared
+	$$ LANGUAGE sql STABLE`,
+}
+
+// GetRole returns the role of the user on the todo or the project. It is empty if the user can't see it
+func (store *Repository) GetRole(kind Kind, targetId int, userId int64) (Role, error) {
+	query := `SELECT todo_role(id, @userId) FROM "todo" WHERE id = @targetId AND deleted_at IS NULL`
+	if kind == KindProject {
+		query = `SELECT project_role(id, @userId) FROM "project" WHERE id = @targetId`
+	}
+	args := pgx.NamedArgs{
+		"targetId": targetId,
+		"userId":   userId,
+	}
+
+	var role *string
+	err := store.DB.QueryRow(context.Background(), query, args).Scan(&role)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && role == nil) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return Role(*role), nil
+}
+
+// GetShares returns the users that the todo or the project is shared with in the order they were added
+func (store *Repository) GetShares(kind Kind, targetId int) ([]Share, error) {
+	table, column := kind.table()
+	query := fmt.Sprintf(`SELECT %s FROM %s s JOIN "user" u ON u.id = s.user_id
+		WHERE s.%s = @targetId ORDER BY s.created_at, u.id`, shareColumns, table, column)
+	args := pgx.NamedArgs{"targetId": targetId}
+
+	rows, err := store.DB.Query(context.Background(), query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shares := []Share{}
+	for rows.Next() {
+		s, err := ScanShare(rows)
+		if err != nil {
+			return nil, err
+		}
+		shares = append(shares, *s)
+	}
+	return shares, rows.Err()
+}
+
+// SaveShare shares the todo or the project with the member. The role is replaced if it is already shared with the member
+func (store *Repository) SaveShare(kind Kind, targetId int, memberId int64, role Role) (*Share, error) {
+	table, column := kind.table()
+	query := fmt.Sprintf(`WITH s AS (
+			INSERT INTO %[1]s(%[2]s, user_id, role) VALUES (@targetId, @memberId, @role)
+			ON CONFLICT (%[2]s, user_id) DO UPDATE SET role = EXCLUDED.role RETURNING *
+		)
+		SELECT %[3]s FROM s JOIN "user" u ON u.id = s.user_id`, table, column, shareColumns)
+	args := pgx.NamedArgs{
+		"targetId": targetId,
+		"memberId": memberId,
+		"role":     role,
+	}
+
+	return ScanShare(store.DB.QueryRow(context.Background(), query, args))
+}
+
+func (store *Repository) UpdateShare(kind Kind, targetId int, memberId int64, role Role) (*Share, error) {
+	table, column := kind.table()
+	query := fmt.Sprintf(`WITH s AS (
+			UPDATE %s SET role = @role WHERE %s = @targetId AND user_id = @memberId RETURNING *
+		)
+		SELECT %s FROM s JOIN "user" u ON u.id = s.user_id`, table, column, shareColumns)
+	args := pgx.NamedArgs{
+		"targetId": targetId,
+		"memberId": memberId,
+		"role":     role,
+	}
+
+	return ScanShare(store.DB.QueryRow(context.Background(), query, args))
+}
+
+func (store *Repository) RemoveShare(kind Kind, targetId int, memberId int64) (*Share, error) {
+	table, column := kind.table()
+	query := fmt.Sprintf(`WITH s AS (
+			DELETE FROM %s WHERE %s = @targetId AND user_id = @memberId RETURNING *
+		)
+		SELECT %s FROM s JOIN "user" u ON u.id = s.user_id`, table, column, shareColumns)
+	args := pgx.NamedArgs{
+		"targetId": targetId,
+		"memberId": memberId,
+	}
+
+	return ScanShare(store.DB.QueryRow(context.Background(), query, args))
+}
@@ -0,0 +1,118 @@
+package share
+
+import (
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/umtdemr/go-todo/user"
+	"strings"
+)
+
+// UserFinder looks up the user that a todo or a project is shared with. It is implemented by the user service
+type UserFinder interface {
+	FindUser(identifier string) *user.VisibleUser
+}
+
+type Service struct {
+	Repository IRepository
+	Users      UserFinder
+}
+
+func NewShareService(repo IRepository, users UserFinder) *Service {
+	return &Service{Repository: repo, Users: users}
+}
+
+// checkRole makes sure that the user has at least the needed role on the todo or the project
+func (service *Service) checkRole(kind Kind, targetId int, userId int64, needed Role) error {
+	role, err := service.Repository.GetRole(kind, targetId, userId)
+	if err != nil {
+		return err
+	}
+
+	if role == "" {
+		return notFound(kind)
+	}
+	if !role.Includes(needed) {
+		return ErrNotOwner
+	}
+	return nil
+}
+
+// CreateShare shares the todo or the project of the owner with the user found by the username or the email.
+// Sharing it again with the same user changes the role
+func (service *Service) CreateShare(kind Kind, targetId int, ownerId int64, data *CreateShareData) (*Share, error) {
+	if err := validateRole(data.Role); err != nil {
+		return nil, err
+	}
+
+	identifier := strings.TrimSpace(data.User)
+	if identifier == "" {
+		return nil, ErrUserRequired
+	}
+
+	if err := service.checkRole(kind, targetId, ownerId, RoleOwner); err != nil {
+		return nil, err
+	}
+
+	member := service.Users.FindUser(identifier)
+	if member == nil {
+		return nil, ErrUserNotFound
+	}
+
+	// the owner of a project owns its todos too, so there is nothing to share with them
+	memberRole, err := service.Repository.GetRole(kind, targetId, member.Id)
+	if err != nil {
+		return nil, err
+	}
+	if memberRole == RoleOwner {
+		return nil, ErrAlreadyOwner
+	}
+
+	return service.Repository.SaveShare(kind, targetId, member.Id, data.Role)
+}
+
+// GetShares lists the users that the todo or the project is shared with. Everyone who can see it can see the list
+func (service *Service) GetShares(kind Kind, targetId int, userId int64) ([]Share, error) {
+	if err := service.checkRole(kind, targetId, userId, RoleViewer); err != nil {
+		return nil, err
+	}
+	return service.Repository.GetShares(kind, targetId)
+}
+
+// UpdateShare changes the role of the member
+func (service *Service) UpdateShare(kind Kind, targetId int, memberId int64, ownerId int64, data *UpdateShareData) (*Share, error) {
+	if data.Role == nil {
+		return nil, ErrNoFieldToUpdate
+	}
+
+	if err := validateRole(*data.Role); err != nil {
+		return nil, err
+	}
+
+	if err := service.checkRole(kind, targetId, ownerId, RoleOwner); err != nil {
+		return nil, err
+	}
+
+	updated, err := service.Repository.UpdateShare(kind, targetId, memberId, *data.Role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrShareNotFound
+	}
+	return updated, err
+}
+
+// RemoveShare revokes the access of the member. Members can remove their own share to leave
+func (service *Service) RemoveShare(kind Kind, targetId int, memberId int64, userId int64) (*Share, error) {
+	needed := RoleOwner
+	if memberId == userId {
+		needed = RoleViewer
+	}
+
+	if err := service.checkRole(kind, targetId, userId, needed); err != nil {
+		return nil, err
+	}
+
+	removed, err := service.Repository.RemoveShare(kind, targetId, memberId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrShareNotFound
+	}
+	return removed, err
+}
@@ -0,0 +1,203 @@
+package share
+
+import (
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/umtdemr/go-todo/user"
+	"testing"
+)
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) GetRole(kind Kind, targetId int, userId int64) (Role, error) {
+	args := m.Called(kind, targetId, userId)
+	return args.Get(0).(Role), args.Error(1)
+}
+
+func (m *MockRepository) GetShares(kind Kind, targetId int) ([]Share, error) {
+	args := m.Called(kind, targetId)
+	return args.Get(0).([]Share), args.Error(1)
+}
+
+func (m *MockRepository) SaveShare(kind Kind, targetId int, memberId int64, role Role) (*Share, error) {
+	args := m.Called(kind, targetId, memberId, role)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Share), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) UpdateShare(kind Kind, targetId int, memberId int64, role Role) (*Share, error) {
+	args := m.Called(kind, targetId, memberId, role)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Share), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) RemoveShare(kind Kind, targetId int, memberId int64) (*Share, error) {
+	args := m.Called(kind, targetId, memberId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Share), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+type MockUserFinder struct {
+	mock.Mock
+}
+
+func (m *MockUserFinder) FindUser(identifier string) *user.VisibleUser {
+	args := m.Called(identifier)
+	if args.Get(0) != nil {
+		return args.Get(0).(*user.VisibleUser)
+	}
+	return nil
+}
+
+func TestRoleIncludes(t *testing.T) {
+	assert.True(t, RoleOwner.Includes(RoleEditor))
+	assert.True(t, RoleEditor.Includes(RoleEditor))
+	assert.True(t, RoleEditor.Includes(RoleViewer))
+	assert.False(t, RoleViewer.Includes(RoleEditor))
+	assert.False(t, Role("").Includes(RoleViewer))
+	assert.False(t, RoleOwner.Includes(""))
+}
+
+func TestCreateShare(t *testing.T) {
+	tests := []struct {
+		name          string
+		data          CreateShareData
+		setupMock     func(repo *MockRepository, users *MockUserFinder)
+		expectedError error
+	}{
+		{
+			name:          "Role is not valid",
+			data:          CreateShareData{User: "deniz", Role: RoleOwner},
+			setupMock:     func(repo *MockRepository, users *MockUserFinder) {},
+			expectedError: ErrRoleNotValid,
+		},
+		{
+			name:          "User isn't sent",
+			data:          CreateShareData{User: " ", Role: RoleViewer},
+			setupMock:     func(repo *MockRepository, users *MockUserFinder) {},
+			expectedError: ErrUserRequired,
+		},
+		{
+			name: "Todo isn't visible to the user",
+			data: CreateShareData{User: "deniz", Role: RoleViewer},
+			setupMock: func(repo *MockRepository, users *MockUserFinder) {
+				repo.On("GetRole", KindTodo, 3, int64(1)).Return(Role(""), nil)
+			},
+			expectedError: ErrTodoNotFound,
+		},
+		{
+			name: "Editors can't share",
+			data: CreateShareData{User: "deniz", Role: RoleViewer},
+			setupMock: func(repo *MockRepository, users *MockUserFinder) {
+				repo.On("GetRole", KindTodo, 3, int64(1)).Return(RoleEditor, nil)
+			},
+			expectedError: ErrNotOwner,
+		},
+		{
+			name: "User doesn't exist",
+			data: CreateShareData{User: "deniz@example.com", Role: RoleViewer},
+			setupMock: func(repo *MockRepository, users *MockUserFinder) {
+				repo.On("GetRole", KindTodo, 3, int64(1)).Return(RoleOwner, nil)
+				users.On("FindUser", "deniz@example.com").Return(nil)
+			},
+			expectedError: ErrUserNotFound,
+		},
+		{
+			name: "User already owns the todo",
+			data: CreateShareData{User: "umit", Role: RoleViewer},
+			setupMock: func(repo *MockRepository, users *MockUserFinder) {
+				repo.On("GetRole", KindTodo, 3, int64(1)).Return(RoleOwner, nil)
+				users.On("FindUser", "umit").Return(&user.VisibleUser{Id: 1})
+			},
+			expectedError: ErrAlreadyOwner,
+		},
+		{
+			name: "Todo is shared with the user",
+			data: CreateShareData{User: " deniz ", Role: RoleEditor},
+			setupMock: func(repo *MockRepository, users *MockUserFinder) {
+				repo.On("GetRole", KindTodo, 3, int64(1)).Return(RoleOwner, nil)
+				users.On("FindUser", "deniz").Return(&user.VisibleUser{Id: 2})
+				repo.On("GetRole", KindTodo, 3, int64(2)).Return(RoleViewer, nil)
+				repo.On("SaveShare", KindTodo, 3, int64(2), RoleEditor).Return(&Share{Role: RoleEditor}, nil)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockUsers := new(MockUserFinder)
+			service := NewShareService(mockRepo, mockUsers)
+			tc.setupMock(mockRepo, mockUsers)
+
+			_, err := service.CreateShare(KindTodo, 3, 1, &tc.data)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+			} else {
+				assert.Nil(t, err)
+			}
+			mockRepo.AssertExpectations(t)
+			mockUsers.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRemoveShare(t *testing.T) {
+	tests := []struct {
+		name          string
+		userId        int64
+		setupMock     func(repo *MockRepository)
+		expectedError error
+	}{
+		{
+			name:   "Editors can't revoke the shares of others",
+			userId: 3,
+			setupMock: func(repo *MockRepository) {
+				repo.On("GetRole", KindProject, 7, int64(3)).Return(RoleEditor, nil)
+			},
+			expectedError: ErrNotOwner,
+		},
+		{
+			name:   "Members can leave",
+			userId: 2,
+			setupMock: func(repo *MockRepository) {
+				repo.On("GetRole", KindProject, 7, int64(2)).Return(RoleViewer, nil)
+				repo.On("RemoveShare", KindProject, 7, int64(2)).Return(&Share{}, nil)
+			},
+		},
+		{
+			name:   "Share doesn't exist",
+			userId: 1,
+			setupMock: func(repo *MockRepository) {
+				repo.On("GetRole", KindProject, 7, int64(1)).Return(RoleOwner, nil)
+				repo.On("RemoveShare", KindProject, 7, int64(2)).Return(nil, pgx.ErrNoRows)
+			},
+			expectedError: ErrShareNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			service := NewShareService(mockRepo, new(MockUserFinder))
+			tc.setupMock(mockRepo)
+
+			_, err := service.RemoveShare(KindProject, 7, 2, tc.userId)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+			} else {
+				assert.Nil(t, err)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
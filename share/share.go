@@ -0,0 +1,93 @@
+package share
+
+import (
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+// Role is the access a user has to a todo or a project
+type Role string
+
+const (
+	// RoleViewer can see the shared todo or project
+	RoleViewer Role = "viewer"
+	// RoleEditor can change the shared todo or project and add todos to it
+	RoleEditor Role = "editor"
+	// RoleOwner is the role of the user who owns the todo or the project. It can't be given with a share
+	RoleOwner Role = "owner"
+)
+
+// rank orders the roles so that a role includes the access of the roles with a lower rank
+func (role Role) rank() int {
+	switch role {
+	case RoleViewer:
+		return 1
+	case RoleEditor:
+		return 2
+	case RoleOwner:
+		return 3
+	}
+	return 0
+}
+
+// Includes reports whether the role gives at least the access of the other role
+func (role Role) Includes(other Role) bool {
+	return other.rank() > 0 && role.rank() >= other.rank()
+}
+
+// Kind is the kind of the item that is shared
+type Kind string
+
+const (
+	KindTodo    Kind = "todo"
+	KindProject Kind = "project"
+)
+
+// table returns the table keeping the shares of the kind and the column referencing the shared item
+func (kind Kind) table() (string, string) {
+	if kind == KindProject {
+		return `"project_share"`, "project_id"
+	}
+	return `"todo_share"`, "todo_id"
+}
+
+type Member struct {
+	Id       int64  `json:"id"`
+	Username string `json:"username"`
+}
+
+type Share struct {
+	User      Member    `json:"user"`
+	Role      Role      `json:"role"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type CreateShareData struct {
+	// User is the username or the email of the user
+	User string `json:"user"`
+	Role Role   `json:"role"`
+}
+
+type UpdateShareData struct {
+	Role *Role `json:"role,omitempty"`
+}
+
+// shareColumns are the columns selected for scanning a share with ScanShare. The share table is aliased as s
+const shareColumns = `u.id, u.username, s.role, s.created_at`
+
+func ScanShare(row pgx.Row) (*Share, error) {
+	s := new(Share)
+	err := row.Scan(&s.User.Id, &s.User.Username, &s.Role, &s.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// validateRole checks that the role can be given with a share
+func validateRole(role Role) error {
+	if role != RoleViewer && role != RoleEditor {
+		return ErrRoleNotValid
+	}
+	return nil
+}
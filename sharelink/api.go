@@ -0,0 +1,194 @@
+package sharelink
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/umtdemr/go-todo/server"
+	"github.com/umtdemr/go-todo/user"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// PasswordHeader is the header that has the password of a protected link. Passwords are not accepted in the query,
+// since the urls of the requests are logged
+const PasswordHeader = "X-Share-Password"
+
+type APIRoute struct {
+	Route   string
+	Service *Service
+}
+
+func NewShareLinkAPIRoute(service *Service) *APIRoute {
+	return &APIRoute{Route: "link", Service: service}
+}
+
+// RegisterRoutes registers the routes for managing the links and the public route for opening them.
+// The public route doesn't need authentication
+func (s *APIRoute) RegisterRoutes(router *mux.Router, userService user.Service) {
+	router.Handle("/link", userService.AuthMiddleware(http.HandlerFunc(s.handleList)))
+	router.Handle("/link/create", userService.AuthMiddleware(http.HandlerFunc(s.handleAdd)))
+	router.Handle("/link/{linkId}", userService.AuthMiddleware(http.HandlerFunc(s.handleFetchAndDelete)))
+	router.HandleFunc("/share/{token}", s.handleOpen)
+}
+
+// respondWithLinkError responds with the fields that caused the error if the error is a LinkError
+func respondWithLinkError(w http.ResponseWriter, msg string, err error) {
+	var e LinkError
+	if errors.As(err, &e) {
+		server.RespondWithErrorFields(w, fmt.Sprintf("validation error: %v", e.Error()), http.StatusBadRequest, e.fields)
+		return
+	}
+	server.RespondWithError(w, fmt.Sprintf("%s: %s", msg, err), http.StatusBadRequest)
+}
+
+// handleList lists the links that the user created
+func (s *APIRoute) handleList(w http.ResponseWriter, r *http.Request) {
+	// only GET methods are allowed
+	if r.Method != http.MethodGet {
+		err := server.ErrNotValidMethod.With("only GET methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+	links, err := s.Service.GetLinks(authenticatedUser.Id)
+	if err != nil {
+		respondWithLinkError(w, "error while getting the links", err)
+		return
+	}
+	server.RespondOK(w, links)
+}
+
+// handleAdd creates a link. The token of the link is only in this response
+func (s *APIRoute) handleAdd(w http.ResponseWriter, r *http.Request) {
+	// only POST methods are allowed
+	if r.Method != http.MethodPost {
+		err := server.ErrNotValidMethod.With("only POST methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var createData CreateLinkData
+	if err := server.DecodeBody(r, &createData); err != nil {
+		server.RespondWithError(w, fmt.Sprintf("parsing error: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+	created, err := s.Service.CreateLink(&createData, authenticatedUser.Id)
+	if err != nil {
+		respondWithLinkError(w, "error while creating the link", err)
+		return
+	}
+	server.RespondCreated(w, created)
+}
+
+// handleFetchAndDelete fetches the link with GET and revokes it with DELETE
+func (s *APIRoute) handleFetchAndDelete(w http.ResponseWriter, r *http.Request) {
+	// only GET and DELETE methods are allowed
+	if r.Method != http.MethodGet && r.Method != http.MethodDelete {
+		err := server.ErrNotValidMethod.With("only GET and DELETE methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	linkId, parseErr := strconv.Atoi(mux.Vars(r)["linkId"])
+	if parseErr != nil {
+		err := server.ErrInvalidRequest.With("need a numeric value for the link id")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+
+	if r.Method == http.MethodDelete {
+		removed, err := s.Service.RevokeLink(linkId, authenticatedUser.Id)
+		if err != nil {
+			respondWithLinkError(w, "error while revoking the link", err)
+			return
+		}
+		server.RespondNoContent(w, removed)
+		return
+	}
+
+	link, err := s.Service.GetLink(linkId, authenticatedUser.Id)
+	if err != nil {
+		respondWithLinkError(w, "error while getting the link", err)
+		return
+	}
+	server.RespondOK(w, link)
+}
+
+// wantsHTML reports whether the link is opened in a browser. format=json or format=html in the query
+// overrides the Accept header
+func wantsHTML(r *http.Request) bool {
+	switch r.URL.Query().Get("format") {
+	case "html":
+		return true
+	case "json":
+		return false
+	}
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+// handleOpen shows the todos of a link to anyone who has its token. The browsers get an HTML page,
+// and the others get JSON. The password of a protected link is sent with the X-Share-Password header,
+// or with the form of the page
+func (s *APIRoute) handleOpen(w http.ResponseWriter, r *http.Request) {
+	// only GET and POST methods are allowed. POST is used by the password form
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		err := server.ErrNotValidMethod.With("only GET and POST methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// the pages shouldn't be cached, indexed or leak the token to the links in the notes
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("X-Robots-Tag", "noindex")
+
+	password := r.Header.Get(PasswordHeader)
+	if r.Method == http.MethodPost {
+		if formPassword := r.PostFormValue("password"); formPassword != "" {
+			password = formPassword
+		}
+	}
+
+	list, err := s.Service.OpenLink(mux.Vars(r)["token"], password)
+	html := wantsHTML(r)
+
+	switch {
+	case err == nil:
+		if html {
+			renderPage(w, pageData{List: list}, http.StatusOK)
+			return
+		}
+		server.RespondOK(w, list)
+	case errors.Is(err, ErrLinkNotFound):
+		if html {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		server.RespondWithError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrPasswordRequired), errors.Is(err, ErrPasswordNotValid):
+		if html {
+			data := pageData{}
+			if errors.Is(err, ErrPasswordNotValid) {
+				data.Error = err.Error()
+			}
+			renderPage(w, data, http.StatusUnauthorized)
+			return
+		}
+		server.RespondWithErrorFields(w, err.Error(), http.StatusUnauthorized, Fields{"password"})
+	case errors.Is(err, ErrTooManyAttempts):
+		if html {
+			renderPage(w, pageData{Error: err.Error()}, http.StatusTooManyRequests)
+			return
+		}
+		server.RespondWithErrorFields(w, err.Error(), http.StatusTooManyRequests, Fields{"password"})
+	default:
+		server.RespondWithError(w, fmt.Sprintf("error while opening the link: %s", err), http.StatusBadRequest)
+	}
+}
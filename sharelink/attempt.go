@@ -0,0 +1,70 @@
+package sharelink
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// maxPasswordAttempts is the count of the wrong passwords that a link accepts in passwordAttemptWindow.
+	// The password isn't checked after that until the window is over
+	maxPasswordAttempts   = 5
+	passwordAttemptWindow = 15 * time.Minute
+)
+
+// passwordAttempts is the count of the wrong passwords sent to a link since the first one in the window
+type passwordAttempts struct {
+	count int
+	since time.Time
+}
+
+// attemptLimiter keeps the wrong passwords of the links in memory, so the limit is per server
+type attemptLimiter struct {
+	mu       sync.Mutex
+	attempts map[int]*passwordAttempts
+}
+
+func newAttemptLimiter() *attemptLimiter {
+	return &attemptLimiter{attempts: make(map[int]*passwordAttempts)}
+}
+
+// allowed reports whether the password of the link can be checked
+func (l *attemptLimiter) allowed(linkId int, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	a, ok := l.attempts[linkId]
+	if !ok {
+		return true
+	}
+	if now.Sub(a.since) >= passwordAttemptWindow {
+		delete(l.attempts, linkId)
+		return true
+	}
+	return a.count < maxPasswordAttempts
+}
+
+// fail counts a wrong password of the link. The links whose windows are over are forgotten
+func (l *attemptLimiter) fail(linkId int, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for id, a := range l.attempts {
+		if now.Sub(a.since) >= passwordAttemptWindow {
+			delete(l.attempts, id)
+		}
+	}
+
+	if a, ok := l.attempts[linkId]; ok {
+		a.count++
+		return
+	}
+	l.attempts[linkId] = &passwordAttempts{count: 1, since: now}
+}
+
+// reset forgets the wrong passwords of the link after the right one is sent
+func (l *attemptLimiter) reset(linkId int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.attempts, linkId)
+}
@@ -0,0 +1,70 @@
+package sharelink
+
+type errKind int
+
+const (
+	_ errKind = iota
+	targetRequired
+	nameLength
+	passwordLength
+	expiresAtNotValid
+	projectNotFound
+	notOwner
+	linkNotFound
+	passwordRequired
+	passwordNotValid
+	tooManyAttempts
+)
+
+type LinkError struct {
+	kind   errKind
+	fields []string
+}
+
+type Fields []string
+
+func (e LinkError) Error() string {
+	switch e.kind {
+	case targetRequired:
+		return "project or filter is required"
+	case nameLength:
+		return "name length should be between 1 and 255"
+	case passwordLength:
+		return "password length should be between 4 and 64"
+	case expiresAtNotValid:
+		return "expiry should be in the future"
+	case projectNotFound:
+		return "project not found"
+	case notOwner:
+		return "only the owner can share the project with a link"
+	case linkNotFound:
+		return "link not found"
+	case passwordRequired:
+		return "password is required"
+	case passwordNotValid:
+		return "password is not correct"
+	case tooManyAttempts:
+		return "too many wrong passwords, try again later"
+	}
+	return "error in share link"
+}
+
+// Is reports whether the target is a LinkError of the same kind so that errors.Is can be used
+func (e LinkError) Is(target error) bool {
+	t, ok := target.(LinkError)
+	return ok && t.kind == e.kind
+}
+
+var (
+	ErrTargetRequired    = LinkError{kind: targetRequired, fields: Fields{"projectId", "filter"}}
+	ErrNameLength        = LinkError{kind: nameLength, fields: Fields{"name"}}
+	ErrPasswordLength    = LinkError{kind: passwordLength, fields: Fields{"password"}}
+	ErrExpiresAtNotValid = LinkError{kind: expiresAtNotValid, fields: Fields{"expiresAt"}}
+	ErrProjectNotFound   = LinkError{kind: projectNotFound, fields: Fields{"projectId"}}
+	ErrNotOwner          = LinkError{kind: notOwner}
+	// ErrLinkNotFound is also returned for the expired links, so that the visitors can't tell them apart
+	ErrLinkNotFound     = LinkError{kind: linkNotFound}
+	ErrPasswordRequired = LinkError{kind: passwordRequired, fields: Fields{"password"}}
+	ErrPasswordNotValid = LinkError{kind: passwordNotValid, fields: Fields{"password"}}
+	ErrTooManyAttempts  = LinkError{kind: tooManyAttempts, fields: Fields{"password"}}
+)
@@ -0,0 +1,75 @@
+package sharelink
+
+import (
+	"html/template"
+	"net/http"
+	"time"
+)
+
+// pageTemplate renders a link for the browsers. The notes are already sanitized when they are rendered,
+// so they are inserted as HTML
+var pageTemplate = template.Must(template.New("page").Funcs(template.FuncMap{
+	"notes": func(notes *string) template.HTML {
+		return template.HTML(*notes)
+	},
+	"date": func(t *time.Time) string {
+		return t.UTC().Format("2 Jan 2006 15:04 MST")
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>{{ if .List }}{{ .List.Name }}{{ else }}Shared todos{{ end }}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 720px; margin: 2rem auto; padding: 0 1rem; color: #222; }
+ul { list-style: none; padding-left: 1.25rem; }
+li { margin: .5rem 0; }
+.done > .title { text-decoration: line-through; color: #888; }
+.meta { font-size: .85rem; color: #666; }
+.tag { background: #eee; border-radius: 3px; padding: 0 .3rem; margin-right: .25rem; }
+.error { color: #b00; }
+</style>
+</head>
+<body>
+{{ if .List }}
+<h1>{{ .List.Name }}</h1>
+{{ if .List.ExpiresAt }}<p class="meta">Available until {{ date .List.ExpiresAt }}</p>{{ end }}
+{{ if .List.Todos }}{{ template "todos" .List.Todos }}{{ else }}<p>There are no todos.</p>{{ end }}
+{{ else }}
+<h1>Shared todos</h1>
+<form method="post">
+<p>This link is protected with a password.</p>
+{{ if .Error }}<p class="error">{{ .Error }}</p>{{ end }}
+<input type="password" name="password" autofocus required>
+<button type="submit">Open</button>
+</form>
+{{ end }}
+</body>
+</html>
+{{ define "todos" }}<ul>
+{{ range . }}<li class="{{ if .Done }}done{{ end }}">
+<input type="checkbox" disabled{{ if .Done }} checked{{ end }}> <span class="title">{{ .Title }}</span>
+<div class="meta">
+{{ if ne .Priority.String "none" }}<span>{{ .Priority }} priority</span>{{ end }}
+{{ if .DueDate }}<span>due {{ date .DueDate }}</span>{{ end }}
+{{ range .Tags }}<span class="tag">{{ . }}</span>{{ end }}
+</div>
+{{ if .NotesHTML }}<div class="notes">{{ notes .NotesHTML }}</div>{{ end }}
+{{ if .Subtasks }}{{ template "todos" .Subtasks }}{{ end }}
+</li>
+{{ end }}</ul>{{ end }}`))
+
+// pageData is either the list of a link or the password form with an optional error
+type pageData struct {
+	List  *PublicList
+	Error string
+}
+
+// renderPage writes the page with the status code
+func renderPage(w http.ResponseWriter, data pageData, statusCode int) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(statusCode)
+	pageTemplate.Execute(w, data)
+}
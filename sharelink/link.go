@@ -0,0 +1,94 @@
+package sharelink
+
+import (
+	"github.com/alexedwards/argon2id"
+	"github.com/jackc/pgx/v5"
+	"github.com/umtdemr/go-todo/todo"
+	"time"
+)
+
+// Link is a public read-only link to a project or to the todos matching a filter.
+// The todos are listed with the access of the user who created the link
+type Link struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+	// ProjectId is the shared project. It is nil if the link shares the todos matching the filter
+	ProjectId *int    `json:"projectId"`
+	Filter    *string `json:"filter"`
+	// HasPassword is true if the link can only be opened with a password
+	HasPassword bool `json:"hasPassword"`
+	// ExpiresAt is when the link stops working. It is nil for the links that don't expire
+	ExpiresAt *time.Time `json:"expiresAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+	// Token is only returned when the link is created. Only its hash is stored, so it can't be shown again
+	Token string `json:"token,omitempty"`
+
+	userId       int64
+	passwordHash *string
+}
+
+type CreateLinkData struct {
+	Name      *string    `json:"name,omitempty"`
+	ProjectId *int       `json:"projectId,omitempty"`
+	Filter    *string    `json:"filter,omitempty"`
+	Password  *string    `json:"password,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// PublicTodo is a todo as it is shown to the visitors of a link. It doesn't have the ids of the todo,
+// its project or its users
+type PublicTodo struct {
+	Title     string        `json:"title"`
+	Done      bool          `json:"done"`
+	Notes     *string       `json:"notes"`
+	NotesHTML *string       `json:"notesHtml"`
+	DueDate   *time.Time    `json:"dueDate"`
+	Priority  todo.Priority `json:"priority"`
+	Tags      []string      `json:"tags"`
+	Subtasks  []PublicTodo  `json:"subtasks"`
+}
+
+// PublicList is what the visitors of a link see
+type PublicList struct {
+	Name      string       `json:"name"`
+	ExpiresAt *time.Time   `json:"expiresAt"`
+	Todos     []PublicTodo `json:"todos"`
+}
+
+const (
+	// tokenBytes is the count of the random bytes in a token
+	tokenBytes = 32
+	// defaultFilterName is the name of the links sharing a filter when a name is not given
+	defaultFilterName = "Todos"
+	minPasswordLength = 4
+	maxPasswordLength = 64
+	maxNameLength     = 255
+)
+
+// passwordParams hash the passwords of the links with less memory than the user passwords,
+// since anyone with the token can make the server check a password without logging in
+var passwordParams = &argon2id.Params{
+	Memory:      19 * 1024,
+	Iterations:  2,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// linkColumns are the columns selected for scanning a link with ScanLink
+const linkColumns = `id, name, project_id, filter, password_hash, expires_at, created_at, user_id`
+
+func ScanLink(row pgx.Row) (*Link, error) {
+	l := new(Link)
+	err := row.Scan(&l.Id, &l.Name, &l.ProjectId, &l.Filter, &l.passwordHash, &l.ExpiresAt, &l.CreatedAt, &l.userId)
+	if err != nil {
+		return nil, err
+	}
+	l.HasPassword = l.passwordHash != nil
+	return l, nil
+}
+
+// expired reports whether the link has stopped working
+func (l *Link) expired(now time.Time) bool {
+	return l.ExpiresAt != nil && !now.Before(*l.ExpiresAt)
+}
@@ -0,0 +1,120 @@
+package sharelink
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type IRepository interface {
+	CreateLink(link *Link, tokenHash string) (*Link, error)
+	GetLinks(userId int64) ([]Link, error)
+	GetLink(linkId int, userId int64) (*Link, error)
+	GetLinkByToken(tokenHash string) (*Link, error)
+	RemoveLink(linkId int, userId int64) (*Link, error)
+}
+
+type Repository struct {
+	DB *pgxpool.Pool
+}
+
+func NewShareLinkRepository(dbConn *pgxpool.Pool) (*Repository, error) {
+	return &Repository{dbConn}, nil
+}
+
+func (store *Repository) Init() error {
+	return store.CreateShareLinkTable()
+}
+
+// CreateShareLinkTable creates the table of the links. Links of a project are deleted with the project
+func (store *Repository) CreateShareLinkTable() error {
+	queries := []string{
+		`CREATE TABLE IF NOT EXISTS "share_link" (
+			id serial PRIMARY KEY,
+			user_id integer NOT NULL REFERENCES "user"(id) ON DELETE CASCADE,
+			token_hash char(64) NOT NULL UNIQUE,
+			name varchar(255) NOT NULL,
+			project_id integer REFERENCES "project"(id) ON DELETE CASCADE,
+			filter text,
+			password_hash text,
+			expires_at timestamptz,
+			created_at timestamp DEFAULT now(),
+			CHECK (project_id IS NOT NULL OR filter IS NOT NULL)
+		)`,
+		`CREATE INDEX IF NOT EXISTS share_link_user_id_idx ON "share_link" (user_id)`,
+	}
+
+	for _, query := range queries {
+		if _, err := store.DB.Exec(context.Background(), query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (store *Repository) CreateLink(link *Link, tokenHash string) (*Link, error) {
+	query := `INSERT INTO "share_link"(user_id, token_hash, name, project_id, filter, password_hash, expires_at)
+		VALUES (@userId, @tokenHash, @name, @projectId, @filter, @passwordHash, @expiresAt)
+		RETURNING ` + linkColumns
+	args := pgx.NamedArgs{
+		"userId":       link.userId,
+		"tokenHash":    tokenHash,
+		"name":         link.Name,
+		"projectId":    link.ProjectId,
+		"filter":       link.Filter,
+		"passwordHash": link.passwordHash,
+		"expiresAt":    link.ExpiresAt,
+	}
+
+	return ScanLink(store.DB.QueryRow(context.Background(), query, args))
+}
+
+// GetLinks returns the links that the user created, newest first
+func (store *Repository) GetLinks(userId int64) ([]Link, error) {
+	query := `SELECT ` + linkColumns + ` FROM "share_link" WHERE user_id = @userId ORDER BY id DESC`
+	args := pgx.NamedArgs{"userId": userId}
+
+	rows, err := store.DB.Query(context.Background(), query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []Link{}
+	for rows.Next() {
+		link, err := ScanLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, *link)
+	}
+	return links, rows.Err()
+}
+
+func (store *Repository) GetLink(linkId int, userId int64) (*Link, error) {
+	query := `SELECT ` + linkColumns + ` FROM "share_link" WHERE id = @linkId AND user_id = @userId`
+	args := pgx.NamedArgs{
+		"linkId": linkId,
+		"userId": userId,
+	}
+
+	return ScanLink(store.DB.QueryRow(context.Background(), query, args))
+}
+
+// GetLinkByToken returns the link with the hash of the token. Expired links are returned too
+func (store *Repository) GetLinkByToken(tokenHash string) (*Link, error) {
+	query := `SELECT ` + linkColumns + ` FROM "share_link" WHERE token_hash = @tokenHash`
+	args := pgx.NamedArgs{"tokenHash": tokenHash}
+
+	return ScanLink(store.DB.QueryRow(context.Background(), query, args))
+}
+
+func (store *Repository) RemoveLink(linkId int, userId int64) (*Link, error) {
+	query := `DELETE FROM "share_link" WHERE id = @linkId AND user_id = @userId RETURNING ` + linkColumns
+	args := pgx.NamedArgs{
+		"linkId": linkId,
+		"userId": userId,
+	}
+
+	return ScanLink(store.DB.QueryRow(context.Background(), query, args))
+}
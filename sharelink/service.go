@@ -0,0 +1,271 @@
+package sharelink
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"github.com/alexedwards/argon2id"
+	"github.com/jackc/pgx/v5"
+	"github.com/umtdemr/go-todo/project"
+	"github.com/umtdemr/go-todo/share"
+	"github.com/umtdemr/go-todo/todo"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// TodoLister lists the todos of a link. It is implemented by the todo service
+type TodoLister interface {
+	GetAllTodos(userId int64, options *todo.ListOptions) ([]todo.Todo, error)
+}
+
+// ProjectGetter is used for checking the project of a link. It is implemented by the project service
+type ProjectGetter interface {
+	GetProject(projectId int, userId int64) (*project.Project, error)
+}
+
+type Service struct {
+	Repository IRepository
+	Todos      TodoLister
+	Projects   ProjectGetter
+
+	attempts *attemptLimiter
+}
+
+func NewShareLinkService(repo IRepository, todos TodoLister, projects ProjectGetter) *Service {
+	return &Service{Repository: repo, Todos: todos, Projects: projects, attempts: newAttemptLimiter()}
+}
+
+// generateToken returns a random token for a link and the hash of it that is stored
+func generateToken() (string, string, error) {
+	buf := make([]byte, tokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	return token, hashToken(token), nil
+}
+
+// hashToken hashes the token so that the links can't be opened with the stored values.
+// The tokens are random, so a fast hash is enough
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateLink creates a link to the project or to the todos matching the filter.
+// Only the owners of a project can share it with a link
+func (service *Service) CreateLink(data *CreateLinkData, userId int64) (*Link, error) {
+	if data.ProjectId == nil && data.Filter == nil {
+		return nil, ErrTargetRequired
+	}
+
+	link := &Link{ProjectId: data.ProjectId, ExpiresAt: data.ExpiresAt, userId: userId}
+
+	if data.Filter != nil {
+		expression := strings.TrimSpace(*data.Filter)
+		if _, err := todo.ParseFilter(expression, time.UTC); err != nil {
+			return nil, err
+		}
+		link.Filter = &expression
+	}
+
+	if data.ExpiresAt != nil && !data.ExpiresAt.After(time.Now()) {
+		return nil, ErrExpiresAtNotValid
+	}
+
+	if data.Name != nil {
+		link.Name = strings.TrimSpace(*data.Name)
+		if link.Name == "" || utf8.RuneCountInString(link.Name) > maxNameLength {
+			return nil, ErrNameLength
+		}
+	}
+
+	if data.Password != nil {
+		if length := len(*data.Password); length < minPasswordLength || length > maxPasswordLength {
+			return nil, ErrPasswordLength
+		}
+		hash, err := argon2id.CreateHash(*data.Password, passwordParams)
+		if err != nil {
+			return nil, err
+		}
+		link.passwordHash = &hash
+	}
+
+	if data.ProjectId != nil {
+		p, err := service.Projects.GetProject(*data.ProjectId, userId)
+		if errors.Is(err, project.ErrProjectNotFound) {
+			return nil, ErrProjectNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		if p.Role != share.RoleOwner {
+			return nil, ErrNotOwner
+		}
+		if link.Name == "" {
+			link.Name = p.Name
+		}
+	}
+
+	if link.Name == "" {
+		link.Name = defaultFilterName
+	}
+
+	token, tokenHash, err := generateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := service.Repository.CreateLink(link, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+	created.Token = token
+	return created, nil
+}
+
+// GetLinks lists the links that the user created. Their tokens can't be shown again
+func (service *Service) GetLinks(userId int64) ([]Link, error) {
+	return service.Repository.GetLinks(userId)
+}
+
+func (service *Service) GetLink(linkId int, userId int64) (*Link, error) {
+	link, err := service.Repository.GetLink(linkId, userId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrLinkNotFound
+	}
+	return link, err
+}
+
+// RevokeLink deletes the link so that its token stops working
+func (service *Service) RevokeLink(linkId int, userId int64) (*Link, error) {
+	removed, err := service.Repository.RemoveLink(linkId, userId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrLinkNotFound
+	}
+	return removed, err
+}
+
+// OpenLink returns the todos of the link with the token. The password is needed if the link has one.
+// Unknown and expired tokens are not found
+func (service *Service) OpenLink(token string, password string) (*PublicList, error) {
+	if token == "" {
+		return nil, ErrLinkNotFound
+	}
+
+	link, err := service.Repository.GetLinkByToken(hashToken(token))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrLinkNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if link.expired(time.Now()) {
+		return nil, ErrLinkNotFound
+	}
+
+	if link.passwordHash != nil {
+		if password == "" {
+			return nil, ErrPasswordRequired
+		}
+		now := time.Now()
+		if !service.attempts.allowed(link.Id, now) {
+			return nil, ErrTooManyAttempts
+		}
+		matched, err := argon2id.ComparePasswordAndHash(password, *link.passwordHash)
+		if err != nil {
+			return nil, err
+		}
+		if !matched {
+			service.attempts.fail(link.Id, now)
+			return nil, ErrPasswordNotValid
+		}
+		service.attempts.reset(link.Id)
+	}
+
+	// the todos are listed with the access that the creator has now, so they stop showing up
+	// when the creator loses the access to them. The public page isn't paginated, so all of them are listed
+	options := &todo.ListOptions{ProjectId: link.ProjectId}
+	if link.Filter != nil {
+		filter, err := todo.ParseFilter(*link.Filter, time.UTC)
+		if err != nil {
+			return nil, err
+		}
+		options.Filter = filter
+		options.IncludeArchived = todo.FilterUsesField(filter, "archived")
+	}
+	// links sharing a filter only list the personal todos of the creator, not the todos of the workspaces
+	if link.ProjectId == nil {
+		options.Personal = true
+	}
+
+	todos, err := service.Todos.GetAllTodos(link.userId, options)
+	if err != nil {
+		return nil, err
+	}
+
+	publicTodos, err := buildPublicTree(todos)
+	if err != nil {
+		return nil, err
+	}
+	return &PublicList{Name: link.Name, ExpiresAt: link.ExpiresAt, Todos: publicTodos}, nil
+}
+
+// buildPublicTree converts the todos to public todos and nests the subtasks under their parents.
+// Subtasks whose parents are not in the list are shown at the top level
+func buildPublicTree(todos []todo.Todo) ([]PublicTodo, error) {
+	children := make(map[int][]int, len(todos))
+	listed := make(map[int]bool, len(todos))
+	for _, t := range todos {
+		listed[t.Id] = true
+	}
+
+	var roots []int
+	for i, t := range todos {
+		if t.ParentId != nil && listed[*t.ParentId] {
+			children[*t.ParentId] = append(children[*t.ParentId], i)
+			continue
+		}
+		roots = append(roots, i)
+	}
+
+	var convert func(indexes []int) ([]PublicTodo, error)
+	convert = func(indexes []int) ([]PublicTodo, error) {
+		converted := make([]PublicTodo, 0, len(indexes))
+		for _, i := range indexes {
+			t := todos[i]
+			public := PublicTodo{
+				Title:    t.Title,
+				Done:     t.Done,
+				Notes:    t.Notes,
+				DueDate:  t.DueDate,
+				Priority: t.Priority,
+				Tags:     t.Tags,
+			}
+			if public.Tags == nil {
+				public.Tags = []string{}
+			}
+
+			if t.Notes != nil {
+				html, err := todo.RenderNotes(*t.Notes)
+				if err != nil {
+					return nil, err
+				}
+				public.NotesHTML = &html
+			}
+
+			subtasks, err := convert(children[t.Id])
+			if err != nil {
+				return nil, err
+			}
+			public.Subtasks = subtasks
+			converted = append(converted, public)
+		}
+		return converted, nil
+	}
+	return convert(roots)
+}
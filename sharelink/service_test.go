@@ -0,0 +1,287 @@
+package sharelink
+
+import (
+	"github.com/alexedwards/argon2id"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/umtdemr/go-todo/project"
+	"github.com/umtdemr/go-todo/share"
+	"github.com/umtdemr/go-todo/todo"
+	"testing"
+	"time"
+)
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) CreateLink(link *Link, tokenHash string) (*Link, error) {
+	args := m.Called(link, tokenHash)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Link), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) GetLinks(userId int64) ([]Link, error) {
+	args := m.Called(userId)
+	return args.Get(0).([]Link), args.Error(1)
+}
+
+func (m *MockRepository) GetLink(linkId int, userId int64) (*Link, error) {
+	args := m.Called(linkId, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Link), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) GetLinkByToken(tokenHash string) (*Link, error) {
+	args := m.Called(tokenHash)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Link), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) RemoveLink(linkId int, userId int64) (*Link, error) {
+	args := m.Called(linkId, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Link), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+type MockTodoLister struct {
+	mock.Mock
+}
+
+func (m *MockTodoLister) GetAllTodos(userId int64, options *todo.ListOptions) ([]todo.Todo, error) {
+	args := m.Called(userId, options)
+	return args.Get(0).([]todo.Todo), args.Error(1)
+}
+
+type MockProjectGetter struct {
+	mock.Mock
+}
+
+func (m *MockProjectGetter) GetProject(projectId int, userId int64) (*project.Project, error) {
+	args := m.Called(projectId, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*project.Project), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func TestCreateLink(t *testing.T) {
+	projectId := 4
+	filter := " done:false "
+	badFilter := "done:maybe"
+	shortPassword := "abc"
+	password := "secret"
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name          string
+		data          CreateLinkData
+		setupMock     func(repo *MockRepository, projects *MockProjectGetter)
+		expectedName  string
+		expectedError error
+	}{
+		{
+			name:          "Project or filter is required",
+			data:          CreateLinkData{},
+			setupMock:     func(repo *MockRepository, projects *MockProjectGetter) {},
+			expectedError: ErrTargetRequired,
+		},
+		{
+			name:          "Filter is not valid",
+			data:          CreateLinkData{Filter: &badFilter},
+			setupMock:     func(repo *MockRepository, projects *MockProjectGetter) {},
+			expectedError: todo.ErrFilterNotValid,
+		},
+		{
+			name:          "Expiry is in the past",
+			data:          CreateLinkData{Filter: &filter, ExpiresAt: &past},
+			setupMock:     func(repo *MockRepository, projects *MockProjectGetter) {},
+			expectedError: ErrExpiresAtNotValid,
+		},
+		{
+			name:          "Password is too short",
+			data:          CreateLinkData{Filter: &filter, Password: &shortPassword},
+			setupMock:     func(repo *MockRepository, projects *MockProjectGetter) {},
+			expectedError: ErrPasswordLength,
+		},
+		{
+			name: "User can't see the project",
+			data: CreateLinkData{ProjectId: &projectId},
+			setupMock: func(repo *MockRepository, projects *MockProjectGetter) {
+				projects.On("GetProject", 4, int64(1)).Return(nil, project.ErrProjectNotFound)
+			},
+			expectedError: ErrProjectNotFound,
+		},
+		{
+			name: "User doesn't own the project",
+			data: CreateLinkData{ProjectId: &projectId},
+			setupMock: func(repo *MockRepository, projects *MockProjectGetter) {
+				projects.On("GetProject", 4, int64(1)).Return(&project.Project{Id: 4, Role: share.RoleEditor}, nil)
+			},
+			expectedError: ErrNotOwner,
+		},
+		{
+			name: "Link of a project is named after the project",
+			data: CreateLinkData{ProjectId: &projectId, Password: &password},
+			setupMock: func(repo *MockRepository, projects *MockProjectGetter) {
+				projects.On("GetProject", 4, int64(1)).Return(&project.Project{Id: 4, Name: "Home", Role: share.RoleOwner}, nil)
+				repo.On("CreateLink", mock.MatchedBy(func(link *Link) bool {
+					return link.Name == "Home" && link.passwordHash != nil && *link.passwordHash != password
+				}), mock.AnythingOfType("string")).Return(&Link{Id: 1, Name: "Home", HasPassword: true}, nil)
+			},
+			expectedName: "Home",
+		},
+		{
+			name: "Link of a filter has the default name",
+			data: CreateLinkData{Filter: &filter},
+			setupMock: func(repo *MockRepository, projects *MockProjectGetter) {
+				repo.On("CreateLink", mock.MatchedBy(func(link *Link) bool {
+					return link.Name == defaultFilterName && *link.Filter == "done:false" && link.userId == 1
+				}), mock.AnythingOfType("string")).Return(&Link{Id: 1, Name: defaultFilterName}, nil)
+			},
+			expectedName: defaultFilterName,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockProjects := new(MockProjectGetter)
+			service := NewShareLinkService(mockRepo, new(MockTodoLister), mockProjects)
+			tc.setupMock(mockRepo, mockProjects)
+
+			created, err := service.CreateLink(&tc.data, 1)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+			} else {
+				assert.Nil(t, err)
+				assert.Equal(t, tc.expectedName, created.Name)
+				// the token is returned once, and only its hash is stored
+				assert.NotEmpty(t, created.Token)
+				mockRepo.AssertCalled(t, "CreateLink", mock.Anything, hashToken(created.Token))
+			}
+			mockRepo.AssertExpectations(t)
+			mockProjects.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOpenLink(t *testing.T) {
+	projectId := 4
+	notes := "**soon**"
+	parentId := 10
+	missingParentId := 99
+	past := time.Now().Add(-time.Hour)
+	passwordHash, _ := argon2id.CreateHash("secret", passwordParams)
+
+	tests := []struct {
+		name          string
+		password      string
+		setupMock     func(repo *MockRepository, todos *MockTodoLister)
+		expectedError error
+	}{
+		{
+			name: "Token doesn't exist",
+			setupMock: func(repo *MockRepository, todos *MockTodoLister) {
+				repo.On("GetLinkByToken", hashToken("token")).Return(nil, pgx.ErrNoRows)
+			},
+			expectedError: ErrLinkNotFound,
+		},
+		{
+			name: "Link is expired",
+			setupMock: func(repo *MockRepository, todos *MockTodoLister) {
+				repo.On("GetLinkByToken", hashToken("token")).Return(&Link{Id: 1, ProjectId: &projectId, ExpiresAt: &past}, nil)
+			},
+			expectedError: ErrLinkNotFound,
+		},
+		{
+			name: "Password isn't sent",
+			setupMock: func(repo *MockRepository, todos *MockTodoLister) {
+				repo.On("GetLinkByToken", hashToken("token")).Return(&Link{Id: 1, ProjectId: &projectId, passwordHash: &passwordHash}, nil)
+			},
+			expectedError: ErrPasswordRequired,
+		},
+		{
+			name:     "Password is wrong",
+			password: "guess",
+			setupMock: func(repo *MockRepository, todos *MockTodoLister) {
+				repo.On("GetLinkByToken", hashToken("token")).Return(&Link{Id: 1, ProjectId: &projectId, passwordHash: &passwordHash}, nil)
+			},
+			expectedError: ErrPasswordNotValid,
+		},
+		{
+			name:     "Todos of the project are listed with the access of the creator",
+			password: "secret",
+			setupMock: func(repo *MockRepository, todos *MockTodoLister) {
+				repo.On("GetLinkByToken", hashToken("token")).Return(&Link{
+					Id: 1, Name: "Home", ProjectId: &projectId, passwordHash: &passwordHash, userId: 7,
+				}, nil)
+				todos.On("GetAllTodos", int64(7), mock.MatchedBy(func(options *todo.ListOptions) bool {
+					return *options.ProjectId == projectId && !options.Personal && options.Limit == 0
+				})).Return([]todo.Todo{
+					{Id: 10, Title: "Paint", Notes: &notes},
+					{Id: 11, Title: "Buy brushes", ParentId: &parentId, Done: true},
+					{Id: 12, Title: "Orphan", ParentId: &missingParentId},
+				}, nil)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockTodos := new(MockTodoLister)
+			service := NewShareLinkService(mockRepo, mockTodos, new(MockProjectGetter))
+			tc.setupMock(mockRepo, mockTodos)
+
+			list, err := service.OpenLink("token", tc.password)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+			} else {
+				assert.Nil(t, err)
+				assert.Equal(t, "Home", list.Name)
+				// subtasks are nested under their parents, and the ones without a listed parent are at the top
+				assert.Len(t, list.Todos, 2)
+				assert.Equal(t, "Buy brushes", list.Todos[0].Subtasks[0].Title)
+				assert.Equal(t, "Orphan", list.Todos[1].Title)
+				assert.Contains(t, *list.Todos[0].NotesHTML, "<strong>soon</strong>")
+			}
+			mockRepo.AssertExpectations(t)
+			mockTodos.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOpenLinkAttempts(t *testing.T) {
+	projectId := 4
+	passwordHash, _ := argon2id.CreateHash("secret", passwordParams)
+
+	mockRepo := new(MockRepository)
+	mockTodos := new(MockTodoLister)
+	service := NewShareLinkService(mockRepo, mockTodos, new(MockProjectGetter))
+	mockRepo.On("GetLinkByToken", hashToken("token")).Return(&Link{Id: 1, ProjectId: &projectId, passwordHash: &passwordHash}, nil)
+
+	for i := 0; i < maxPasswordAttempts; i++ {
+		_, err := service.OpenLink("token", "guess")
+		assert.ErrorIs(t, err, ErrPasswordNotValid)
+	}
+
+	// even the right password isn't checked until the window is over
+	_, err := service.OpenLink("token", "secret")
+	assert.ErrorIs(t, err, ErrTooManyAttempts)
+
+	service.attempts.attempts[1].since = time.Now().Add(-passwordAttemptWindow)
+	mockTodos.On("GetAllTodos", mock.Anything, mock.Anything).Return([]todo.Todo{}, nil)
+	_, err = service.OpenLink("token", "secret")
+	assert.Nil(t, err)
+	assert.Empty(t, service.attempts.attempts)
+}
@@ -2,18 +2,20 @@ package main
 
 import (
 	"context"
-	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// PostgresStore holds a pool of connections. Each request and each transaction acquires a connection of its own,
+// so the transactions and the row locks of concurrent requests don't share a session
 type PostgresStore struct {
-	DB *pgx.Conn
+	DB *pgxpool.Pool
 }
 
 func NewPostgresStore(connStr string) (*PostgresStore, error) {
-	conn, err := pgx.Connect(context.Background(), connStr)
+	pool, err := pgxpool.New(context.Background(), connStr)
 	if err != nil {
 		return nil, err
 	}
 
-	return &PostgresStore{conn}, nil
+	return &PostgresStore{pool}, nil
 }
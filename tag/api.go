@@ -0,0 +1,150 @@
+package tag
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/umtdemr/go-todo/server"
+	"github.com/umtdemr/go-todo/user"
+	"net/http"
+	"strconv"
+)
+
+type APIRoute struct {
+	Route   string
+	Service *Service
+}
+
+func NewTagAPIRoute(service *Service) *APIRoute {
+	return &APIRoute{Route: "tag", Service: service}
+}
+
+// RegisterRoutes registers the routes for the tag API
+func (s *APIRoute) RegisterRoutes(router *mux.Router, userService user.Service) {
+	router.Handle("/tag", userService.AuthMiddleware(http.HandlerFunc(s.handleList)))
+	router.Handle("/tag/list", userService.AuthMiddleware(http.HandlerFunc(s.handleList)))
+	router.Handle("/tag/create", userService.AuthMiddleware(http.HandlerFunc(s.handleAdd)))
+	router.Handle("/tag/update", userService.AuthMiddleware(http.HandlerFunc(s.handleUpdate)))
+	router.Handle("/tag/{id}", userService.AuthMiddleware(http.HandlerFunc(s.handleFetchAndDelete)))
+}
+
+// respondWithTagError responds with the fields that caused the error if the error is a TagError
+func respondWithTagError(w http.ResponseWriter, msg string, err error) {
+	var e TagError
+	if errors.As(err, &e) {
+		server.RespondWithErrorFields(w, fmt.Sprintf("validation error: %v", e.Error()), http.StatusBadRequest, e.fields)
+		return
+	}
+	server.RespondWithError(w, fmt.Sprintf("%s: %s", msg, err), http.StatusBadRequest)
+}
+
+// handleList handles the list request
+func (s *APIRoute) handleList(w http.ResponseWriter, r *http.Request) {
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+	tags, err := s.Service.GetAllTags(authenticatedUser.Id)
+
+	if err != nil {
+		server.RespondWithError(w, fmt.Sprintf("error while getting list: %s", err), http.StatusBadRequest)
+		return
+	}
+	server.RespondOK(w, tags)
+}
+
+// handleAdd handles the add request
+func (s *APIRoute) handleAdd(w http.ResponseWriter, r *http.Request) {
+	// only POST methods are allowed
+	if r.Method != http.MethodPost {
+		err := server.ErrNotValidMethod.With("only POST methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var createData CreateTagData
+
+	err := server.DecodeBody(r, &createData)
+
+	if err != nil {
+		server.RespondWithError(w, fmt.Sprintf("parsing error: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// get the authenticated user from the context
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+
+	createdTag, createErr := s.Service.CreateTag(&createData, authenticatedUser.Id)
+	if createErr != nil {
+		respondWithTagError(w, "error while creating the tag", createErr)
+		return
+	}
+
+	server.RespondCreated(w, createdTag)
+}
+
+// handleUpdate handles the update request. It is used for renaming tags
+func (s *APIRoute) handleUpdate(w http.ResponseWriter, r *http.Request) {
+	// only POST methods are allowed
+	if r.Method != http.MethodPost {
+		err := server.ErrNotValidMethod.With("only POST methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var updateData UpdateTagData
+
+	err := server.DecodeBody(r, &updateData)
+
+	if err != nil {
+		server.RespondWithError(w, fmt.Sprintf("error while parsing: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// get the authenticated user from the context
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+
+	updatedTag, updateErr := s.Service.UpdateTag(&updateData, authenticatedUser.Id)
+	if updateErr != nil {
+		respondWithTagError(w, "Error while updating", updateErr)
+		return
+	}
+
+	server.RespondOK(w, updatedTag)
+}
+
+// handleFetchAndDelete handles the fetch and delete requests
+func (s *APIRoute) handleFetchAndDelete(w http.ResponseWriter, r *http.Request) {
+	// only GET and DELETE methods are allowed
+	if r.Method != http.MethodGet && r.Method != http.MethodDelete {
+		err := server.ErrNotValidMethod.With("only GET and DELETE methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// parse the ID from the path variables
+	tagId, parseErr := strconv.Atoi(mux.Vars(r)["id"])
+
+	if parseErr != nil {
+		err := server.ErrInvalidRequest.With("need a numeric value for the id")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// get the authenticated user from the context
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+
+	if r.Method == http.MethodDelete {
+		removedTag, removeErr := s.Service.RemoveTag(tagId, authenticatedUser.Id)
+		if removeErr != nil {
+			respondWithTagError(w, "error while deleting", removeErr)
+			return
+		}
+		server.RespondNoContent(w, removedTag)
+		return
+	}
+
+	fetchedTag, err := s.Service.GetTag(tagId, authenticatedUser.Id)
+	if err != nil {
+		respondWithTagError(w, "error while fetching", err)
+		return
+	}
+	server.RespondOK(w, fetchedTag)
+}
@@ -0,0 +1,49 @@
+package tag
+
+type errKind int
+
+const (
+	_ errKind = iota
+	nameLength
+	nameNotValidCharacters
+	nameTaken
+	idRequired
+	tagNotFound
+)
+
+type TagError struct {
+	kind   errKind
+	fields []string
+}
+
+type Fields []string
+
+func (e TagError) Error() string {
+	switch e.kind {
+	case nameLength:
+		return "tag name length should be between 1 and 50"
+	case nameNotValidCharacters:
+		return "tag name can only include letters, numbers, - and _"
+	case nameTaken:
+		return "there is already a tag with the same name"
+	case idRequired:
+		return "id is required"
+	case tagNotFound:
+		return "tag not found"
+	}
+	return "error in tag"
+}
+
+// Is reports whether the target is a TagError of the same kind so that errors.Is can be used
+func (e TagError) Is(target error) bool {
+	t, ok := target.(TagError)
+	return ok && t.kind == e.kind
+}
+
+var (
+	ErrNameLength             = TagError{kind: nameLength, fields: Fields{"name"}}
+	ErrNameNotValidCharacters = TagError{kind: nameNotValidCharacters, fields: Fields{"name"}}
+	ErrNameTaken              = TagError{kind: nameTaken, fields: Fields{"name"}}
+	ErrIdRequired             = TagError{kind: idRequired, fields: Fields{"id"}}
+	ErrTagNotFound            = TagError{kind: tagNotFound}
+)
@@ -0,0 +1,131 @@
+package tag
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type IRepository interface {
+	CreateTag(data *CreateTagData, userId int64) (*Tag, error)
+	GetAllTags(userId int64) ([]Tag, error)
+	GetTag(tagId int, userId int64) (*Tag, error)
+	UpdateTag(data *UpdateTagData, userId int64) (*Tag, error)
+	RemoveTag(tagId int, userId int64) (*Tag, error)
+}
+
+type Repository struct {
+	DB *pgxpool.Pool
+}
+
+func NewTagRepository(dbConn *pgxpool.Pool) (*Repository, error) {
+	return &Repository{dbConn}, nil
+}
+
+func (store *Repository) Init() error {
+	return store.CreateTagTable()
+}
+
+func (store *Repository) CreateTagTable() error {
+	query := `CREATE TABLE IF NOT EXISTS "tag" (
+		id serial PRIMARY KEY,
+		user_id integer NOT NULL REFERENCES "user"(id) ON DELETE CASCADE,
+		name varchar(50) NOT NULL,
+		created_at timestamp DEFAULT now(),
+		UNIQUE (user_id, name)
+	)`
+
+	_, err := store.DB.Exec(context.Background(), query)
+	return err
+}
+
+// uniqueViolation is the postgres error code for unique constraint violations
+const uniqueViolation = "23505"
+
+// mapNameTaken returns ErrNameTaken if the error is caused by the unique constraint on the name
+func mapNameTaken(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return ErrNameTaken
+	}
+	return err
+}
+
+func (store *Repository) CreateTag(data *CreateTagData, userId int64) (*Tag, error) {
+	query := `INSERT INTO "tag"(name, user_id) VALUES (@name, @userId) RETURNING ` + tagColumns
+	args := pgx.NamedArgs{
+		"name":   data.Name,
+		"userId": userId,
+	}
+
+	createdTag, err := ScanTag(store.DB.QueryRow(context.Background(), query, args))
+	if err != nil {
+		return nil, mapNameTaken(err)
+	}
+	return createdTag, nil
+}
+
+func (store *Repository) GetAllTags(userId int64) ([]Tag, error) {
+	query := `SELECT ` + tagColumns + ` FROM "tag" WHERE user_id = @userId ORDER BY name`
+	args := pgx.NamedArgs{"userId": userId}
+
+	rows, err := store.DB.Query(context.Background(), query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []Tag{}
+
+	for rows.Next() {
+		t, err := ScanTag(rows)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, *t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+func (store *Repository) GetTag(tagId int, userId int64) (*Tag, error) {
+	query := `SELECT ` + tagColumns + ` FROM "tag" WHERE id = @tagId AND user_id = @userId`
+	args := pgx.NamedArgs{
+		"tagId":  tagId,
+		"userId": userId,
+	}
+
+	return ScanTag(store.DB.QueryRow(context.Background(), query, args))
+}
+
+func (store *Repository) UpdateTag(data *UpdateTagData, userId int64) (*Tag, error) {
+	query := `UPDATE "tag" SET name = @name WHERE id = @tagId AND user_id = @userId RETURNING ` + tagColumns
+	args := pgx.NamedArgs{
+		"name":   *data.Name,
+		"tagId":  *data.Id,
+		"userId": userId,
+	}
+
+	updatedTag, err := ScanTag(store.DB.QueryRow(context.Background(), query, args))
+	if err != nil {
+		return nil, mapNameTaken(err)
+	}
+	return updatedTag, nil
+}
+
+// RemoveTag deletes the tag. It is removed from the todos by the foreign key of the todo_tag table
+func (store *Repository) RemoveTag(tagId int, userId int64) (*Tag, error) {
+	query := `DELETE FROM "tag" WHERE id = @tagId AND user_id = @userId RETURNING ` + tagColumns
+	args := pgx.NamedArgs{
+		"tagId":  tagId,
+		"userId": userId,
+	}
+
+	return ScanTag(store.DB.QueryRow(context.Background(), query, args))
+}
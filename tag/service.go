@@ -0,0 +1,66 @@
+package tag
+
+import (
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"strings"
+)
+
+type Service struct {
+	Repository IRepository
+}
+
+func NewTagService(repo IRepository) *Service {
+	return &Service{Repository: repo}
+}
+
+func (service *Service) GetAllTags(userId int64) ([]Tag, error) {
+	return service.Repository.GetAllTags(userId)
+}
+
+func (service *Service) CreateTag(data *CreateTagData, userId int64) (*Tag, error) {
+	data.Name = strings.TrimSpace(data.Name)
+	if err := ValidateName(data.Name); err != nil {
+		return nil, err
+	}
+	return service.Repository.CreateTag(data, userId)
+}
+
+func (service *Service) GetTag(tagId int, userId int64) (*Tag, error) {
+	t, err := service.Repository.GetTag(tagId, userId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrTagNotFound
+	}
+	return t, err
+}
+
+// UpdateTag renames a tag. The todos keep the tag since they are linked by its id
+func (service *Service) UpdateTag(data *UpdateTagData, userId int64) (*Tag, error) {
+	if data.Id == nil {
+		return nil, ErrIdRequired
+	}
+
+	if data.Name == nil {
+		return nil, ErrNameLength
+	}
+
+	name := strings.TrimSpace(*data.Name)
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+	data.Name = &name
+
+	t, err := service.Repository.UpdateTag(data, userId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrTagNotFound
+	}
+	return t, err
+}
+
+func (service *Service) RemoveTag(tagId int, userId int64) (*Tag, error) {
+	t, err := service.Repository.RemoveTag(tagId, userId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrTagNotFound
+	}
+	return t, err
+}
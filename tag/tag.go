@@ -0,0 +1,69 @@
+package tag
+
+import (
+	"github.com/jackc/pgx/v5"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type Tag struct {
+	Id        int       `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type CreateTagData struct {
+	Name string `json:"name"`
+}
+
+type UpdateTagData struct {
+	Id   *int    `json:"id,omitempty"`
+	Name *string `json:"name,omitempty"`
+}
+
+// tagColumns are the columns selected for scanning a tag with ScanTag
+const tagColumns = `id, name, created_at`
+
+func ScanTag(row pgx.Row) (*Tag, error) {
+	t := new(Tag)
+	err := row.Scan(&t.Id, &t.Name, &t.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+var nameRegex = regexp.MustCompile(`^[\p{L}\p{N}_-]+$`)
+
+// ValidateName checks if the name can be used as a tag name
+func ValidateName(name string) error {
+	if nameLength := utf8.RuneCountInString(name); nameLength < 1 || nameLength > 50 {
+		return ErrNameLength
+	}
+
+	if !nameRegex.MatchString(name) {
+		return ErrNameNotValidCharacters
+	}
+	return nil
+}
+
+// NormalizeNames trims and validates the given tag names and removes the duplicates
+func NormalizeNames(names []string) ([]string, error) {
+	normalized := []string{}
+	seen := make(map[string]bool)
+
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if err := ValidateName(name); err != nil {
+			return nil, err
+		}
+
+		if !seen[name] {
+			seen[name] = true
+			normalized = append(normalized, name)
+		}
+	}
+	return normalized, nil
+}
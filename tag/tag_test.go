@@ -0,0 +1,55 @@
+package tag
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeNames(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         []string
+		expectedNames []string
+		expectedError error
+	}{
+		{
+			name:          "Empty list",
+			input:         []string{},
+			expectedNames: []string{},
+		},
+		{
+			name:          "Names are trimmed and duplicates are removed",
+			input:         []string{" work", "errand", "work "},
+			expectedNames: []string{"work", "errand"},
+		},
+		{
+			name:          "Name is empty",
+			input:         []string{"work", "  "},
+			expectedError: ErrNameLength,
+		},
+		{
+			name:          "Name is longer than 50",
+			input:         []string{strings.Repeat("t", 51)},
+			expectedError: ErrNameLength,
+		},
+		{
+			name:          "Name includes a space",
+			input:         []string{"on hold"},
+			expectedError: ErrNameNotValidCharacters,
+		},
+		{
+			name:          "Name includes letters from other alphabets",
+			input:         []string{"iş", "follow-up", "q_4"},
+			expectedNames: []string{"iş", "follow-up", "q_4"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			names, err := NormalizeNames(tc.input)
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedNames, names)
+		})
+	}
+}
@@ -0,0 +1,301 @@
+package timetrack
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/umtdemr/go-todo/server"
+	"github.com/umtdemr/go-todo/user"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type APIRoute struct {
+	Route   string
+	Service *Service
+}
+
+func NewTimeTrackAPIRoute(service *Service) *APIRoute {
+	return &APIRoute{Route: "time", Service: service}
+}
+
+// RegisterRoutes registers the routes for the time tracking API
+func (s *APIRoute) RegisterRoutes(router *mux.Router, userService user.Service) {
+	router.Handle("/timer", userService.AuthMiddleware(http.HandlerFunc(s.handleRunningTimer)))
+	router.Handle("/todo/{id}/timer/start", userService.AuthMiddleware(http.HandlerFunc(s.handleStart)))
+	router.Handle("/todo/{id}/timer/stop", userService.AuthMiddleware(http.HandlerFunc(s.handleStop)))
+	router.Handle("/todo/{id}/time", userService.AuthMiddleware(http.HandlerFunc(s.handleListAndAdd)))
+	router.Handle("/todo/{id}/time/{entryId}", userService.AuthMiddleware(http.HandlerFunc(s.handleUpdateAndDelete)))
+	router.Handle("/project/{id}/time", userService.AuthMiddleware(http.HandlerFunc(s.handleProjectTime)))
+	router.Handle("/time/report", userService.AuthMiddleware(http.HandlerFunc(s.handleReport)))
+}
+
+// respondWithTimeError responds with the fields that caused the error if the error is a TimeError
+func respondWithTimeError(w http.ResponseWriter, msg string, err error) {
+	var e TimeError
+	if errors.As(err, &e) {
+		server.RespondWithErrorFields(w, fmt.Sprintf("validation error: %v", e.Error()), http.StatusBadRequest, e.fields)
+		return
+	}
+	server.RespondWithError(w, fmt.Sprintf("%s: %s", msg, err), http.StatusBadRequest)
+}
+
+// parseIds parses the id in the path and the entry id if the path has it
+func parseIds(r *http.Request, withEntry bool) (int, int, error) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return 0, 0, server.ErrInvalidRequest.With("need a numeric value for the id")
+	}
+
+	if !withEntry {
+		return id, 0, nil
+	}
+
+	entryId, err := strconv.Atoi(vars["entryId"])
+	if err != nil {
+		return 0, 0, server.ErrInvalidRequest.With("need a numeric value for the entry id")
+	}
+	return id, entryId, nil
+}
+
+// parseReportOptions parses the range, the grouping and the project of the report. from and to are days in the
+// tz timezone, and both of them are included in the report
+func parseReportOptions(query url.Values) (*ReportOptions, error) {
+	options := &ReportOptions{
+		Timezone: query.Get("tz"),
+		GroupBy:  query.Get("group_by"),
+	}
+
+	loc := time.UTC
+	if options.Timezone != "" {
+		timezoneLoc, err := time.LoadLocation(options.Timezone)
+		if err != nil {
+			return nil, ErrTimezoneNotValid
+		}
+		loc = timezoneLoc
+	}
+
+	from, fromErr := time.ParseInLocation(time.DateOnly, query.Get("from"), loc)
+	to, toErr := time.ParseInLocation(time.DateOnly, query.Get("to"), loc)
+	if fromErr != nil || toErr != nil {
+		return nil, ErrReportRangeNotValid
+	}
+	options.From = from
+	options.To = to.AddDate(0, 0, 1)
+
+	if projectValue := query.Get("project"); projectValue != "" {
+		projectId, err := strconv.Atoi(projectValue)
+		if err != nil {
+			return nil, ErrProjectNotFound
+		}
+		options.ProjectId = &projectId
+	}
+	return options, nil
+}
+
+// handleRunningTimer responds with the running timer of the user, or null if no timer is running
+func (s *APIRoute) handleRunningTimer(w http.ResponseWriter, r *http.Request) {
+	// only GET methods are allowed
+	if r.Method != http.MethodGet {
+		err := server.ErrNotValidMethod.With("only GET methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+	running, err := s.Service.GetRunningTimer(authenticatedUser.Id)
+	if err != nil {
+		respondWithTimeError(w, "error while getting the timer", err)
+		return
+	}
+	server.RespondOK(w, running)
+}
+
+// handleStart starts a timer on the todo
+func (s *APIRoute) handleStart(w http.ResponseWriter, r *http.Request) {
+	// only POST methods are allowed
+	if r.Method != http.MethodPost {
+		err := server.ErrNotValidMethod.With("only POST methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	todoId, _, parseErr := parseIds(r, false)
+	if parseErr != nil {
+		server.RespondWithError(w, parseErr.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// the note is optional, so the body can be empty
+	var startData StartTimerData
+	if err := server.DecodeBody(r, &startData); err != nil && !errors.Is(err, io.EOF) {
+		server.RespondWithError(w, fmt.Sprintf("parsing error: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+	started, err := s.Service.StartTimer(todoId, authenticatedUser.Id, &startData)
+	if err != nil {
+		respondWithTimeError(w, "error while starting the timer", err)
+		return
+	}
+	server.RespondCreated(w, started)
+}
+
+// handleStop stops the timer of the user on the todo
+func (s *APIRoute) handleStop(w http.ResponseWriter, r *http.Request) {
+	// only POST methods are allowed
+	if r.Method != http.MethodPost {
+		err := server.ErrNotValidMethod.With("only POST methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	todoId, _, parseErr := parseIds(r, false)
+	if parseErr != nil {
+		server.RespondWithError(w, parseErr.Error(), http.StatusBadRequest)
+		return
+	}
+
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+	stopped, err := s.Service.StopTimer(todoId, authenticatedUser.Id)
+	if err != nil {
+		respondWithTimeError(w, "error while stopping the timer", err)
+		return
+	}
+	server.RespondOK(w, stopped)
+}
+
+// handleListAndAdd lists the entries of the todo with GET and adds an entry manually with POST
+func (s *APIRoute) handleListAndAdd(w http.ResponseWriter, r *http.Request) {
+	// only GET and POST methods are allowed
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		err := server.ErrNotValidMethod.With("only GET and POST methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	todoId, _, parseErr := parseIds(r, false)
+	if parseErr != nil {
+		server.RespondWithError(w, parseErr.Error(), http.StatusBadRequest)
+		return
+	}
+
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+
+	if r.Method == http.MethodGet {
+		todoTime, err := s.Service.GetTodoTime(todoId, authenticatedUser.Id)
+		if err != nil {
+			respondWithTimeError(w, "error while getting the entries", err)
+			return
+		}
+		server.RespondOK(w, todoTime)
+		return
+	}
+
+	var createData CreateEntryData
+	if err := server.DecodeBody(r, &createData); err != nil {
+		server.RespondWithError(w, fmt.Sprintf("parsing error: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	created, err := s.Service.CreateEntry(todoId, authenticatedUser.Id, &createData)
+	if err != nil {
+		respondWithTimeError(w, "error while creating the entry", err)
+		return
+	}
+	server.RespondCreated(w, created)
+}
+
+// handleUpdateAndDelete changes the entry with POST and deletes it with DELETE
+func (s *APIRoute) handleUpdateAndDelete(w http.ResponseWriter, r *http.Request) {
+	// only POST and DELETE methods are allowed
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		err := server.ErrNotValidMethod.With("only POST and DELETE methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	todoId, entryId, parseErr := parseIds(r, true)
+	if parseErr != nil {
+		server.RespondWithError(w, parseErr.Error(), http.StatusBadRequest)
+		return
+	}
+
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+
+	if r.Method == http.MethodDelete {
+		removed, err := s.Service.RemoveEntry(entryId, todoId, authenticatedUser.Id)
+		if err != nil {
+			respondWithTimeError(w, "error while deleting the entry", err)
+			return
+		}
+		server.RespondNoContent(w, removed)
+		return
+	}
+
+	var updateData UpdateEntryData
+	if err := server.DecodeBody(r, &updateData); err != nil {
+		server.RespondWithError(w, fmt.Sprintf("error while parsing: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	updated, err := s.Service.UpdateEntry(entryId, todoId, authenticatedUser.Id, &updateData)
+	if err != nil {
+		respondWithTimeError(w, "error while updating the entry", err)
+		return
+	}
+	server.RespondOK(w, updated)
+}
+
+// handleProjectTime responds with the time tracked on the todos of the project
+func (s *APIRoute) handleProjectTime(w http.ResponseWriter, r *http.Request) {
+	// only GET methods are allowed
+	if r.Method != http.MethodGet {
+		err := server.ErrNotValidMethod.With("only GET methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	projectId, _, parseErr := parseIds(r, false)
+	if parseErr != nil {
+		server.RespondWithError(w, parseErr.Error(), http.StatusBadRequest)
+		return
+	}
+
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+	projectTime, err := s.Service.GetProjectTime(projectId, authenticatedUser.Id)
+	if err != nil {
+		respondWithTimeError(w, "error while getting the tracked time", err)
+		return
+	}
+	server.RespondOK(w, projectTime)
+}
+
+// handleReport responds with the tracked time in a date range, grouped by day, todo or project
+func (s *APIRoute) handleReport(w http.ResponseWriter, r *http.Request) {
+	// only GET methods are allowed
+	if r.Method != http.MethodGet {
+		err := server.ErrNotValidMethod.With("only GET methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	options, err := parseReportOptions(r.URL.Query())
+	if err != nil {
+		respondWithTimeError(w, "error while parsing the report options", err)
+		return
+	}
+
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+	report, err := s.Service.GetReport(authenticatedUser.Id, options)
+	if err != nil {
+		respondWithTimeError(w, "error while getting the report", err)
+		return
+	}
+	server.RespondOK(w, report)
+}
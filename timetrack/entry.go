@@ -0,0 +1,153 @@
+package timetrack
+
+import (
+	"github.com/jackc/pgx/v5"
+	"time"
+	"unicode/utf8"
+)
+
+// Entry is a period of time a user has worked on a todo. It is either tracked with a timer or added manually
+type Entry struct {
+	Id        int       `json:"id"`
+	TodoId    int       `json:"todoId"`
+	UserId    int64     `json:"userId"`
+	Note      string    `json:"note"`
+	StartedAt time.Time `json:"startedAt"`
+	// EndedAt is nil while the timer of the entry is running
+	EndedAt *time.Time `json:"endedAt"`
+	// Seconds is the tracked time. It keeps growing until the timer is stopped
+	Seconds   int64     `json:"seconds"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type StartTimerData struct {
+	Note string `json:"note"`
+}
+
+// CreateEntryData is a manually added entry. Either the end or the minutes need to be sent with the start
+type CreateEntryData struct {
+	StartedAt *time.Time `json:"startedAt"`
+	EndedAt   *time.Time `json:"endedAt"`
+	Minutes   *int       `json:"minutes"`
+	Note      string     `json:"note"`
+}
+
+type UpdateEntryData struct {
+	StartedAt *time.Time `json:"startedAt,omitempty"`
+	EndedAt   *time.Time `json:"endedAt,omitempty"`
+	Note      *string    `json:"note,omitempty"`
+}
+
+// TodoTime is the time tracked on a todo by all of its users
+type TodoTime struct {
+	TodoId  int     `json:"todoId"`
+	Seconds int64   `json:"seconds"`
+	Entries []Entry `json:"entries"`
+}
+
+type TodoTotal struct {
+	TodoId  int    `json:"todoId"`
+	Title   string `json:"title"`
+	Seconds int64  `json:"seconds"`
+}
+
+// ProjectTime is the time tracked on the todos of a project, which are not in the trash
+type ProjectTime struct {
+	ProjectId int         `json:"projectId"`
+	Seconds   int64       `json:"seconds"`
+	Todos     []TodoTotal `json:"todos"`
+}
+
+// ReportOptions are the options of a time report. From is inclusive and To is exclusive
+type ReportOptions struct {
+	From time.Time
+	To   time.Time
+	// Timezone is the timezone of the days when the entries are grouped by day
+	Timezone string
+	GroupBy  string
+	// ProjectId limits the report to a project. The entries of all users are reported then,
+	// otherwise only the entries of the user are
+	ProjectId *int
+}
+
+// ReportRow is the tracked time of a group. Key is the day, the id of the todo or the id of the project,
+// and Label is the day, the title of the todo or the name of the project
+type ReportRow struct {
+	Key     string `json:"key"`
+	Label   string `json:"label"`
+	Seconds int64  `json:"seconds"`
+}
+
+type Report struct {
+	From    time.Time   `json:"from"`
+	To      time.Time   `json:"to"`
+	GroupBy string      `json:"groupBy"`
+	Seconds int64       `json:"seconds"`
+	Rows    []ReportRow `json:"rows"`
+}
+
+const (
+	GroupByDay     = "day"
+	GroupByTodo    = "todo"
+	GroupByProject = "project"
+)
+
+const (
+	// MaxEntryDuration is the longest time a manually added entry can have
+	MaxEntryDuration = 24 * time.Hour
+	// MaxReportRange is the longest period a report can cover
+	MaxReportRange = 366 * 24 * time.Hour
+)
+
+const maxNoteLength = 500
+
+// entryColumns are the columns selected for scanning an entry with ScanEntry
+const entryColumns = `id, todo_id, user_id, note, started_at, ended_at,
+	EXTRACT(EPOCH FROM COALESCE(ended_at, now()) - started_at)::bigint, created_at`
+
+func ScanEntry(row pgx.Row) (*Entry, error) {
+	e := new(Entry)
+	err := row.Scan(&e.Id, &e.TodoId, &e.UserId, &e.Note, &e.StartedAt, &e.EndedAt, &e.Seconds, &e.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// validateNote checks the length of the note
+func validateNote(note string) error {
+	if utf8.RuneCountInString(note) > maxNoteLength {
+		return ErrNoteLength
+	}
+	return nil
+}
+
+// validatePeriod checks that the entry doesn't start in the future and ends after it starts.
+// A running entry has no end
+func validatePeriod(startedAt time.Time, endedAt *time.Time) error {
+	now := time.Now()
+	if startedAt.After(now) {
+		return ErrPeriodNotValid
+	}
+
+	if endedAt == nil {
+		return nil
+	}
+
+	if !endedAt.After(startedAt) || endedAt.After(now) {
+		return ErrPeriodNotValid
+	}
+	if endedAt.Sub(startedAt) > MaxEntryDuration {
+		return ErrDurationNotValid
+	}
+	return nil
+}
+
+// sumSeconds adds up the tracked time of the entries
+func sumSeconds(entries []Entry) int64 {
+	var seconds int64
+	for _, e := range entries {
+		seconds += e.Seconds
+	}
+	return seconds
+}
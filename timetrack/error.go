@@ -0,0 +1,89 @@
+package timetrack
+
+type errKind int
+
+const (
+	_ errKind = iota
+	noteLength
+	periodNotValid
+	durationNotValid
+	noFieldToUpdate
+	todoNotFound
+	projectNotFound
+	notAllowed
+	timerRunning
+	timerNotRunning
+	entryNotFound
+	entryRunning
+	notOwner
+	reportRangeNotValid
+	groupNotValid
+	timezoneNotValid
+)
+
+type TimeError struct {
+	kind   errKind
+	fields []string
+}
+
+type Fields []string
+
+func (e TimeError) Error() string {
+	switch e.kind {
+	case noteLength:
+		return "note can be at most 500 characters"
+	case periodNotValid:
+		return "entry needs to start in the past and end after it starts, but not in the future"
+	case durationNotValid:
+		return "entry can be at most 24 hours long"
+	case noFieldToUpdate:
+		return "no field is provided"
+	case todoNotFound:
+		return "todo not found"
+	case projectNotFound:
+		return "project not found"
+	case notAllowed:
+		return "only the owner and the editors of the todo can track time on it"
+	case timerRunning:
+		return "another timer is already running"
+	case timerNotRunning:
+		return "timer is not running"
+	case entryNotFound:
+		return "entry not found"
+	case entryRunning:
+		return "timer of the entry needs to be stopped to change its end"
+	case notOwner:
+		return "only the user who tracked the time can change the entry"
+	case reportRangeNotValid:
+		return "from and to need to be days, and the range can be at most 366 days"
+	case groupNotValid:
+		return "group_by needs to be one of day, todo and project"
+	case timezoneNotValid:
+		return "timezone is not valid"
+	}
+	return "error in time tracking"
+}
+
+// Is reports whether the target is a TimeError of the same kind so that errors.Is can be used
+func (e TimeError) Is(target error) bool {
+	t, ok := target.(TimeError)
+	return ok && t.kind == e.kind
+}
+
+var (
+	ErrNoteLength          = TimeError{kind: noteLength, fields: Fields{"note"}}
+	ErrPeriodNotValid      = TimeError{kind: periodNotValid, fields: Fields{"startedAt", "endedAt", "minutes"}}
+	ErrDurationNotValid    = TimeError{kind: durationNotValid, fields: Fields{"endedAt", "minutes"}}
+	ErrNoFieldToUpdate     = TimeError{kind: noFieldToUpdate}
+	ErrTodoNotFound        = TimeError{kind: todoNotFound}
+	ErrProjectNotFound     = TimeError{kind: projectNotFound, fields: Fields{"project"}}
+	ErrNotAllowed          = TimeError{kind: notAllowed}
+	ErrTimerRunning        = TimeError{kind: timerRunning}
+	ErrTimerNotRunning     = TimeError{kind: timerNotRunning}
+	ErrEntryNotFound       = TimeError{kind: entryNotFound}
+	ErrEntryRunning        = TimeError{kind: entryRunning, fields: Fields{"endedAt"}}
+	ErrNotOwner            = TimeError{kind: notOwner}
+	ErrReportRangeNotValid = TimeError{kind: reportRangeNotValid, fields: Fields{"from", "to"}}
+	ErrGroupNotValid       = TimeError{kind: groupNotValid, fields: Fields{"group_by"}}
+	ErrTimezoneNotValid    = TimeError{kind: timezoneNotValid, fields: Fields{"tz"}}
+)
@@ -0,0 +1,272 @@
+package timetrack
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type IRepository interface {
+	StartTimer(todoId int, userId int64, note string) (*Entry, error)
+	StopTimer(todoId int, userId int64) (*Entry, error)
+	GetRunningTimer(userId int64) (*Entry, error)
+	CreateEntry(todoId int, userId int64, data *CreateEntryData) (*Entry, error)
+	GetEntries(todoId int) ([]Entry, error)
+	GetEntry(entryId int, todoId int) (*Entry, error)
+	UpdateEntry(entryId int, todoId int, data *UpdateEntryData) (*Entry, error)
+	RemoveEntry(entryId int, todoId int) (*Entry, error)
+	GetProjectTotals(projectId int) ([]TodoTotal, error)
+	GetReport(userId int64, options *ReportOptions) ([]ReportRow, error)
+	StopTrashedTimers() (int64, error)
+}
+
+type Repository struct {
+	DB *pgxpool.Pool
+}
+
+func NewTimeTrackRepository(dbConn *pgxpool.Pool) (*Repository, error) {
+	return &Repository{dbConn}, nil
+}
+
+func (store *Repository) Init() error {
+	return store.CreateTimeEntryTable()
+}
+
+// CreateTimeEntryTable creates the time entry table. The entries are deleted with their todos, and a user can only
+// have one entry without an end, which is the running timer
+func (store *Repository) CreateTimeEntryTable() error {
+	queries := []string{
+		`CREATE TABLE IF NOT EXISTS "time_entry" (
+			id serial PRIMARY KEY,
+			todo_id integer NOT NULL REFERENCES "todo"(id) ON DELETE CASCADE,
+			user_id integer NOT NULL REFERENCES "user"(id) ON DELETE CASCADE,
+			note varchar(500) NOT NULL DEFAULT '',
+			started_at timestamptz NOT NULL,
+			ended_at timestamptz,
+			created_at timestamp DEFAULT now(),
+			CHECK (ended_at IS NULL OR ended_at >= started_at)
+		)`,
+		`CREATE INDEX IF NOT EXISTS time_entry_todo_id_idx ON "time_entry" (todo_id, started_at)`,
+		`CREATE INDEX IF NOT EXISTS time_entry_user_id_idx ON "time_entry" (user_id, started_at)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS time_entry_running_idx ON "time_entry" (user_id) WHERE ended_at IS NULL`,
+	}
+
+	for _, query := range queries {
+		if _, err := store.DB.Exec(context.Background(), query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uniqueViolation is the postgres error code for unique constraint violations
+const uniqueViolation = "23505"
+
+// mapTimerRunning returns ErrTimerRunning if the error is caused by the unique index on the running timers
+func mapTimerRunning(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return ErrTimerRunning
+	}
+	return err
+}
+
+func (store *Repository) StartTimer(todoId int, userId int64, note string) (*Entry, error) {
+	query := `INSERT INTO "time_entry"(todo_id, user_id, note, started_at) VALUES (@todoId, @userId, @note, now())
+		RETURNING ` + entryColumns
+	args := pgx.NamedArgs{
+		"todoId": todoId,
+		"userId": userId,
+		"note":   note,
+	}
+
+	started, err := ScanEntry(store.DB.QueryRow(context.Background(), query, args))
+	if err != nil {
+		return nil, mapTimerRunning(err)
+	}
+	return started, nil
+}
+
+// StopTimer ends the running timer of the user if it is running on the todo
+func (store *Repository) StopTimer(todoId int, userId int64) (*Entry, error) {
+	query := `UPDATE "time_entry" SET ended_at = now()
+		WHERE todo_id = @todoId AND user_id = @userId AND ended_at IS NULL
+		RETURNING ` + entryColumns
+	args := pgx.NamedArgs{"todoId": todoId, "userId": userId}
+
+	return ScanEntry(store.DB.QueryRow(context.Background(), query, args))
+}
+
+func (store *Repository) GetRunningTimer(userId int64) (*Entry, error) {
+	query := `SELECT ` + entryColumns + ` FROM "time_entry" WHERE user_id = @userId AND ended_at IS NULL`
+	args := pgx.NamedArgs{"userId": userId}
+
+	return ScanEntry(store.DB.QueryRow(context.Background(), query, args))
+}
+
+func (store *Repository) CreateEntry(todoId int, userId int64, data *CreateEntryData) (*Entry, error) {
+	query := `INSERT INTO "time_entry"(todo_id, user_id, note, started_at, ended_at)
+		VALUES (@todoId, @userId, @note, @startedAt, @endedAt)
+		RETURNING ` + entryColumns
+	args := pgx.NamedArgs{
+		"todoId":    todoId,
+		"userId":    userId,
+		"note":      data.Note,
+		"startedAt": data.StartedAt,
+		"endedAt":   data.EndedAt,
+	}
+
+	return ScanEntry(store.DB.QueryRow(context.Background(), query, args))
+}
+
+// GetEntries returns the entries of all users on the todo, oldest first
+func (store *Repository) GetEntries(todoId int) ([]Entry, error) {
+	query := `SELECT ` + entryColumns + ` FROM "time_entry" WHERE todo_id = @todoId ORDER BY started_at, id`
+	args := pgx.NamedArgs{"todoId": todoId}
+
+	rows, err := store.DB.Query(context.Background(), query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []Entry{}
+	for rows.Next() {
+		e, err := ScanEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *e)
+	}
+	return entries, rows.Err()
+}
+
+func (store *Repository) GetEntry(entryId int, todoId int) (*Entry, error) {
+	query := `SELECT ` + entryColumns + ` FROM "time_entry" WHERE id = @entryId AND todo_id = @todoId`
+	args := pgx.NamedArgs{"entryId": entryId, "todoId": todoId}
+
+	return ScanEntry(store.DB.QueryRow(context.Background(), query, args))
+}
+
+// UpdateEntry changes the fields of the entry which are sent
+func (store *Repository) UpdateEntry(entryId int, todoId int, data *UpdateEntryData) (*Entry, error) {
+	query := `UPDATE "time_entry" SET
+			note = COALESCE(@note, note),
+			started_at = COALESCE(@startedAt, started_at),
+			ended_at = COALESCE(@endedAt, ended_at)
+		WHERE id = @entryId AND todo_id = @todoId
+		RETURNING ` + entryColumns
+	args := pgx.NamedArgs{
+		"entryId":   entryId,
+		"todoId":    todoId,
+		"note":      data.Note,
+		"startedAt": data.StartedAt,
+		"endedAt":   data.EndedAt,
+	}
+
+	return ScanEntry(store.DB.QueryRow(context.Background(), query, args))
+}
+
+func (store *Repository) RemoveEntry(entryId int, todoId int) (*Entry, error) {
+	query := `DELETE FROM "time_entry" WHERE id = @entryId AND todo_id = @todoId RETURNING ` + entryColumns
+	args := pgx.NamedArgs{"entryId": entryId, "todoId": todoId}
+
+	return ScanEntry(store.DB.QueryRow(context.Background(), query, args))
+}
+
+// secondsColumn is the tracked time of an entry in the queries with the entries as e
+const secondsColumn = `EXTRACT(EPOCH FROM COALESCE(e.ended_at, now()) - e.started_at)::bigint`
+
+// GetProjectTotals returns the time tracked on each todo of the project that is not in the trash
+func (store *Repository) GetProjectTotals(projectId int) ([]TodoTotal, error) {
+	query := `SELECT t.id, t.title, SUM(` + secondsColumn + `)::bigint
+		FROM "time_entry" e JOIN "todo" t ON t.id = e.todo_id
+		WHERE t.project_id = @projectId AND t.deleted_at IS NULL
+		GROUP BY t.id, t.title
+		ORDER BY t.id`
+	args := pgx.NamedArgs{"projectId": projectId}
+
+	rows, err := store.DB.Query(context.Background(), query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := []TodoTotal{}
+	for rows.Next() {
+		var total TodoTotal
+		if err := rows.Scan(&total.TodoId, &total.Title, &total.Seconds); err != nil {
+			return nil, err
+		}
+		totals = append(totals, total)
+	}
+	return totals, rows.Err()
+}
+
+// reportGroups are the key, the label and the order of the rows for each grouping of a report.
+// The days are ordered chronologically, and the todos and the projects by the tracked time
+var reportGroups = map[string][3]string{
+	GroupByDay: {
+		`to_char(e.started_at AT TIME ZONE @timezone, 'YYYY-MM-DD')`,
+		`to_char(e.started_at AT TIME ZONE @timezone, 'YYYY-MM-DD')`,
+		`1`,
+	},
+	GroupByTodo:    {`t.id::text`, `t.title`, `3 DESC, 1`},
+	GroupByProject: {`COALESCE(p.id::text, '')`, `COALESCE(p.name, '')`, `3 DESC, 1`},
+}
+
+// GetReport sums the tracked time of the entries started in the range of the report. It covers the entries of the
+// user, or the entries of all users in the project of the report. Entries of the todos in the trash are included
+func (store *Repository) GetReport(userId int64, options *ReportOptions) ([]ReportRow, error) {
+	group := reportGroups[options.GroupBy]
+
+	scope := `e.user_id = @userId`
+	if options.ProjectId != nil {
+		scope = `t.project_id = @projectId`
+	}
+
+	query := `SELECT ` + group[0] + `, ` + group[1] + `, SUM(` + secondsColumn + `)::bigint
+		FROM "time_entry" e
+		JOIN "todo" t ON t.id = e.todo_id
+		LEFT JOIN "project" p ON p.id = t.project_id
+		WHERE e.started_at >= @from AND e.started_at < @to AND ` + scope + `
+		GROUP BY 1, 2
+		ORDER BY ` + group[2]
+	args := pgx.NamedArgs{
+		"userId":    userId,
+		"projectId": options.ProjectId,
+		"from":      options.From,
+		"to":        options.To,
+		"timezone":  options.Timezone,
+	}
+
+	rows, err := store.DB.Query(context.Background(), query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	report := []ReportRow{}
+	for rows.Next() {
+		var row ReportRow
+		if err := rows.Scan(&row.Key, &row.Label, &row.Seconds); err != nil {
+			return nil, err
+		}
+		report = append(report, row)
+	}
+	return report, rows.Err()
+}
+
+// StopTrashedTimers stops the running timers of the todos in the trash
+func (store *Repository) StopTrashedTimers() (int64, error) {
+	query := `UPDATE "time_entry" SET ended_at = now()
+		WHERE ended_at IS NULL AND todo_id IN (SELECT id FROM "todo" WHERE deleted_at IS NOT NULL)`
+
+	commandTag, err := store.DB.Exec(context.Background(), query)
+	if err != nil {
+		return 0, err
+	}
+	return commandTag.RowsAffected(), nil
+}
@@ -0,0 +1,262 @@
+package timetrack
+
+import (
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/umtdemr/go-todo/project"
+	"github.com/umtdemr/go-todo/share"
+	"github.com/umtdemr/go-todo/todo"
+	"time"
+)
+
+// TodoGetter is used to check that the user can see the todo. It is implemented by the todo service
+type TodoGetter interface {
+	GetTodo(todoId int, userId int64) (*todo.Todo, error)
+}
+
+// ProjectGetter is used to check that the user can see the project. It is implemented by the project service
+type ProjectGetter interface {
+	GetProject(projectId int, userId int64) (*project.Project, error)
+}
+
+type Service struct {
+	Repository IRepository
+	Todos      TodoGetter
+	Projects   ProjectGetter
+}
+
+func NewTimeTrackService(repo IRepository, todos TodoGetter, projects ProjectGetter) *Service {
+	return &Service{Repository: repo, Todos: todos, Projects: projects}
+}
+
+// checkTodo makes sure that the user can see the todo and has at least the needed role on it
+func (service *Service) checkTodo(todoId int, userId int64, needed share.Role) error {
+	t, err := service.Todos.GetTodo(todoId, userId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrTodoNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if !t.Role.Includes(needed) {
+		return ErrNotAllowed
+	}
+	return nil
+}
+
+// checkProject makes sure that the user can see the project
+func (service *Service) checkProject(projectId int, userId int64) error {
+	if _, err := service.Projects.GetProject(projectId, userId); err != nil {
+		if errors.Is(err, project.ErrProjectNotFound) {
+			return ErrProjectNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// StartTimer starts a timer of the user on the todo. A user can only have one running timer
+func (service *Service) StartTimer(todoId int, userId int64, data *StartTimerData) (*Entry, error) {
+	if err := validateNote(data.Note); err != nil {
+		return nil, err
+	}
+
+	if err := service.checkTodo(todoId, userId, share.RoleEditor); err != nil {
+		return nil, err
+	}
+	return service.Repository.StartTimer(todoId, userId, data.Note)
+}
+
+// StopTimer stops the timer of the user on the todo
+func (service *Service) StopTimer(todoId int, userId int64) (*Entry, error) {
+	stopped, err := service.Repository.StopTimer(todoId, userId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrTimerNotRunning
+	}
+	return stopped, err
+}
+
+// GetRunningTimer returns the running timer of the user. It is nil if no timer is running
+func (service *Service) GetRunningTimer(userId int64) (*Entry, error) {
+	running, err := service.Repository.GetRunningTimer(userId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return running, err
+}
+
+// CreateEntry adds the time the user has worked on the todo without a timer
+func (service *Service) CreateEntry(todoId int, userId int64, data *CreateEntryData) (*Entry, error) {
+	if data.StartedAt == nil || (data.EndedAt == nil) == (data.Minutes == nil) {
+		return nil, ErrPeriodNotValid
+	}
+
+	if data.Minutes != nil {
+		if *data.Minutes < 1 {
+			return nil, ErrDurationNotValid
+		}
+		endedAt := data.StartedAt.Add(time.Duration(*data.Minutes) * time.Minute)
+		data.EndedAt = &endedAt
+	}
+
+	if err := validatePeriod(*data.StartedAt, data.EndedAt); err != nil {
+		return nil, err
+	}
+
+	if err := validateNote(data.Note); err != nil {
+		return nil, err
+	}
+
+	if err := service.checkTodo(todoId, userId, share.RoleEditor); err != nil {
+		return nil, err
+	}
+	return service.Repository.CreateEntry(todoId, userId, data)
+}
+
+// GetTodoTime returns the entries of all users on the todo with their total
+func (service *Service) GetTodoTime(todoId int, userId int64) (*TodoTime, error) {
+	if err := service.checkTodo(todoId, userId, share.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	entries, err := service.Repository.GetEntries(todoId)
+	if err != nil {
+		return nil, err
+	}
+	return &TodoTime{TodoId: todoId, Seconds: sumSeconds(entries), Entries: entries}, nil
+}
+
+// getOwnEntry returns the entry if it is tracked by the user
+func (service *Service) getOwnEntry(entryId int, todoId int, userId int64) (*Entry, error) {
+	if err := service.checkTodo(todoId, userId, share.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	e, err := service.Repository.GetEntry(entryId, todoId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrEntryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if e.UserId != userId {
+		return nil, ErrNotOwner
+	}
+	return e, nil
+}
+
+// UpdateEntry changes the period or the note of the entry of the user. The end of a running entry can't be changed
+func (service *Service) UpdateEntry(entryId int, todoId int, userId int64, data *UpdateEntryData) (*Entry, error) {
+	if data.StartedAt == nil && data.EndedAt == nil && data.Note == nil {
+		return nil, ErrNoFieldToUpdate
+	}
+
+	if data.Note != nil {
+		if err := validateNote(*data.Note); err != nil {
+			return nil, err
+		}
+	}
+
+	current, err := service.getOwnEntry(entryId, todoId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if current.EndedAt == nil && data.EndedAt != nil {
+		return nil, ErrEntryRunning
+	}
+
+	startedAt, endedAt := current.StartedAt, current.EndedAt
+	if data.StartedAt != nil {
+		startedAt = *data.StartedAt
+	}
+	if data.EndedAt != nil {
+		endedAt = data.EndedAt
+	}
+	if err := validatePeriod(startedAt, endedAt); err != nil {
+		return nil, err
+	}
+
+	updated, err := service.Repository.UpdateEntry(entryId, todoId, data)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrEntryNotFound
+	}
+	return updated, err
+}
+
+// RemoveEntry deletes the entry of the user. Deleting a running entry discards the timer
+func (service *Service) RemoveEntry(entryId int, todoId int, userId int64) (*Entry, error) {
+	if _, err := service.getOwnEntry(entryId, todoId, userId); err != nil {
+		return nil, err
+	}
+
+	removed, err := service.Repository.RemoveEntry(entryId, todoId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrEntryNotFound
+	}
+	return removed, err
+}
+
+// GetProjectTime returns the time tracked on each todo of the project with their total
+func (service *Service) GetProjectTime(projectId int, userId int64) (*ProjectTime, error) {
+	if err := service.checkProject(projectId, userId); err != nil {
+		return nil, err
+	}
+
+	totals, err := service.Repository.GetProjectTotals(projectId)
+	if err != nil {
+		return nil, err
+	}
+
+	projectTime := &ProjectTime{ProjectId: projectId, Todos: totals}
+	for _, total := range totals {
+		projectTime.Seconds += total.Seconds
+	}
+	return projectTime, nil
+}
+
+// GetReport sums the tracked time in the range of the report, grouped by day, todo or project
+func (service *Service) GetReport(userId int64, options *ReportOptions) (*Report, error) {
+	if options.GroupBy == "" {
+		options.GroupBy = GroupByDay
+	}
+	if _, ok := reportGroups[options.GroupBy]; !ok {
+		return nil, ErrGroupNotValid
+	}
+
+	if !options.To.After(options.From) || options.To.Sub(options.From) > MaxReportRange {
+		return nil, ErrReportRangeNotValid
+	}
+
+	if options.Timezone == "" {
+		options.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(options.Timezone); err != nil {
+		return nil, ErrTimezoneNotValid
+	}
+
+	if options.ProjectId != nil {
+		if err := service.checkProject(*options.ProjectId, userId); err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := service.Repository.GetReport(userId, options)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{From: options.From, To: options.To, GroupBy: options.GroupBy, Rows: rows}
+	for _, row := range rows {
+		report.Seconds += row.Seconds
+	}
+	return report, nil
+}
+
+// StopTrashedTimers stops the running timers of the todos in the trash. It is called by the todo service when
+// todos are moved to the trash
+func (service *Service) StopTrashedTimers() (int64, error) {
+	return service.Repository.StopTrashedTimers()
+}
@@ -0,0 +1,409 @@
+package timetrack
+
+import (
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/umtdemr/go-todo/project"
+	"github.com/umtdemr/go-todo/share"
+	"github.com/umtdemr/go-todo/todo"
+	"strings"
+	"testing"
+	"time"
+)
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) StartTimer(todoId int, userId int64, note string) (*Entry, error) {
+	args := m.Called(todoId, userId, note)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Entry), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) StopTimer(todoId int, userId int64) (*Entry, error) {
+	args := m.Called(todoId, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Entry), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) GetRunningTimer(userId int64) (*Entry, error) {
+	args := m.Called(userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Entry), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) CreateEntry(todoId int, userId int64, data *CreateEntryData) (*Entry, error) {
+	args := m.Called(todoId, userId, data)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Entry), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) GetEntries(todoId int) ([]Entry, error) {
+	args := m.Called(todoId)
+	return args.Get(0).([]Entry), args.Error(1)
+}
+
+func (m *MockRepository) GetEntry(entryId int, todoId int) (*Entry, error) {
+	args := m.Called(entryId, todoId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Entry), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) UpdateEntry(entryId int, todoId int, data *UpdateEntryData) (*Entry, error) {
+	args := m.Called(entryId, todoId, data)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Entry), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) RemoveEntry(entryId int, todoId int) (*Entry, error) {
+	args := m.Called(entryId, todoId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Entry), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) GetProjectTotals(projectId int) ([]TodoTotal, error) {
+	args := m.Called(projectId)
+	return args.Get(0).([]TodoTotal), args.Error(1)
+}
+
+func (m *MockRepository) GetReport(userId int64, options *ReportOptions) ([]ReportRow, error) {
+	args := m.Called(userId, options)
+	return args.Get(0).([]ReportRow), args.Error(1)
+}
+
+func (m *MockRepository) StopTrashedTimers() (int64, error) {
+	args := m.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
+
+type MockTodoGetter struct {
+	mock.Mock
+}
+
+func (m *MockTodoGetter) GetTodo(todoId int, userId int64) (*todo.Todo, error) {
+	args := m.Called(todoId, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*todo.Todo), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+type MockProjectGetter struct {
+	mock.Mock
+}
+
+func (m *MockProjectGetter) GetProject(projectId int, userId int64) (*project.Project, error) {
+	args := m.Called(projectId, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*project.Project), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func TestStartTimer(t *testing.T) {
+	tests := []struct {
+		name          string
+		data          StartTimerData
+		setupMock     func(repo *MockRepository, todos *MockTodoGetter)
+		expectedError error
+	}{
+		{
+			name:          "Note is too long",
+			data:          StartTimerData{Note: strings.Repeat("a", maxNoteLength+1)},
+			setupMock:     func(repo *MockRepository, todos *MockTodoGetter) {},
+			expectedError: ErrNoteLength,
+		},
+		{
+			name: "User can't see the todo",
+			setupMock: func(repo *MockRepository, todos *MockTodoGetter) {
+				todos.On("GetTodo", 3, int64(1)).Return(nil, pgx.ErrNoRows)
+			},
+			expectedError: ErrTodoNotFound,
+		},
+		{
+			name: "Viewers can't track time",
+			setupMock: func(repo *MockRepository, todos *MockTodoGetter) {
+				todos.On("GetTodo", 3, int64(1)).Return(&todo.Todo{Id: 3, Role: share.RoleViewer}, nil)
+			},
+			expectedError: ErrNotAllowed,
+		},
+		{
+			name: "Another timer of the user is running",
+			setupMock: func(repo *MockRepository, todos *MockTodoGetter) {
+				todos.On("GetTodo", 3, int64(1)).Return(&todo.Todo{Id: 3, Role: share.RoleEditor}, nil)
+				repo.On("StartTimer", 3, int64(1), "").Return(nil, ErrTimerRunning)
+			},
+			expectedError: ErrTimerRunning,
+		},
+		{
+			name: "Timer is started",
+			data: StartTimerData{Note: "review"},
+			setupMock: func(repo *MockRepository, todos *MockTodoGetter) {
+				todos.On("GetTodo", 3, int64(1)).Return(&todo.Todo{Id: 3, Role: share.RoleOwner}, nil)
+				repo.On("StartTimer", 3, int64(1), "review").Return(&Entry{Id: 1, TodoId: 3, UserId: 1}, nil)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockTodos := new(MockTodoGetter)
+			service := NewTimeTrackService(mockRepo, mockTodos, new(MockProjectGetter))
+			tc.setupMock(mockRepo, mockTodos)
+
+			started, err := service.StartTimer(3, 1, &tc.data)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, 1, started.Id)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestStopTimer(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewTimeTrackService(mockRepo, new(MockTodoGetter), new(MockProjectGetter))
+
+	mockRepo.On("StopTimer", 3, int64(1)).Return(nil, pgx.ErrNoRows)
+	_, err := service.StopTimer(3, 1)
+	assert.ErrorIs(t, err, ErrTimerNotRunning)
+}
+
+func TestCreateEntry(t *testing.T) {
+	startedAt := time.Now().Add(-3 * time.Hour)
+	endedAt := startedAt.Add(90 * time.Minute)
+	beforeStart := startedAt.Add(-time.Minute)
+	inFuture := time.Now().Add(time.Hour)
+	tooLong := startedAt.Add(-MaxEntryDuration)
+	minutes := 90
+	noMinutes := 0
+
+	tests := []struct {
+		name            string
+		data            CreateEntryData
+		expectedEndedAt *time.Time
+		expectedError   error
+	}{
+		{
+			name:          "Start is missing",
+			data:          CreateEntryData{EndedAt: &endedAt},
+			expectedError: ErrPeriodNotValid,
+		},
+		{
+			name:          "Neither the end nor the minutes are sent",
+			data:          CreateEntryData{StartedAt: &startedAt},
+			expectedError: ErrPeriodNotValid,
+		},
+		{
+			name:          "Both the end and the minutes are sent",
+			data:          CreateEntryData{StartedAt: &startedAt, EndedAt: &endedAt, Minutes: &minutes},
+			expectedError: ErrPeriodNotValid,
+		},
+		{
+			name:          "Entry ends before it starts",
+			data:          CreateEntryData{StartedAt: &startedAt, EndedAt: &beforeStart},
+			expectedError: ErrPeriodNotValid,
+		},
+		{
+			name:          "Entry ends in the future",
+			data:          CreateEntryData{StartedAt: &startedAt, EndedAt: &inFuture},
+			expectedError: ErrPeriodNotValid,
+		},
+		{
+			name:          "Entry is longer than a day",
+			data:          CreateEntryData{StartedAt: &tooLong, EndedAt: &endedAt},
+			expectedError: ErrDurationNotValid,
+		},
+		{
+			name:          "Minutes are not positive",
+			data:          CreateEntryData{StartedAt: &startedAt, Minutes: &noMinutes},
+			expectedError: ErrDurationNotValid,
+		},
+		{
+			name:            "Entry is created with the end",
+			data:            CreateEntryData{StartedAt: &startedAt, EndedAt: &endedAt},
+			expectedEndedAt: &endedAt,
+		},
+		{
+			name:            "End is calculated from the minutes",
+			data:            CreateEntryData{StartedAt: &startedAt, Minutes: &minutes},
+			expectedEndedAt: &endedAt,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockTodos := new(MockTodoGetter)
+			service := NewTimeTrackService(mockRepo, mockTodos, new(MockProjectGetter))
+
+			mockTodos.On("GetTodo", 3, int64(1)).Return(&todo.Todo{Id: 3, Role: share.RoleOwner}, nil)
+			mockRepo.On("CreateEntry", 3, int64(1), &tc.data).Return(&Entry{Id: 1}, nil)
+
+			_, err := service.CreateEntry(3, 1, &tc.data)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				mockRepo.AssertNotCalled(t, "CreateEntry", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			assert.Nil(t, err)
+			assert.True(t, tc.expectedEndedAt.Equal(*tc.data.EndedAt))
+		})
+	}
+}
+
+func TestUpdateEntry(t *testing.T) {
+	startedAt := time.Now().Add(-2 * time.Hour)
+	endedAt := time.Now().Add(-time.Hour)
+	note := "fixed the build"
+
+	tests := []struct {
+		name          string
+		data          UpdateEntryData
+		current       *Entry
+		expectedError error
+	}{
+		{
+			name:          "No field is sent",
+			expectedError: ErrNoFieldToUpdate,
+		},
+		{
+			name:          "Entry is tracked by another user",
+			data:          UpdateEntryData{Note: &note},
+			current:       &Entry{Id: 5, TodoId: 3, UserId: 2, StartedAt: startedAt, EndedAt: &endedAt},
+			expectedError: ErrNotOwner,
+		},
+		{
+			name:          "End of a running entry can't be changed",
+			data:          UpdateEntryData{EndedAt: &endedAt},
+			current:       &Entry{Id: 5, TodoId: 3, UserId: 1, StartedAt: startedAt},
+			expectedError: ErrEntryRunning,
+		},
+		{
+			name:          "New start is after the current end",
+			data:          UpdateEntryData{StartedAt: &endedAt},
+			current:       &Entry{Id: 5, TodoId: 3, UserId: 1, StartedAt: startedAt, EndedAt: &endedAt},
+			expectedError: ErrPeriodNotValid,
+		},
+		{
+			name:    "Note of a running entry is changed",
+			data:    UpdateEntryData{Note: &note},
+			current: &Entry{Id: 5, TodoId: 3, UserId: 1, StartedAt: startedAt},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockTodos := new(MockTodoGetter)
+			service := NewTimeTrackService(mockRepo, mockTodos, new(MockProjectGetter))
+
+			mockTodos.On("GetTodo", 3, int64(1)).Return(&todo.Todo{Id: 3, Role: share.RoleEditor}, nil)
+			mockRepo.On("GetEntry", 5, 3).Return(tc.current, nil)
+			mockRepo.On("UpdateEntry", 5, 3, &tc.data).Return(&Entry{Id: 5}, nil)
+
+			_, err := service.UpdateEntry(5, 3, 1, &tc.data)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				mockRepo.AssertNotCalled(t, "UpdateEntry", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			assert.Nil(t, err)
+			mockRepo.AssertCalled(t, "UpdateEntry", 5, 3, &tc.data)
+		})
+	}
+}
+
+func TestGetReport(t *testing.T) {
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	projectId := 4
+
+	tests := []struct {
+		name          string
+		options       ReportOptions
+		setupMock     func(repo *MockRepository, projects *MockProjectGetter)
+		expectedError error
+	}{
+		{
+			name:          "Grouping is not valid",
+			options:       ReportOptions{From: from, To: from.AddDate(0, 0, 7), GroupBy: "week"},
+			setupMock:     func(repo *MockRepository, projects *MockProjectGetter) {},
+			expectedError: ErrGroupNotValid,
+		},
+		{
+			name:          "Range ends before it starts",
+			options:       ReportOptions{From: from, To: from.AddDate(0, 0, -1)},
+			setupMock:     func(repo *MockRepository, projects *MockProjectGetter) {},
+			expectedError: ErrReportRangeNotValid,
+		},
+		{
+			name:          "Range is longer than a year",
+			options:       ReportOptions{From: from, To: from.AddDate(1, 0, 2)},
+			setupMock:     func(repo *MockRepository, projects *MockProjectGetter) {},
+			expectedError: ErrReportRangeNotValid,
+		},
+		{
+			name:          "Timezone is not valid",
+			options:       ReportOptions{From: from, To: from.AddDate(0, 0, 7), Timezone: "Mars/Olympus"},
+			setupMock:     func(repo *MockRepository, projects *MockProjectGetter) {},
+			expectedError: ErrTimezoneNotValid,
+		},
+		{
+			name:    "User can't see the project",
+			options: ReportOptions{From: from, To: from.AddDate(0, 0, 7), ProjectId: &projectId},
+			setupMock: func(repo *MockRepository, projects *MockProjectGetter) {
+				projects.On("GetProject", projectId, int64(1)).Return(nil, project.ErrProjectNotFound)
+			},
+			expectedError: ErrProjectNotFound,
+		},
+		{
+			name:    "Rows are summed",
+			options: ReportOptions{From: from, To: from.AddDate(0, 0, 7), GroupBy: GroupByTodo},
+			setupMock: func(repo *MockRepository, projects *MockProjectGetter) {
+				repo.On("GetReport", int64(1), mock.Anything).Return([]ReportRow{
+					{Key: "3", Label: "write the report", Seconds: 3600},
+					{Key: "7", Label: "send the invoice", Seconds: 600},
+				}, nil)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockProjects := new(MockProjectGetter)
+			service := NewTimeTrackService(mockRepo, new(MockTodoGetter), mockProjects)
+			tc.setupMock(mockRepo, mockProjects)
+
+			report, err := service.GetReport(1, &tc.options)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				mockRepo.AssertNotCalled(t, "GetReport", mock.Anything, mock.Anything)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, int64(4200), report.Seconds)
+			assert.Equal(t, "UTC", tc.options.Timezone)
+		})
+	}
+}
@@ -1,13 +1,18 @@
 package todo
 
 import (
+	"errors"
 	"fmt"
 	"github.com/gorilla/mux"
 	"github.com/umtdemr/go-todo/server"
+	"github.com/umtdemr/go-todo/tag"
 	"github.com/umtdemr/go-todo/user"
+	"github.com/umtdemr/go-todo/workspace"
 	"net/http"
-	"os"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type APIRoute struct {
@@ -19,25 +24,278 @@ func NewTodoAPIRoute(service *Service) *APIRoute {
 	return &APIRoute{Route: "todo", Service: service}
 }
 
-// RegisterRoutes registers the routes for the todo API
-func (s *APIRoute) RegisterRoutes(router *mux.Router, userService user.Service) {
-	router.Handle("/todo", userService.AuthMiddleware(http.HandlerFunc(s.handleList)))
-	router.Handle("/todo/list", userService.AuthMiddleware(http.HandlerFunc(s.handleList)))
-	router.Handle("/todo/create", userService.AuthMiddleware(http.HandlerFunc(s.handleAdd)))
+// RegisterRoutes registers the routes for the todo API.
+// The routes that list and create todos work in the active workspace that is resolved by the workspace service
+func (s *APIRoute) RegisterRoutes(router *mux.Router, userService user.Service, workspaceService *workspace.Service) {
+	router.Handle("/todo", userService.AuthMiddleware(workspaceService.Middleware(http.HandlerFunc(s.handleList))))
+	router.Handle("/todo/list", userService.AuthMiddleware(workspaceService.Middleware(http.HandlerFunc(s.handleList))))
+	router.Handle("/todo/create", userService.AuthMiddleware(workspaceService.Middleware(http.HandlerFunc(s.handleAdd))))
 	router.Handle("/todo/update", userService.AuthMiddleware(http.HandlerFunc(s.handleUpdate)))
+	router.Handle("/todo/reorder", userService.AuthMiddleware(http.HandlerFunc(s.handleReorder)))
+	router.Handle("/todo/views", userService.AuthMiddleware(http.HandlerFunc(s.handleViewList)))
+	router.Handle("/todo/views/create", userService.AuthMiddleware(http.HandlerFunc(s.handleViewAdd)))
+	router.Handle("/todo/views/update", userService.AuthMiddleware(http.HandlerFunc(s.handleViewUpdate)))
+	router.Handle("/todo/views/{id}", userService.AuthMiddleware(
+		workspaceService.Middleware(http.HandlerFunc(s.handleViewFetchAndDelete)),
+	))
+	router.Handle("/templates", userService.AuthMiddleware(http.HandlerFunc(s.handleTemplateList)))
+	router.Handle("/templates/create", userService.AuthMiddleware(http.HandlerFunc(s.handleTemplateAdd)))
+	router.Handle("/templates/update", userService.AuthMiddleware(http.HandlerFunc(s.handleTemplateUpdate)))
+	router.Handle("/templates/{id}", userService.AuthMiddleware(http.HandlerFunc(s.handleTemplateFetchAndDelete)))
+	router.Handle("/templates/{id}/instantiate", userService.AuthMiddleware(
+		workspaceService.Middleware(http.HandlerFunc(s.handleTemplateInstantiate)),
+	))
+	router.Handle("/todo/search", userService.AuthMiddleware(workspaceService.Middleware(http.HandlerFunc(s.handleSearch))))
+	router.Handle("/todo/archive", userService.AuthMiddleware(http.HandlerFunc(s.handleArchiveCompleted)))
+	router.Handle("/todo/trash", userService.AuthMiddleware(http.HandlerFunc(s.handleTrash)))
+	router.Handle("/todo/{id}/restore", userService.AuthMiddleware(http.HandlerFunc(s.handleRestore)))
 	router.Handle("/todo/{id}", userService.AuthMiddleware(http.HandlerFunc(s.handleFetchAndDelete)))
+	router.Handle("/project/{id}/dependencies", userService.AuthMiddleware(http.HandlerFunc(s.handleDependencyGraph)))
+	router.Handle("/project/{id}/board", userService.AuthMiddleware(http.HandlerFunc(s.handleBoard)))
+	router.Handle("/project/{id}/burndown", userService.AuthMiddleware(http.HandlerFunc(s.handleBurndown)))
+}
+
+// respondWithTodoError responds with the fields that caused the error if the error is a TodoError
+func respondWithTodoError(w http.ResponseWriter, msg string, err error) {
+	var e TodoError
+	if errors.As(err, &e) {
+		server.RespondWithErrorFields(w, fmt.Sprintf("validation error: %v", e.Error()), http.StatusBadRequest, e.fields)
+		return
+	}
+
+	// tag names are validated by the tag package
+	var tagErr tag.TagError
+	if errors.As(err, &tagErr) {
+		server.RespondWithErrorFields(w, fmt.Sprintf("validation error: %v", tagErr.Error()), http.StatusBadRequest, []string{"tags"})
+		return
+	}
+	server.RespondWithError(w, fmt.Sprintf("%s: %s", msg, err), http.StatusBadRequest)
+}
+
+// parseRender reports whether the notes are requested as HTML with render=html
+func parseRender(query url.Values) (bool, error) {
+	if !query.Has("render") {
+		return false, nil
+	}
+	if query.Get("render") != "html" {
+		return false, ErrRenderNotValid
+	}
+	return true, nil
+}
+
+// parseListOptions parses the list filters from the query parameters
+func parseListOptions(query url.Values) (*ListOptions, error) {
+	options := &ListOptions{}
+
+	if overdue := query.Get("overdue"); overdue != "" {
+		isOverdue, err := strconv.ParseBool(overdue)
+		if err != nil {
+			return nil, ErrListOptionNotValid.With("overdue needs to be a boolean")
+		}
+		options.Overdue = isOverdue
+	}
+
+	if dueToday := query.Get("due_today"); dueToday != "" {
+		isDueToday, err := strconv.ParseBool(dueToday)
+		if err != nil {
+			return nil, ErrListOptionNotValid.With("due_today needs to be a boolean")
+		}
+		options.DueToday = isDueToday
+	}
+
+	if dueBefore := query.Get("due_before"); dueBefore != "" {
+		dueBeforeTime, err := time.Parse(time.RFC3339, dueBefore)
+		if err != nil {
+			return nil, ErrListOptionNotValid.With("due_before needs to be an RFC 3339 timestamp")
+		}
+		options.DueBefore = &dueBeforeTime
+	}
+
+	if timezone := query.Get("tz"); timezone != "" {
+		options.Timezone = &timezone
+	}
+
+	// project is either the id of a project or "inbox" for the todos without a project
+	if projectValue := query.Get("project"); projectValue == "inbox" {
+		options.Inbox = true
+	} else if projectValue != "" {
+		projectId, err := strconv.Atoi(projectValue)
+		if err != nil {
+			return nil, ErrListOptionNotValid.With("project needs to be either an id or inbox")
+		}
+		options.ProjectId = &projectId
+	}
+
+	// parent is either the id of a todo to list its subtasks or "none" for the top level todos
+	if parentValue := query.Get("parent"); parentValue == "none" {
+		options.TopLevel = true
+	} else if parentValue != "" {
+		parentId, err := strconv.Atoi(parentValue)
+		if err != nil {
+			return nil, ErrListOptionNotValid.With("parent needs to be either an id or none")
+		}
+		options.ParentId = &parentId
+	}
+
+	if seriesValue := query.Get("series"); seriesValue != "" {
+		seriesId, err := strconv.Atoi(seriesValue)
+		if err != nil {
+			return nil, ErrListOptionNotValid.With("series needs to be an id")
+		}
+		options.SeriesId = &seriesId
+	}
+
+	// only the todos assigned to the authenticated user can be listed
+	switch assignedTo := query.Get("assigned_to"); assignedTo {
+	case "":
+	case "me":
+		options.AssignedToMe = true
+	default:
+		return nil, ErrListOptionNotValid.With("assigned_to should be me")
+	}
+
+	if includeArchived := query.Get("include_archived"); includeArchived != "" {
+		isIncluded, err := strconv.ParseBool(includeArchived)
+		if err != nil {
+			return nil, ErrListOptionNotValid.With("include_archived needs to be a boolean")
+		}
+		options.IncludeArchived = isIncluded
+	}
+
+	if onlyArchived := query.Get("only_archived"); onlyArchived != "" {
+		isOnly, err := strconv.ParseBool(onlyArchived)
+		if err != nil {
+			return nil, ErrListOptionNotValid.With("only_archived needs to be a boolean")
+		}
+		options.OnlyArchived = isOnly
+	}
+
+	if options.IncludeArchived && options.OnlyArchived {
+		return nil, ErrListOptionNotValid.With("include_archived and only_archived can't be used together")
+	}
+
+	// tag can be sent multiple times. tag_match decides if the todos need to have any or all of them
+	options.Tags = query["tag"]
+	switch tagMatch := query.Get("tag_match"); tagMatch {
+	case "", "any":
+	case "all":
+		options.MatchAllTags = true
+	default:
+		return nil, ErrListOptionNotValid.With("tag_match should be either any or all")
+	}
+
+	if sort := query.Get("sort"); sort != "" {
+		sortOption, err := ParseSortOption(sort)
+		if err != nil {
+			return nil, err
+		}
+		options.Sort = sortOption
+	}
+
+	// filter is parsed in the requested timezone so that the dates mean the days of the user
+	if filterValue := query.Get("filter"); filterValue != "" {
+		loc := time.UTC
+		if options.Timezone != nil {
+			timezoneLoc, err := time.LoadLocation(*options.Timezone)
+			if err != nil {
+				return nil, ErrTimezoneNotValid.With(*options.Timezone)
+			}
+			loc = timezoneLoc
+		}
+
+		filter, err := ParseFilter(filterValue, loc)
+		if err != nil {
+			return nil, err
+		}
+		options.Filter = filter
+
+		// archived todos need to be listed for the filter to match them
+		if FilterUsesField(filter, "archived") && !options.OnlyArchived {
+			options.IncludeArchived = true
+		}
+	}
+
+	// limit is capped with the server side maximum instead of failing the request
+	options.Limit = DefaultListLimit
+	if limitValue := query.Get("limit"); limitValue != "" {
+		limit, err := strconv.Atoi(limitValue)
+		if err != nil || limit < 1 {
+			return nil, ErrListOptionNotValid.With("limit needs to be a positive number")
+		}
+		options.Limit = min(limit, MaxListLimit)
+	}
+
+	// the cursor needs to be parsed after the sort since it is only valid for the sort it was created with
+	if cursorValue := query.Get("cursor"); cursorValue != "" {
+		cursor, err := DecodeCursor(cursorValue, options.Sort)
+		if err != nil {
+			return nil, err
+		}
+		options.After = cursor
+	}
+
+	return options, nil
+}
+
+// nextPageLink returns the Link header value pointing to the next page of the request
+func nextPageLink(r *http.Request, nextCursor string) string {
+	query := r.URL.Query()
+	query.Set("cursor", nextCursor)
+	next := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+	return fmt.Sprintf(`<%s>; rel="next"`, next.String())
+}
+
+// scopeToWorkspace limits the listed todos to the active workspace, or to the personal space if there isn't any
+func scopeToWorkspace(r *http.Request, options *ListOptions) {
+	if ws := workspace.FromContext(r.Context()); ws != nil {
+		options.WorkspaceId = &ws.Id
+		return
+	}
+	options.Personal = true
 }
 
 // handleList handles the list request
 func (s *APIRoute) handleList(w http.ResponseWriter, r *http.Request) {
+	s.respondWithList(w, r, r.URL.Query())
+}
+
+// respondWithList responds with the page of the todos listed with the given query parameters.
+// The Link header is set if there is a next page
+func (s *APIRoute) respondWithList(w http.ResponseWriter, r *http.Request, query url.Values) {
+	options, parseErr := parseListOptions(query)
+	if parseErr != nil {
+		respondWithTodoError(w, "error while parsing the list options", parseErr)
+		return
+	}
+	scopeToWorkspace(r, options)
+
+	render, renderErr := parseRender(query)
+	if renderErr != nil {
+		respondWithTodoError(w, "error while parsing the list options", renderErr)
+		return
+	}
+
 	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
-	todos, err := s.Service.GetAllTodos(authenticatedUser.Id)
+	page, err := s.Service.ListTodos(authenticatedUser.Id, options)
 
 	if err != nil {
-		server.RespondWithError(w, fmt.Sprintf("error while getting list: %s", err), http.StatusBadRequest)
+		respondWithTodoError(w, "error while getting list", err)
 		return
 	}
-	server.RespondOK(w, todos)
+
+	if render {
+		for i := range page.Items {
+			if err := renderTodoNotes(&page.Items[i]); err != nil {
+				server.RespondWithError(w, "error while rendering the notes", http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+
+	if page.NextCursor != nil {
+		w.Header().Set("Link", nextPageLink(r, *page.NextCursor))
+	}
+	server.RespondOK(w, page)
 }
 
 // handleAdd handles the add request
@@ -62,9 +320,35 @@ func (s *APIRoute) handleAdd(w http.ResponseWriter, r *http.Request) {
 	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
 
 	// create the todo
+	// todos created in a workspace belong to it
+	if ws := workspace.FromContext(r.Context()); ws != nil {
+		createTodoType.WorkspaceId = &ws.Id
+	}
+
+	// in the quick mode the due date, the recurrence, the tags, the priority and the project are parsed from the title
+	if value := r.URL.Query().Get("quick"); value != "" {
+		isQuick, err := strconv.ParseBool(value)
+		if err != nil {
+			err := server.ErrInvalidRequest.With("quick needs to be a boolean")
+			server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if isQuick {
+			result, quickErr := s.Service.QuickAddTodo(&createTodoType, authenticatedUser.Id)
+			if quickErr != nil {
+				respondWithTodoError(w, "error while generating the todo", quickErr)
+				return
+			}
+			server.RespondCreated(w, result)
+			return
+		}
+	}
+
 	createdTodo, createErr := s.Service.CreateTodo(&createTodoType, authenticatedUser.Id)
 	if createErr != nil {
-		fmt.Fprintf(os.Stderr, "error while generating the todo: %s\n", createErr)
+		respondWithTodoError(w, "error while generating the todo", createErr)
+		return
 	}
 
 	server.RespondCreated(w, createdTodo)
@@ -100,13 +384,43 @@ func (s *APIRoute) handleUpdate(w http.ResponseWriter, r *http.Request) {
 	// update the todo
 	updatedTodo, updateErr := s.Service.UpdateTodo(&updateData, authenticatedUser.Id)
 	if updateErr != nil {
-		server.RespondWithError(w, fmt.Sprintf("Error while updating: %s", updateErr), http.StatusBadRequest)
+		respondWithTodoError(w, "Error while updating", updateErr)
 		return
 	}
 
 	server.RespondOK(w, updatedTodo)
 }
 
+// handleReorder handles the reorder request
+func (s *APIRoute) handleReorder(w http.ResponseWriter, r *http.Request) {
+	// only POST methods are allowed
+	if r.Method != http.MethodPost {
+		err := server.ErrNotValidMethod.With("only POST methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var reorderData ReorderTodoData
+
+	err := server.DecodeBody(r, &reorderData)
+
+	if err != nil {
+		server.RespondWithError(w, fmt.Sprintf("error while parsing: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// get the authenticated user from the context
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+
+	reorderedTodo, reorderErr := s.Service.ReorderTodo(&reorderData, authenticatedUser.Id)
+	if reorderErr != nil {
+		respondWithTodoError(w, "Error while reordering", reorderErr)
+		return
+	}
+
+	server.RespondOK(w, reorderedTodo)
+}
+
 // handleFetchAndDelete handles the fetch and delete requests
 func (s *APIRoute) handleFetchAndDelete(w http.ResponseWriter, r *http.Request) {
 	// only GET and DELETE methods are allowed
@@ -139,9 +453,20 @@ func (s *APIRoute) handleFetchAndDelete(w http.ResponseWriter, r *http.Request)
 	// get the authenticated user from the context
 	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
 
-	// if the method is DELETE, remove the todo
+	// if the method is DELETE, move the todo to the trash or delete it permanently
 	if r.Method == http.MethodDelete {
-		removedTodo, removeErr := s.Service.RemoveTodo(todoIdInt, authenticatedUser.Id)
+		permanent := false
+		if value := r.URL.Query().Get("permanent"); value != "" {
+			isPermanent, err := strconv.ParseBool(value)
+			if err != nil {
+				err := server.ErrInvalidRequest.With("permanent needs to be a boolean")
+				server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			permanent = isPermanent
+		}
+
+		removedTodo, removeErr := s.Service.RemoveTodo(todoIdInt, authenticatedUser.Id, permanent)
 		if removeErr != nil {
 			server.RespondWithError(w, removeErr.Error(), http.StatusBadRequest)
 			return
@@ -151,12 +476,496 @@ func (s *APIRoute) handleFetchAndDelete(w http.ResponseWriter, r *http.Request)
 		}
 	} else {
 		// if the method is GET, fetch the todo
+		render, renderErr := parseRender(r.URL.Query())
+		if renderErr != nil {
+			respondWithTodoError(w, "error while fetching the todo", renderErr)
+			return
+		}
+
 		fetchedTodo, err := s.Service.GetTodo(todoIdInt, authenticatedUser.Id)
 		if err != nil {
 			server.RespondWithError(w, err.Error(), http.StatusBadRequest)
 			return
 		}
+
+		if render {
+			if err := renderTodoNotes(fetchedTodo); err != nil {
+				server.RespondWithError(w, "error while rendering the notes", http.StatusInternalServerError)
+				return
+			}
+		}
 		server.RespondOK(w, fetchedTodo)
 		return
 	}
 }
+
+// handleTrash handles the request for listing the todos in the trash
+func (s *APIRoute) handleTrash(w http.ResponseWriter, r *http.Request) {
+	// only GET methods are allowed
+	if r.Method != http.MethodGet {
+		err := server.ErrNotValidMethod.With("only GET methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+	todos, err := s.Service.GetTrash(authenticatedUser.Id)
+	if err != nil {
+		respondWithTodoError(w, "error while getting the trash", err)
+		return
+	}
+	server.RespondOK(w, todos)
+}
+
+// handleRestore handles the request for taking a todo out of the trash
+func (s *APIRoute) handleRestore(w http.ResponseWriter, r *http.Request) {
+	// only POST methods are allowed
+	if r.Method != http.MethodPost {
+		err := server.ErrNotValidMethod.With("only POST methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// parse the ID from the path variables
+	todoId, parseErr := strconv.Atoi(mux.Vars(r)["id"])
+	if parseErr != nil {
+		err := server.ErrInvalidRequest.With("need a numeric value for the id")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+	restoredTodo, err := s.Service.RestoreTodo(todoId, authenticatedUser.Id)
+	if err != nil {
+		respondWithTodoError(w, "error while restoring", err)
+		return
+	}
+	server.RespondOK(w, restoredTodo)
+}
+
+// handleDependencyGraph returns the todos of a project in the order they can be done, with their dependencies
+func (s *APIRoute) handleDependencyGraph(w http.ResponseWriter, r *http.Request) {
+	// only GET methods are allowed
+	if r.Method != http.MethodGet {
+		err := server.ErrNotValidMethod.With("only GET methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	projectId, parseErr := strconv.Atoi(mux.Vars(r)["id"])
+	if parseErr != nil {
+		err := server.ErrInvalidRequest.With("need a numeric value for the id")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+	graph, err := s.Service.GetDependencyGraph(projectId, authenticatedUser.Id)
+	if err != nil {
+		respondWithTodoError(w, "error while getting the dependencies", err)
+		return
+	}
+	server.RespondOK(w, graph)
+}
+
+// handleBoard returns the todos of a project grouped by their statuses
+func (s *APIRoute) handleBoard(w http.ResponseWriter, r *http.Request) {
+	// only GET methods are allowed
+	if r.Method != http.MethodGet {
+		err := server.ErrNotValidMethod.With("only GET methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	projectId, parseErr := strconv.Atoi(mux.Vars(r)["id"])
+	if parseErr != nil {
+		err := server.ErrInvalidRequest.With("need a numeric value for the id")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+	board, err := s.Service.GetBoard(projectId, authenticatedUser.Id)
+	if err != nil {
+		respondWithTodoError(w, "error while getting the board", err)
+		return
+	}
+	server.RespondOK(w, board)
+}
+
+// parseBurndownOptions parses the days of the burndown. from and to are days in the tz timezone
+func parseBurndownOptions(query url.Values) (*BurndownOptions, error) {
+	options := &BurndownOptions{Timezone: query.Get("tz")}
+
+	loc := time.UTC
+	if options.Timezone != "" {
+		timezoneLoc, err := time.LoadLocation(options.Timezone)
+		if err != nil {
+			return nil, ErrTimezoneNotValid.With(options.Timezone)
+		}
+		loc = timezoneLoc
+	}
+
+	from, fromErr := time.ParseInLocation(time.DateOnly, query.Get("from"), loc)
+	to, toErr := time.ParseInLocation(time.DateOnly, query.Get("to"), loc)
+	if fromErr != nil || toErr != nil {
+		return nil, ErrBurndownRangeNotValid
+	}
+	options.From = from
+	options.To = to
+	return options, nil
+}
+
+// handleBurndown returns the remaining estimate of a project for each day in the requested range
+func (s *APIRoute) handleBurndown(w http.ResponseWriter, r *http.Request) {
+	// only GET methods are allowed
+	if r.Method != http.MethodGet {
+		err := server.ErrNotValidMethod.With("only GET methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	projectId, parseErr := strconv.Atoi(mux.Vars(r)["id"])
+	if parseErr != nil {
+		err := server.ErrInvalidRequest.With("need a numeric value for the id")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	options, err := parseBurndownOptions(r.URL.Query())
+	if err != nil {
+		respondWithTodoError(w, "error while parsing the burndown options", err)
+		return
+	}
+
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+	burndown, err := s.Service.GetBurndown(projectId, authenticatedUser.Id, options)
+	if err != nil {
+		respondWithTodoError(w, "error while getting the burndown", err)
+		return
+	}
+	server.RespondOK(w, burndown)
+}
+
+// handleArchiveCompleted handles the request for archiving all the done todos
+func (s *APIRoute) handleArchiveCompleted(w http.ResponseWriter, r *http.Request) {
+	// only POST methods are allowed
+	if r.Method != http.MethodPost {
+		err := server.ErrNotValidMethod.With("only POST methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+	archivedCount, err := s.Service.ArchiveCompleted(authenticatedUser.Id)
+	if err != nil {
+		respondWithTodoError(w, "error while archiving", err)
+		return
+	}
+	server.RespondOK(w, map[string]int64{"archived": archivedCount})
+}
+
+// handleSearch handles the search request. The list filters can be used to narrow down the results
+func (s *APIRoute) handleSearch(w http.ResponseWriter, r *http.Request) {
+	// only GET methods are allowed
+	if r.Method != http.MethodGet {
+		err := server.ErrNotValidMethod.With("only GET methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	options, parseErr := parseListOptions(query)
+	if parseErr != nil {
+		respondWithTodoError(w, "error while parsing the list options", parseErr)
+		return
+	}
+	scopeToWorkspace(r, options)
+
+	// search results are ordered by their rank
+	if options.Sort != nil || options.After != nil {
+		err := ErrListOptionNotValid.With("sort and cursor can't be used with search")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	render, renderErr := parseRender(query)
+	if renderErr != nil {
+		respondWithTodoError(w, "error while parsing the list options", renderErr)
+		return
+	}
+
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+	results, err := s.Service.SearchTodos(authenticatedUser.Id, query.Get("q"), options)
+	if err != nil {
+		respondWithTodoError(w, "error while searching", err)
+		return
+	}
+
+	if render {
+		for i := range results {
+			if err := renderTodoNotes(&results[i].Todo); err != nil {
+				server.RespondWithError(w, "error while rendering the notes", http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+	server.RespondOK(w, results)
+}
+
+// handleViewList handles the request for listing the saved views
+func (s *APIRoute) handleViewList(w http.ResponseWriter, r *http.Request) {
+	// only GET methods are allowed
+	if r.Method != http.MethodGet {
+		err := server.ErrNotValidMethod.With("only GET methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+	views, err := s.Service.GetAllViews(authenticatedUser.Id)
+	if err != nil {
+		respondWithTodoError(w, "error while getting views", err)
+		return
+	}
+	server.RespondOK(w, views)
+}
+
+// handleViewAdd handles the request for saving a view
+func (s *APIRoute) handleViewAdd(w http.ResponseWriter, r *http.Request) {
+	// only POST methods are allowed
+	if r.Method != http.MethodPost {
+		err := server.ErrNotValidMethod.With("only POST methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var createViewData CreateViewData
+	if err := server.DecodeBody(r, &createViewData); err != nil {
+		server.RespondWithError(w, fmt.Sprintf("parsing error: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+	createdView, err := s.Service.CreateView(&createViewData, authenticatedUser.Id)
+	if err != nil {
+		respondWithTodoError(w, "error while creating the view", err)
+		return
+	}
+	server.RespondCreated(w, createdView)
+}
+
+// handleViewUpdate handles the request for renaming a view or changing its criteria
+func (s *APIRoute) handleViewUpdate(w http.ResponseWriter, r *http.Request) {
+	// only POST methods are allowed
+	if r.Method != http.MethodPost {
+		err := server.ErrNotValidMethod.With("only POST methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var updateViewData UpdateViewData
+	if err := server.DecodeBody(r, &updateViewData); err != nil {
+		server.RespondWithError(w, fmt.Sprintf("error while parsing: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+	updatedView, err := s.Service.UpdateView(&updateViewData, authenticatedUser.Id)
+	if err != nil {
+		respondWithTodoError(w, "error while updating the view", err)
+		return
+	}
+	server.RespondOK(w, updatedView)
+}
+
+// handleViewFetchAndDelete lists the todos of a view or deletes the view.
+// The todos are listed like the list request with the criteria of the view, so pagination and filters work the same
+func (s *APIRoute) handleViewFetchAndDelete(w http.ResponseWriter, r *http.Request) {
+	// only GET and DELETE methods are allowed
+	if r.Method != http.MethodGet && r.Method != http.MethodDelete {
+		err := server.ErrNotValidMethod.With("only GET and DELETE methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// parse the ID from the path variables
+	viewId, parseErr := strconv.Atoi(mux.Vars(r)["id"])
+	if parseErr != nil {
+		err := server.ErrInvalidRequest.With("need a numeric value for the id")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+
+	if r.Method == http.MethodDelete {
+		removedView, err := s.Service.RemoveView(viewId, authenticatedUser.Id)
+		if err != nil {
+			respondWithTodoError(w, "error while deleting the view", err)
+			return
+		}
+		server.RespondNoContent(w, removedView)
+		return
+	}
+
+	view, err := s.Service.GetView(viewId, authenticatedUser.Id)
+	if err != nil {
+		respondWithTodoError(w, "error while fetching the view", err)
+		return
+	}
+
+	// the criteria of the view are added to the filter of the request and its sort replaces the requested one
+	query := r.URL.Query()
+	filter := view.Criteria.FilterExpression()
+	if requestFilter := query.Get("filter"); requestFilter != "" {
+		filter = strings.TrimSpace(filter + " (" + requestFilter + ")")
+	}
+	if filter != "" {
+		query.Set("filter", filter)
+	}
+	if view.Criteria.Sort != nil {
+		query.Set("sort", *view.Criteria.Sort)
+	}
+
+	s.respondWithList(w, r, query)
+}
+
+// handleTemplateList handles the request for listing the templates
+func (s *APIRoute) handleTemplateList(w http.ResponseWriter, r *http.Request) {
+	// only GET methods are allowed
+	if r.Method != http.MethodGet {
+		err := server.ErrNotValidMethod.With("only GET methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+	templates, err := s.Service.GetAllTemplates(authenticatedUser.Id)
+	if err != nil {
+		respondWithTodoError(w, "error while getting templates", err)
+		return
+	}
+	server.RespondOK(w, templates)
+}
+
+// handleTemplateAdd handles the request for saving a template
+func (s *APIRoute) handleTemplateAdd(w http.ResponseWriter, r *http.Request) {
+	// only POST methods are allowed
+	if r.Method != http.MethodPost {
+		err := server.ErrNotValidMethod.With("only POST methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var createTemplateData CreateTemplateData
+	if err := server.DecodeBody(r, &createTemplateData); err != nil {
+		server.RespondWithError(w, fmt.Sprintf("parsing error: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+	createdTemplate, err := s.Service.CreateTemplate(&createTemplateData, authenticatedUser.Id)
+	if err != nil {
+		respondWithTodoError(w, "error while creating the template", err)
+		return
+	}
+	server.RespondCreated(w, createdTemplate)
+}
+
+// handleTemplateUpdate handles the request for renaming a template or replacing its items
+func (s *APIRoute) handleTemplateUpdate(w http.ResponseWriter, r *http.Request) {
+	// only POST methods are allowed
+	if r.Method != http.MethodPost {
+		err := server.ErrNotValidMethod.With("only POST methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var updateTemplateData UpdateTemplateData
+	if err := server.DecodeBody(r, &updateTemplateData); err != nil {
+		server.RespondWithError(w, fmt.Sprintf("error while parsing: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+	updatedTemplate, err := s.Service.UpdateTemplate(&updateTemplateData, authenticatedUser.Id)
+	if err != nil {
+		respondWithTodoError(w, "error while updating the template", err)
+		return
+	}
+	server.RespondOK(w, updatedTemplate)
+}
+
+// handleTemplateFetchAndDelete fetches or deletes a template
+func (s *APIRoute) handleTemplateFetchAndDelete(w http.ResponseWriter, r *http.Request) {
+	// only GET and DELETE methods are allowed
+	if r.Method != http.MethodGet && r.Method != http.MethodDelete {
+		err := server.ErrNotValidMethod.With("only GET and DELETE methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// parse the ID from the path variables
+	templateId, parseErr := strconv.Atoi(mux.Vars(r)["id"])
+	if parseErr != nil {
+		err := server.ErrInvalidRequest.With("need a numeric value for the id")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+
+	if r.Method == http.MethodDelete {
+		removedTemplate, err := s.Service.RemoveTemplate(templateId, authenticatedUser.Id)
+		if err != nil {
+			respondWithTodoError(w, "error while deleting the template", err)
+			return
+		}
+		server.RespondNoContent(w, removedTemplate)
+		return
+	}
+
+	template, err := s.Service.GetTemplate(templateId, authenticatedUser.Id)
+	if err != nil {
+		respondWithTodoError(w, "error while fetching the template", err)
+		return
+	}
+	server.RespondOK(w, template)
+}
+
+// handleTemplateInstantiate handles the request for creating the todos of a template.
+// The todos are created in the active workspace unless they are put in a project
+func (s *APIRoute) handleTemplateInstantiate(w http.ResponseWriter, r *http.Request) {
+	// only POST methods are allowed
+	if r.Method != http.MethodPost {
+		err := server.ErrNotValidMethod.With("only POST methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// parse the ID from the path variables
+	templateId, parseErr := strconv.Atoi(mux.Vars(r)["id"])
+	if parseErr != nil {
+		err := server.ErrInvalidRequest.With("need a numeric value for the id")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var instantiateData InstantiateTemplateData
+	if err := server.DecodeBody(r, &instantiateData); err != nil {
+		server.RespondWithError(w, fmt.Sprintf("parsing error: %v", err), http.StatusBadRequest)
+		return
+	}
+	if ws := workspace.FromContext(r.Context()); ws != nil {
+		instantiateData.WorkspaceId = &ws.Id
+	}
+
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+	createdTodos, err := s.Service.InstantiateTemplate(templateId, &instantiateData, authenticatedUser.Id)
+	if err != nil {
+		respondWithTodoError(w, "error while creating the todos of the template", err)
+		return
+	}
+	server.RespondCreated(w, createdTodos)
+}
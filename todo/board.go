@@ -0,0 +1,42 @@
+package todo
+
+import "github.com/umtdemr/go-todo/project"
+
+// BoardColumn is the todos in a status of the workflow of a project
+type BoardColumn struct {
+	Status   string `json:"status"`
+	WipLimit int    `json:"wipLimit"`
+	Count    int    `json:"count"`
+	// OverLimit is true when the column has more todos than its WIP limit
+	OverLimit bool   `json:"overLimit"`
+	Todos     []Todo `json:"todos"`
+}
+
+// Board is the todos of a project grouped by their statuses, in the order of the workflow
+type Board struct {
+	ProjectId int           `json:"projectId"`
+	Columns   []BoardColumn `json:"columns"`
+}
+
+// buildBoard groups the todos by their statuses. Todos in a status that the workflow doesn't have are put in the first
+// column, since they would be moved there when the workflow is changed
+func buildBoard(projectId int, workflow *project.Workflow, todos []Todo) *Board {
+	board := &Board{ProjectId: projectId, Columns: make([]BoardColumn, len(workflow.Statuses))}
+	indexes := make(map[string]int, len(workflow.Statuses))
+	for i, status := range workflow.Statuses {
+		board.Columns[i] = BoardColumn{Status: status.Name, WipLimit: status.WipLimit, Todos: []Todo{}}
+		indexes[status.Name] = i
+	}
+
+	for _, t := range todos {
+		column := &board.Columns[indexes[t.Status]]
+		column.Todos = append(column.Todos, t)
+	}
+
+	for i := range board.Columns {
+		column := &board.Columns[i]
+		column.Count = len(column.Todos)
+		column.OverLimit = column.WipLimit > 0 && column.Count > column.WipLimit
+	}
+	return board
+}
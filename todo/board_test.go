@@ -0,0 +1,32 @@
+package todo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/umtdemr/go-todo/project"
+	"testing"
+)
+
+func TestBuildBoard(t *testing.T) {
+	workflow := &project.Workflow{Statuses: []project.Status{
+		{Name: "backlog"},
+		{Name: "doing", WipLimit: 1},
+		{Name: "done"},
+	}}
+	todos := []Todo{
+		{Id: 1, Status: "doing"},
+		{Id: 2, Status: "removed"},
+		{Id: 3, Status: "doing"},
+		{Id: 4, Status: "backlog"},
+	}
+
+	board := buildBoard(5, workflow, todos)
+	assert.Equal(t, 5, board.ProjectId)
+	assert.Len(t, board.Columns, 3)
+
+	// todos in the statuses that are not in the workflow are shown in the first column
+	assert.Equal(t, []Todo{{Id: 2, Status: "removed"}, {Id: 4, Status: "backlog"}}, board.Columns[0].Todos)
+	assert.Equal(t, 2, board.Columns[1].Count)
+	assert.True(t, board.Columns[1].OverLimit)
+	assert.Equal(t, 0, board.Columns[2].Count)
+	assert.Equal(t, []Todo{}, board.Columns[2].Todos)
+}
@@ -0,0 +1,126 @@
+package todo
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"github.com/umtdemr/go-todo/project"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultListLimit is the page size when the limit is not sent
+	DefaultListLimit = 50
+	// MaxListLimit is the largest page size that can be requested
+	MaxListLimit = 200
+)
+
+// Cursor points to the last todo of a page. It is sent to the clients as an opaque string
+type Cursor struct {
+	// Sort is the sort option the cursor was created with, like "-priority"
+	Sort string `json:"s"`
+	// Value is the sort key of the todo. It is nil if the todo doesn't have a value for the sort field
+	Value *string `json:"v,omitempty"`
+	Id    int     `json:"i"`
+}
+
+// TodoPage is a page of the todo list. NextCursor is nil on the last page
+type TodoPage struct {
+	Items      []Todo  `json:"items"`
+	NextCursor *string `json:"next_cursor"`
+	// Estimates are the sums of the estimates of all the todos in the list for each estimate unit
+	Estimates []project.EstimateSum `json:"estimates"`
+}
+
+// String returns the sort option in the form it is parsed from
+func (o *SortOption) String() string {
+	if o == nil {
+		return "position"
+	}
+	if o.Desc {
+		return "-" + o.Field
+	}
+	return o.Field
+}
+
+// newCursor creates the cursor pointing to the todo in the list sorted with the given option
+func newCursor(t *Todo, sort *SortOption) *Cursor {
+	cursor := &Cursor{Sort: sort.String(), Id: t.Id}
+
+	var value string
+	switch sortField(sort) {
+	case "priority":
+		value = strconv.Itoa(int(t.Priority))
+	case "position":
+		value = strconv.FormatFloat(t.Position, 'g', -1, 64)
+	case "created_at":
+		value = t.CreatedAt.Format(time.RFC3339Nano)
+	case "updated_at":
+		value = t.UpdatedAt.Format(time.RFC3339Nano)
+	case "due_date":
+		if t.DueDate == nil {
+			return cursor
+		}
+		value = t.DueDate.Format(time.RFC3339Nano)
+	}
+	cursor.Value = &value
+	return cursor
+}
+
+// Encode returns the opaque form of the cursor
+func (c *Cursor) Encode() string {
+	encoded, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+// DecodeCursor parses a cursor encoded with Encode and checks that it was created for the given sort option
+func DecodeCursor(value string, sort *SortOption) (*Cursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrCursorNotValid
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(decoded, &cursor); err != nil {
+		return nil, ErrCursorNotValid
+	}
+
+	if cursor.Sort != sort.String() {
+		return nil, ErrCursorNotValid.With("the sort order has changed")
+	}
+
+	// the value is parsed here so that the repository doesn't get a malformed value
+	if _, err := cursor.sortValue(); err != nil {
+		return nil, ErrCursorNotValid
+	}
+	return &cursor, nil
+}
+
+// sortValue returns the value of the cursor with the type of the sort column
+func (c *Cursor) sortValue() (any, error) {
+	if c.Value == nil {
+		return nil, nil
+	}
+
+	option, err := ParseSortOption(c.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	switch option.Field {
+	case "priority":
+		return strconv.Atoi(*c.Value)
+	case "position":
+		return strconv.ParseFloat(*c.Value, 64)
+	default:
+		return time.Parse(time.RFC3339Nano, *c.Value)
+	}
+}
+
+// sortField returns the field the list is sorted by
+func sortField(sort *SortOption) string {
+	if sort == nil {
+		return "position"
+	}
+	return sort.Field
+}
@@ -0,0 +1,72 @@
+package todo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestCursor(t *testing.T) {
+	createdAt := time.Date(2026, time.March, 4, 10, 30, 0, 123456000, time.UTC)
+	dueDate := time.Date(2026, time.March, 10, 9, 0, 0, 0, time.UTC)
+	todo := &Todo{Id: 7, Priority: PriorityHigh, Position: 1536.5, CreatedAt: createdAt, DueDate: &dueDate}
+
+	tests := []struct {
+		name          string
+		sort          string
+		todo          *Todo
+		expectedValue any
+	}{
+		{name: "Default order", sort: "", todo: todo, expectedValue: 1536.5},
+		{name: "Priority descending", sort: "-priority", todo: todo, expectedValue: int(PriorityHigh)},
+		{name: "Created at", sort: "created_at", todo: todo, expectedValue: createdAt},
+		{name: "Due date", sort: "due_date", todo: todo, expectedValue: dueDate},
+		{name: "Todo without due date", sort: "-due_date", todo: &Todo{Id: 7}, expectedValue: nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var sort *SortOption
+			if tc.sort != "" {
+				sort, _ = ParseSortOption(tc.sort)
+			}
+
+			encoded := newCursor(tc.todo, sort).Encode()
+			cursor, err := DecodeCursor(encoded, sort)
+			assert.Nil(t, err)
+			assert.Equal(t, 7, cursor.Id)
+
+			value, err := cursor.sortValue()
+			assert.Nil(t, err)
+			if expectedTime, ok := tc.expectedValue.(time.Time); ok {
+				assert.True(t, expectedTime.Equal(value.(time.Time)))
+			} else {
+				assert.Equal(t, tc.expectedValue, value)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorNotValid(t *testing.T) {
+	prioritySort, _ := ParseSortOption("priority")
+	encoded := newCursor(&Todo{Id: 1, Priority: PriorityLow}, prioritySort).Encode()
+	badValue := (&Cursor{Sort: "priority", Value: new(string), Id: 1}).Encode()
+
+	tests := []struct {
+		name  string
+		value string
+		sort  *SortOption
+	}{
+		{name: "Not base64", value: "not a cursor!", sort: prioritySort},
+		{name: "Not json", value: "bm90IGpzb24", sort: prioritySort},
+		{name: "Sort order has changed", value: encoded, sort: nil},
+		{name: "Value doesn't match the sort field", value: badValue, sort: prioritySort},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := DecodeCursor(tc.value, tc.sort)
+			assert.ErrorIs(t, err, ErrCursorNotValid)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package todo
+
+import "slices"
+
+// Dependency means that the todo can't be done before the todo blocking it is done
+type Dependency struct {
+	TodoId      int `json:"todoId"`
+	BlockedById int `json:"blockedById"`
+}
+
+// DependencyGraph is the todos of a project in topological order, so that every todo comes after
+// the todos blocking it. Dependencies only has the dependencies between the todos of the project
+type DependencyGraph struct {
+	Todos        []Todo       `json:"todos"`
+	Dependencies []Dependency `json:"dependencies"`
+}
+
+// sortByDependencies orders the todos so that the blocking todos come first. Todos that don't depend on each other
+// keep their order. The todos of a cycle, which can't be created through the service, are added at the end
+func sortByDependencies(todos []Todo, dependencies []Dependency) []Todo {
+	indexes := make(map[int]int, len(todos))
+	for i, t := range todos {
+		indexes[t.Id] = i
+	}
+
+	blockerCounts := make([]int, len(todos))
+	blocking := make(map[int][]int, len(todos))
+	for _, d := range dependencies {
+		todoIndex, ok := indexes[d.TodoId]
+		blockerIndex, blockerOk := indexes[d.BlockedById]
+		if !ok || !blockerOk {
+			continue
+		}
+		blockerCounts[todoIndex]++
+		blocking[blockerIndex] = append(blocking[blockerIndex], todoIndex)
+	}
+
+	// ready holds the indexes of the todos without any unsorted blocker, in their original order
+	var ready []int
+	for i, count := range blockerCounts {
+		if count == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	sorted := make([]Todo, 0, len(todos))
+	added := make([]bool, len(todos))
+	for len(ready) > 0 {
+		current := ready[0]
+		ready = ready[1:]
+		sorted = append(sorted, todos[current])
+		added[current] = true
+
+		for _, blocked := range blocking[current] {
+			blockerCounts[blocked]--
+			if blockerCounts[blocked] == 0 {
+				position, _ := slices.BinarySearch(ready, blocked)
+				ready = slices.Insert(ready, position, blocked)
+			}
+		}
+	}
+
+	for i, t := range todos {
+		if !added[i] {
+			sorted = append(sorted, t)
+		}
+	}
+	return sorted
+}
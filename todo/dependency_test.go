@@ -0,0 +1,51 @@
+package todo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSortByDependencies(t *testing.T) {
+	todos := []Todo{{Id: 1}, {Id: 2}, {Id: 3}, {Id: 4}, {Id: 5}}
+
+	tests := []struct {
+		name         string
+		dependencies []Dependency
+		expectedIds  []int
+	}{
+		{
+			name:        "Order is kept without dependencies",
+			expectedIds: []int{1, 2, 3, 4, 5},
+		},
+		{
+			name:         "Blocking todos come first",
+			dependencies: []Dependency{{TodoId: 1, BlockedById: 4}, {TodoId: 4, BlockedById: 5}},
+			expectedIds:  []int{2, 3, 5, 4, 1},
+		},
+		{
+			name:         "Todo waits for all of its blockers",
+			dependencies: []Dependency{{TodoId: 2, BlockedById: 1}, {TodoId: 2, BlockedById: 5}},
+			expectedIds:  []int{1, 3, 4, 5, 2},
+		},
+		{
+			name:         "Blockers outside the project are ignored",
+			dependencies: []Dependency{{TodoId: 1, BlockedById: 9}},
+			expectedIds:  []int{1, 2, 3, 4, 5},
+		},
+		{
+			name:         "Todos of a cycle are added at the end",
+			dependencies: []Dependency{{TodoId: 1, BlockedById: 2}, {TodoId: 2, BlockedById: 1}},
+			expectedIds:  []int{3, 4, 5, 1, 2},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var ids []int
+			for _, sorted := range sortByDependencies(todos, tc.dependencies) {
+				ids = append(ids, sorted.Id)
+			}
+			assert.Equal(t, tc.expectedIds, ids)
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package todo
+
+type errKind int
+
+const (
+	_ errKind = iota
+	titleEmpty
+	idRequired
+	noFieldToUpdate
+	timezoneNotValid
+	reminderOffsetNotValid
+	clearFieldNotValid
+	listOptionNotValid
+	priorityNotValid
+	sortNotValid
+	reorderTargetNotValid
+	projectNotValid
+	parentNotValid
+	parentCycle
+	recurrenceNotValid
+	recurrenceNeedsDueDate
+	scopeNotValid
+	archiveNotDone
+	cursorNotValid
+	searchQueryEmpty
+	searchNotSupported
+	filterNotValid
+	viewNameLength
+	viewNameTaken
+	viewCriteriaNotValid
+	notesTooLong
+	renderNotValid
+	readOnly
+	assigneeNotValid
+	dependencyNotValid
+	dependencyCycle
+	todoBlocked
+	statusNotValid
+	transitionNotAllowed
+	estimateNotValid
+	burndownRangeNotValid
+	templateNameLength
+	templateNameTaken
+	templateItemsNotValid
+	startDateRequired
+)
+
+type TodoError struct {
+	kind   errKind
+	info   string
+	fields []string
+}
+
+type Fields []string
+
+// With returns a copy of the error with additional information appended to its message
+func (e TodoError) With(info string) TodoError {
+	err := e
+	err.info = info
+	return err
+}
+
+func (e TodoError) message() string {
+	switch e.kind {
+	case titleEmpty:
+		return "title need to be sent"
+	case idRequired:
+		return "id is required"
+	case noFieldToUpdate:
+		return "no field is provided"
+	case timezoneNotValid:
+		return "timezone is not valid"
+	case reminderOffsetNotValid:
+		return "reminder offset should be a positive number of minutes"
+	case clearFieldNotValid:
+		return "field can not be cleared"
+	case listOptionNotValid:
+		return "list option is not valid"
+	case priorityNotValid:
+		return "priority should be one of none, low, medium, high and urgent"
+	case sortNotValid:
+		return "sort should be one of priority, position, created_at, updated_at and due_date"
+	case parentNotValid:
+		return "parent todo doesn't exist"
+	case parentCycle:
+		return "a todo can't be a subtask of itself or of its own subtasks"
+	case recurrenceNotValid:
+		return "recurrence is not valid"
+	case recurrenceNeedsDueDate:
+		return "recurring todos need a due date"
+	case scopeNotValid:
+		return "scope should be either this or series"
+	case archiveNotDone:
+		return "only done todos can be archived"
+	case cursorNotValid:
+		return "cursor is not valid"
+	case searchQueryEmpty:
+		return "search query needs to have at least one word"
+	case searchNotSupported:
+		return "search is not supported"
+	case filterNotValid:
+		return "filter is not valid"
+	case viewNameLength:
+		return "name of the view should be between 1 and 100 characters"
+	case viewNameTaken:
+		return "there is already a view with the name"
+	case viewCriteriaNotValid:
+		return "criteria of the view is not valid"
+	case notesTooLong:
+		return "notes can be at most 20000 characters"
+	case renderNotValid:
+		return "render should be html"
+	case readOnly:
+		return "todo is shared with you as a viewer"
+	case assigneeNotValid:
+		return "assignee doesn't exist or can't see the todo"
+	case dependencyNotValid:
+		return "blocking todo doesn't exist"
+	case dependencyCycle:
+		return "a todo can't be blocked by itself or by the todos that it blocks"
+	case todoBlocked:
+		return "todo is blocked by todos that are not done yet"
+	case statusNotValid:
+		return "status is not in the workflow of the project"
+	case transitionNotAllowed:
+		return "workflow of the project doesn't allow moving the todo to the status"
+	case estimateNotValid:
+		return "estimate should be between 0 and 100000"
+	case burndownRangeNotValid:
+		return "from and to need to be days, and the range can be at most 366 days"
+	case templateNameLength:
+		return "name of the template should be between 1 and 100 characters"
+	case templateNameTaken:
+		return "there is already a template with the name"
+	case templateItemsNotValid:
+		return "items of the template are not valid"
+	case startDateRequired:
+		return "start date needs to be sent"
+	case projectNotValid:
+		return "project doesn't exist or is archived"
+	case reorderTargetNotValid:
+		return "either beforeId or afterId need to be sent and it should be a different todo"
+	}
+	return "error in todo"
+}
+
+func (e TodoError) Error() string {
+	if e.info != "" {
+		return e.message() + ": " + e.info
+	}
+	return e.message()
+}
+
+// Is reports whether the target is a TodoError of the same kind so that errors.Is can be used
+func (e TodoError) Is(target error) bool {
+	t, ok := target.(TodoError)
+	return ok && t.kind == e.kind
+}
+
+var (
+	ErrTitleEmpty             = TodoError{kind: titleEmpty, fields: Fields{"title"}}
+	ErrIdRequired             = TodoError{kind: idRequired, fields: Fields{"id"}}
+	ErrNoFieldToUpdate        = TodoError{kind: noFieldToUpdate}
+	ErrTimezoneNotValid       = TodoError{kind: timezoneNotValid, fields: Fields{"dueTimezone"}}
+	ErrReminderOffsetNotValid = TodoError{kind: reminderOffsetNotValid, fields: Fields{"reminderOffset"}}
+	ErrClearFieldNotValid     = TodoError{kind: clearFieldNotValid, fields: Fields{"clear"}}
+	ErrListOptionNotValid     = TodoError{kind: listOptionNotValid}
+	ErrPriorityNotValid       = TodoError{kind: priorityNotValid, fields: Fields{"priority"}}
+	ErrSortNotValid           = TodoError{kind: sortNotValid, fields: Fields{"sort"}}
+	ErrReorderTargetNotValid  = TodoError{kind: reorderTargetNotValid, fields: Fields{"beforeId", "afterId"}}
+	ErrProjectNotValid        = TodoError{kind: projectNotValid, fields: Fields{"projectId"}}
+	ErrParentNotValid         = TodoError{kind: parentNotValid, fields: Fields{"parentId"}}
+	ErrParentCycle            = TodoError{kind: parentCycle, fields: Fields{"parentId"}}
+	ErrRecurrenceNotValid     = TodoError{kind: recurrenceNotValid, fields: Fields{"recurrence"}}
+	ErrRecurrenceNeedsDueDate = TodoError{kind: recurrenceNeedsDueDate, fields: Fields{"recurrence", "dueDate"}}
+	ErrScopeNotValid          = TodoError{kind: scopeNotValid, fields: Fields{"scope"}}
+	ErrArchiveNotDone         = TodoError{kind: archiveNotDone, fields: Fields{"archived"}}
+	ErrCursorNotValid         = TodoError{kind: cursorNotValid, fields: Fields{"cursor"}}
+	ErrSearchQueryEmpty       = TodoError{kind: searchQueryEmpty, fields: Fields{"q"}}
+	ErrSearchNotSupported     = TodoError{kind: searchNotSupported}
+	ErrFilterNotValid         = TodoError{kind: filterNotValid, fields: Fields{"filter"}}
+	ErrViewNameLength         = TodoError{kind: viewNameLength, fields: Fields{"name"}}
+	ErrViewNameTaken          = TodoError{kind: viewNameTaken, fields: Fields{"name"}}
+	ErrViewCriteriaNotValid   = TodoError{kind: viewCriteriaNotValid, fields: Fields{"criteria"}}
+	ErrNotesTooLong           = TodoError{kind: notesTooLong, fields: Fields{"notes"}}
+	ErrRenderNotValid         = TodoError{kind: renderNotValid, fields: Fields{"render"}}
+	ErrReadOnly               = TodoError{kind: readOnly}
+	ErrAssigneeNotValid       = TodoError{kind: assigneeNotValid, fields: Fields{"assigneeId"}}
+	ErrDependencyNotValid     = TodoError{kind: dependencyNotValid, fields: Fields{"blockedBy"}}
+	ErrDependencyCycle        = TodoError{kind: dependencyCycle, fields: Fields{"blockedBy"}}
+	ErrTodoBlocked            = TodoError{kind: todoBlocked, fields: Fields{"done"}}
+	ErrStatusNotValid         = TodoError{kind: statusNotValid, fields: Fields{"status"}}
+	ErrTransitionNotAllowed   = TodoError{kind: transitionNotAllowed, fields: Fields{"status"}}
+	ErrEstimateNotValid       = TodoError{kind: estimateNotValid, fields: Fields{"estimate"}}
+	ErrBurndownRangeNotValid  = TodoError{kind: burndownRangeNotValid, fields: Fields{"from", "to"}}
+	ErrTemplateNameLength     = TodoError{kind: templateNameLength, fields: Fields{"name"}}
+	ErrTemplateNameTaken      = TodoError{kind: templateNameTaken, fields: Fields{"name"}}
+	ErrTemplateItemsNotValid  = TodoError{kind: templateItemsNotValid, fields: Fields{"items"}}
+	ErrStartDateRequired      = TodoError{kind: startDateRequired, fields: Fields{"startDate"}}
+)
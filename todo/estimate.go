@@ -0,0 +1,53 @@
+package todo
+
+import "time"
+
+// MaxEstimate is the largest estimate a todo can have, in minutes or in points
+const MaxEstimate = 100000
+
+// MaxBurndownRange is the longest period a burndown can cover
+const MaxBurndownRange = 366 * 24 * time.Hour
+
+// BurndownOptions are the days of a burndown. Both From and To are included, and they are days in the Timezone
+type BurndownOptions struct {
+	From     time.Time
+	To       time.Time
+	Timezone string
+}
+
+// BurndownDay is the state of the estimates of a project at the end of a day. Remaining sums the estimates of the
+// todos that were not done yet, and Total sums the estimates of all the todos in the project on that day
+type BurndownDay struct {
+	Day       string `json:"day"`
+	Remaining int    `json:"remaining"`
+	Total     int    `json:"total"`
+}
+
+type Burndown struct {
+	ProjectId int           `json:"projectId"`
+	Unit      string        `json:"unit"`
+	Days      []BurndownDay `json:"days"`
+}
+
+// validateEstimate checks that the estimate is not negative and not larger than MaxEstimate
+func validateEstimate(estimate *int) error {
+	if estimate != nil && (*estimate < 0 || *estimate > MaxEstimate) {
+		return ErrEstimateNotValid
+	}
+	return nil
+}
+
+// validateBurndownOptions checks the range of the burndown and the timezone of its days
+func validateBurndownOptions(options *BurndownOptions) error {
+	if options.To.Before(options.From) || options.To.Sub(options.From) > MaxBurndownRange {
+		return ErrBurndownRangeNotValid
+	}
+
+	if options.Timezone == "" {
+		options.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(options.Timezone); err != nil {
+		return ErrTimezoneNotValid.With(options.Timezone)
+	}
+	return nil
+}
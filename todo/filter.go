@@ -0,0 +1,392 @@
+package todo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// FilterNode is a node of a parsed filter expression. It is one of FilterAnd, FilterOr, FilterNot and FilterTerm
+type FilterNode interface {
+	filterNode()
+}
+
+// FilterAnd matches the todos that match all of its nodes
+type FilterAnd struct {
+	Nodes []FilterNode
+}
+
+// FilterOr matches the todos that match any of its nodes
+type FilterOr struct {
+	Nodes []FilterNode
+}
+
+// FilterNot matches the todos that don't match its node
+type FilterNot struct {
+	Node FilterNode
+}
+
+// FilterTerm is a single comparison like priority>=high
+type FilterTerm struct {
+	Field    string
+	Operator string
+	// Value is a bool, Priority, FilterTime, int, string or nil for "none" depending on the field
+	Value any
+	// Raw is the term as it was written. It is used in the errors
+	Raw string
+}
+
+// FilterTime is a date or a timestamp in a filter. End is the start of the next day for dates
+// and equal to Start for timestamps
+type FilterTime struct {
+	Start time.Time
+	End   time.Time
+}
+
+func (FilterAnd) filterNode()  {}
+func (FilterOr) filterNode()   {}
+func (FilterNot) filterNode()  {}
+func (FilterTerm) filterNode() {}
+
+const (
+	FilterEqual          = ":"
+	FilterNotEqual       = "!="
+	FilterLess           = "<"
+	FilterLessOrEqual    = "<="
+	FilterGreater        = ">"
+	FilterGreaterOrEqual = ">="
+)
+
+// filterOperators are ordered so that the two character operators are matched first
+var filterOperators = []string{FilterNotEqual, FilterLessOrEqual, FilterGreaterOrEqual, FilterEqual, FilterLess, FilterGreater}
+
+type filterFieldKind int
+
+const (
+	filterBool filterFieldKind = iota
+	filterPriority
+	filterTime
+	filterText
+	filterReference
+)
+
+// filterFields maps the fields that can be used in filters to the kind of their values
+var filterFields = map[string]filterFieldKind{
+	"done":     filterBool,
+	"archived": filterBool,
+	"priority": filterPriority,
+	"due":      filterTime,
+	"created":  filterTime,
+	"updated":  filterTime,
+	"title":    filterText,
+	"tag":      filterText,
+	"status":   filterText,
+	"project":  filterReference,
+	"parent":   filterReference,
+}
+
+// maxFilterTerms limits the size of the generated query
+const maxFilterTerms = 50
+
+// ParseFilter parses a filter expression like `done:false priority>=high (tag:work OR tag:home)`.
+// Terms next to each other need to match together. OR, NOT, "-" prefix and parentheses can be used.
+// Dates are parsed in the given location
+func ParseFilter(input string, loc *time.Location) (FilterNode, error) {
+	tokens, err := tokenizeFilter(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, ErrFilterNotValid.With("filter is empty")
+	}
+
+	parser := &filterParser{tokens: tokens, loc: loc}
+	node, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if !parser.done() {
+		return nil, ErrFilterNotValid.With(fmt.Sprintf("unexpected %q", parser.peek()))
+	}
+	return node, nil
+}
+
+// tokenizeFilter splits the filter into parentheses, keywords and terms. Values of the terms can be quoted
+func tokenizeFilter(input string) ([]string, error) {
+	var tokens []string
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')':
+			tokens = append(tokens, string(r))
+			i++
+		default:
+			start := i
+			inQuotes := false
+			for i < len(runes) && (inQuotes || (!unicode.IsSpace(runes[i]) && runes[i] != '(' && runes[i] != ')')) {
+				if runes[i] == '\\' && inQuotes && i+1 < len(runes) {
+					i++
+				} else if runes[i] == '"' {
+					inQuotes = !inQuotes
+				}
+				i++
+			}
+			if inQuotes {
+				return nil, ErrFilterNotValid.With(fmt.Sprintf("%q has an unclosed quote", string(runes[start:])))
+			}
+			tokens = append(tokens, string(runes[start:i]))
+		}
+	}
+	return tokens, nil
+}
+
+type filterParser struct {
+	tokens []string
+	pos    int
+	terms  int
+	loc    *time.Location
+}
+
+func (p *filterParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *filterParser) peek() string {
+	if p.done() {
+		return ""
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() string {
+	token := p.peek()
+	p.pos++
+	return token
+}
+
+// parseOr parses the nodes separated with OR
+func (p *filterParser) parseOr() (FilterNode, error) {
+	var nodes []FilterNode
+	for {
+		node, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+
+		if p.peek() != "OR" {
+			break
+		}
+		p.next()
+	}
+
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return FilterOr{Nodes: nodes}, nil
+}
+
+// parseAnd parses the nodes next to each other until an OR or a closing parenthesis. AND is optional between them
+func (p *filterParser) parseAnd() (FilterNode, error) {
+	var nodes []FilterNode
+	for !p.done() && p.peek() != "OR" && p.peek() != ")" {
+		if p.peek() == "AND" {
+			p.next()
+			continue
+		}
+
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+
+	switch len(nodes) {
+	case 0:
+		return nil, ErrFilterNotValid.With("expected a term")
+	case 1:
+		return nodes[0], nil
+	}
+	return FilterAnd{Nodes: nodes}, nil
+}
+
+// parseUnary parses a negated node, a group in parentheses or a term
+func (p *filterParser) parseUnary() (FilterNode, error) {
+	token := p.next()
+
+	switch {
+	case token == "NOT":
+		if p.done() {
+			return nil, ErrFilterNotValid.With("expected a term after NOT")
+		}
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return FilterNot{Node: node}, nil
+	case token == "(":
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, ErrFilterNotValid.With("missing closing parenthesis")
+		}
+		return node, nil
+	case token == ")":
+		return nil, ErrFilterNotValid.With(`unexpected ")"`)
+	case strings.HasPrefix(token, "-") && len(token) > 1:
+		term, err := p.parseTerm(token[1:])
+		if err != nil {
+			return nil, err
+		}
+		return FilterNot{Node: term}, nil
+	}
+	return p.parseTerm(token)
+}
+
+// parseTerm parses a term like priority>=high and validates its value for the field
+func (p *filterParser) parseTerm(raw string) (FilterNode, error) {
+	p.terms++
+	if p.terms > maxFilterTerms {
+		return nil, ErrFilterNotValid.With(fmt.Sprintf("filter can have at most %d terms", maxFilterTerms))
+	}
+
+	field, operator, value, ok := splitFilterTerm(raw)
+	if !ok {
+		return nil, filterTermError(raw, "expected a term like field:value")
+	}
+
+	kind, ok := filterFields[field]
+	if !ok {
+		return nil, filterTermError(raw, fmt.Sprintf("unknown field %q", field))
+	}
+
+	// only the ordered fields can be compared with < and >
+	ordered := kind == filterPriority || kind == filterTime
+	if !ordered && operator != FilterEqual && operator != FilterNotEqual {
+		return nil, filterTermError(raw, fmt.Sprintf("%s can only be used with : and !=", field))
+	}
+
+	term := FilterTerm{Field: field, Operator: operator, Raw: raw}
+	switch kind {
+	case filterBool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, filterTermError(raw, "expected true or false")
+		}
+		term.Value = parsed
+	case filterPriority:
+		parsed, err := ParsePriority(strings.ToLower(value))
+		if err != nil {
+			return nil, filterTermError(raw, "expected one of none, low, medium, high and urgent")
+		}
+		term.Value = parsed
+	case filterTime:
+		if value == "none" {
+			if operator != FilterEqual && operator != FilterNotEqual {
+				return nil, filterTermError(raw, "none can only be used with : and !=")
+			}
+			term.Value = nil
+			break
+		}
+		parsed, err := parseFilterTime(value, p.loc)
+		if err != nil {
+			return nil, filterTermError(raw, "expected a date like 2026-11-01 or an RFC 3339 timestamp")
+		}
+		term.Value = parsed
+	case filterText:
+		if value == "" {
+			return nil, filterTermError(raw, "value can't be empty")
+		}
+		term.Value = value
+	case filterReference:
+		if value == "none" || value == "inbox" {
+			term.Value = nil
+			break
+		}
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, filterTermError(raw, "expected an id or none")
+		}
+		term.Value = id
+	}
+	return term, nil
+}
+
+// splitFilterTerm splits a term into its field, operator and unquoted value
+func splitFilterTerm(raw string) (string, string, string, bool) {
+	end := strings.IndexFunc(raw, func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '_'
+	})
+	if end <= 0 {
+		return "", "", "", false
+	}
+
+	field := strings.ToLower(raw[:end])
+	rest := raw[end:]
+	for _, operator := range filterOperators {
+		if strings.HasPrefix(rest, operator) {
+			value, err := unquoteFilterValue(rest[len(operator):])
+			if err != nil {
+				return "", "", "", false
+			}
+			return field, operator, value, true
+		}
+	}
+	return "", "", "", false
+}
+
+func unquoteFilterValue(value string) (string, error) {
+	if !strings.HasPrefix(value, `"`) {
+		return value, nil
+	}
+	return strconv.Unquote(value)
+}
+
+func parseFilterTime(value string, loc *time.Location) (FilterTime, error) {
+	if date, err := time.ParseInLocation(time.DateOnly, value, loc); err == nil {
+		return FilterTime{Start: date, End: date.AddDate(0, 0, 1)}, nil
+	}
+
+	timestamp, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return FilterTime{}, err
+	}
+	return FilterTime{Start: timestamp, End: timestamp}, nil
+}
+
+func filterTermError(raw string, reason string) error {
+	return ErrFilterNotValid.With(fmt.Sprintf("%q: %s", raw, reason))
+}
+
+// FilterUsesField reports whether any term of the filter is about the field
+func FilterUsesField(node FilterNode, field string) bool {
+	switch n := node.(type) {
+	case FilterAnd:
+		for _, child := range n.Nodes {
+			if FilterUsesField(child, field) {
+				return true
+			}
+		}
+	case FilterOr:
+		for _, child := range n.Nodes {
+			if FilterUsesField(child, field) {
+				return true
+			}
+		}
+	case FilterNot:
+		return FilterUsesField(n.Node, field)
+	case FilterTerm:
+		return n.Field == field
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+package todo
+
+import (
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParseFilter(t *testing.T) {
+	istanbul, _ := time.LoadLocation("Europe/Istanbul")
+	november := time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		input        string
+		loc          *time.Location
+		expectedNode FilterNode
+	}{
+		{
+			name:  "Terms next to each other",
+			input: "done:false priority>=high due<2026-11-01 tag:work",
+			loc:   time.UTC,
+			expectedNode: FilterAnd{Nodes: []FilterNode{
+				FilterTerm{Field: "done", Operator: FilterEqual, Value: false, Raw: "done:false"},
+				FilterTerm{Field: "priority", Operator: FilterGreaterOrEqual, Value: PriorityHigh, Raw: "priority>=high"},
+				FilterTerm{Field: "due", Operator: FilterLess, Value: FilterTime{Start: november, End: november.AddDate(0, 0, 1)}, Raw: "due<2026-11-01"},
+				FilterTerm{Field: "tag", Operator: FilterEqual, Value: "work", Raw: "tag:work"},
+			}},
+		},
+		{
+			name:  "OR binds looser than AND",
+			input: "tag:work priority:urgent OR tag:home",
+			loc:   time.UTC,
+			expectedNode: FilterOr{Nodes: []FilterNode{
+				FilterAnd{Nodes: []FilterNode{
+					FilterTerm{Field: "tag", Operator: FilterEqual, Value: "work", Raw: "tag:work"},
+					FilterTerm{Field: "priority", Operator: FilterEqual, Value: PriorityUrgent, Raw: "priority:urgent"},
+				}},
+				FilterTerm{Field: "tag", Operator: FilterEqual, Value: "home", Raw: "tag:home"},
+			}},
+		},
+		{
+			name:  "Parentheses, NOT and negated terms",
+			input: "NOT (tag:work OR tag:home) AND -project:none",
+			loc:   time.UTC,
+			expectedNode: FilterAnd{Nodes: []FilterNode{
+				FilterNot{Node: FilterOr{Nodes: []FilterNode{
+					FilterTerm{Field: "tag", Operator: FilterEqual, Value: "work", Raw: "tag:work"},
+					FilterTerm{Field: "tag", Operator: FilterEqual, Value: "home", Raw: "tag:home"},
+				}}},
+				FilterNot{Node: FilterTerm{Field: "project", Operator: FilterEqual, Value: nil, Raw: "project:none"}},
+			}},
+		},
+		{
+			name:         "Quoted value",
+			input:        `title:"buy \"fresh\" milk"`,
+			loc:          time.UTC,
+			expectedNode: FilterTerm{Field: "title", Operator: FilterEqual, Value: `buy "fresh" milk`, Raw: `title:"buy \"fresh\" milk"`},
+		},
+		{
+			name:  "Dates are parsed in the location",
+			input: "created:2026-11-01",
+			loc:   istanbul,
+			expectedNode: FilterTerm{Field: "created", Operator: FilterEqual, Raw: "created:2026-11-01", Value: FilterTime{
+				Start: time.Date(2026, time.November, 1, 0, 0, 0, 0, istanbul),
+				End:   time.Date(2026, time.November, 2, 0, 0, 0, 0, istanbul),
+			}},
+		},
+		{
+			name:         "Timestamp",
+			input:        "updated>2026-11-01T09:30:00Z",
+			loc:          time.UTC,
+			expectedNode: FilterTerm{Field: "updated", Operator: FilterGreater, Raw: "updated>2026-11-01T09:30:00Z", Value: FilterTime{Start: november.Add(570 * time.Minute), End: november.Add(570 * time.Minute)}},
+		},
+		{
+			name:         "Reference with id",
+			input:        "parent!=12",
+			loc:          time.UTC,
+			expectedNode: FilterTerm{Field: "parent", Operator: FilterNotEqual, Value: 12, Raw: "parent!=12"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			node, err := ParseFilter(tc.input, tc.loc)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedNode, node)
+		})
+	}
+}
+
+func TestParseFilterNotValid(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		expectedTerm string
+	}{
+		{name: "Empty filter", input: "  ", expectedTerm: "empty"},
+		{name: "Unknown field", input: "done:true colour:red", expectedTerm: `"colour:red"`},
+		{name: "Missing operator", input: "urgent", expectedTerm: `"urgent"`},
+		{name: "Not a boolean", input: "done:maybe", expectedTerm: `"done:maybe"`},
+		{name: "Not a priority", input: "priority>=hgh", expectedTerm: `"priority>=hgh"`},
+		{name: "Not a date", input: "due<tomorrow", expectedTerm: `"due<tomorrow"`},
+		{name: "Ordering a field without order", input: "tag>work", expectedTerm: `"tag>work"`},
+		{name: "Comparing with none", input: "due<none", expectedTerm: `"due<none"`},
+		{name: "Unclosed quote", input: `title:"milk`, expectedTerm: `title:\"milk`},
+		{name: "Unclosed parenthesis", input: "(tag:work OR tag:home", expectedTerm: "parenthesis"},
+		{name: "Unexpected parenthesis", input: "tag:work)", expectedTerm: `")"`},
+		{name: "Dangling OR", input: "tag:work OR", expectedTerm: "expected a term"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseFilter(tc.input, time.UTC)
+			assert.ErrorIs(t, err, ErrFilterNotValid)
+			assert.Contains(t, err.Error(), tc.expectedTerm)
+		})
+	}
+}
+
+func TestFilterBuilder(t *testing.T) {
+	tests := []struct {
+		name              string
+		input             string
+		expectedCondition string
+		expectedArgs      pgx.NamedArgs
+	}{
+		{
+			name:              "Values are passed as args",
+			input:             "done:false priority>=high",
+			expectedCondition: "(done = @filter0 AND priority >= @filter1)",
+			expectedArgs:      pgx.NamedArgs{"filter0": false, "filter1": PriorityHigh},
+		},
+		{
+			name:              "Title is searched with escaped wildcards and backslashes",
+			input:             `title:"50%_off\\"`,
+			expectedCondition: "title ILIKE @filter0",
+			expectedArgs:      pgx.NamedArgs{"filter0": `%50\%\_off\\%`},
+		},
+		{
+			name:              "Status is matched exactly",
+			input:             `status:"in_progress"`,
+			expectedCondition: "status = @filter0",
+			expectedArgs:      pgx.NamedArgs{"filter0": "in_progress"},
+		},
+		{
+			name:              "Date is compared as a whole day",
+			input:             "due!=2026-11-01",
+			expectedCondition: "(due_date IS NULL OR due_date < @filter0 OR due_date >= @filter1)",
+			expectedArgs: pgx.NamedArgs{
+				"filter0": time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC),
+				"filter1": time.Date(2026, time.November, 2, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:              "Negated group",
+			input:             "-project:none OR NOT parent:3",
+			expectedCondition: "((project_id IS NULL) IS NOT TRUE OR (parent_id = @filter0) IS NOT TRUE)",
+			expectedArgs:      pgx.NamedArgs{"filter0": 3},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			node, err := ParseFilter(tc.input, time.UTC)
+			assert.Nil(t, err)
+
+			args := pgx.NamedArgs{}
+			builder := &filterBuilder{args: args}
+			assert.Equal(t, tc.expectedCondition, builder.build(node))
+			assert.Equal(t, tc.expectedArgs, args)
+		})
+	}
+}
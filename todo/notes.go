@@ -0,0 +1,52 @@
+package todo
+
+import (
+	"bytes"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"unicode/utf8"
+)
+
+// MaxNotesLength is the maximum count of characters in the notes of a todo
+const MaxNotesLength = 20000
+
+var markdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+// notesPolicy allows the formatting that Markdown produces but removes scripts, styles and unsafe links
+var notesPolicy = bluemonday.UGCPolicy()
+
+// validateNotes checks the size of the notes
+func validateNotes(notes *string) error {
+	if notes != nil && utf8.RuneCountInString(*notes) > MaxNotesLength {
+		return ErrNotesTooLong
+	}
+	return nil
+}
+
+// RenderNotes converts the Markdown notes to sanitized HTML
+func RenderNotes(source string) (string, error) {
+	var rendered bytes.Buffer
+	if err := markdown.Convert([]byte(source), &rendered); err != nil {
+		return "", err
+	}
+	return notesPolicy.Sanitize(rendered.String()), nil
+}
+
+// renderTodoNotes fills NotesHTML of the todo and its subtasks
+func renderTodoNotes(t *Todo) error {
+	if t.Notes != nil {
+		html, err := RenderNotes(*t.Notes)
+		if err != nil {
+			return err
+		}
+		t.NotesHTML = &html
+	}
+
+	for i := range t.Subtasks {
+		if err := renderTodoNotes(&t.Subtasks[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package todo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRenderNotes(t *testing.T) {
+	tests := []struct {
+		name     string
+		notes    string
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "Markdown",
+			notes:    "# Plan\n\n- [x] **call** the [bank](https://example.com)\n- ~~old~~ item",
+			contains: []string{"<h1>Plan</h1>", "<strong>call</strong>", `href="https://example.com"`, "<del>old</del>"},
+		},
+		{
+			name:     "Script",
+			notes:    "hello <script>alert(1)</script>",
+			contains: []string{"hello"},
+			excludes: []string{"<script"},
+		},
+		{
+			name:     "Javascript link",
+			notes:    "[click](javascript:alert(1)) <a href=\"javascript:alert(2)\" onclick=\"alert(3)\">raw</a>",
+			contains: []string{"click"},
+			excludes: []string{"javascript:", "onclick"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			html, err := RenderNotes(tc.notes)
+			assert.Nil(t, err)
+			for _, expected := range tc.contains {
+				assert.Contains(t, html, expected)
+			}
+			for _, unexpected := range tc.excludes {
+				assert.NotContains(t, html, unexpected)
+			}
+		})
+	}
+}
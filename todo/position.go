@@ -0,0 +1,26 @@
+package todo
+
+// positionGap is the distance between the positions of two todos when they are created or renumbered
+const positionGap = 1024
+
+// minPositionGap is the smallest distance allowed between two positions.
+// When there is no room left between two todos, the positions of the user are renumbered
+const minPositionGap = 1e-6
+
+// positionBetween returns a position between lower and upper. nil means there is no todo on that side.
+// The second return value is false if there is no room left between the given positions
+func positionBetween(lower, upper *float64) (float64, bool) {
+	switch {
+	case lower == nil && upper == nil:
+		return positionGap, true
+	case lower == nil:
+		return *upper - positionGap, true
+	case upper == nil:
+		return *lower + positionGap, true
+	}
+
+	if *upper-*lower < minPositionGap {
+		return 0, false
+	}
+	return *lower + (*upper-*lower)/2, true
+}
@@ -0,0 +1,61 @@
+package todo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPositionBetween(t *testing.T) {
+	lower := 1024.0
+	upper := 2048.0
+	tooClose := lower + minPositionGap/2
+
+	tests := []struct {
+		name             string
+		lower            *float64
+		upper            *float64
+		expectedPosition float64
+		expectedOk       bool
+	}{
+		{
+			name:             "No todo on both sides",
+			expectedPosition: positionGap,
+			expectedOk:       true,
+		},
+		{
+			name:             "Moved to the top",
+			upper:            &lower,
+			expectedPosition: 0,
+			expectedOk:       true,
+		},
+		{
+			name:             "Moved to the bottom",
+			lower:            &upper,
+			expectedPosition: 3072,
+			expectedOk:       true,
+		},
+		{
+			name:             "Moved between two todos",
+			lower:            &lower,
+			upper:            &upper,
+			expectedPosition: 1536,
+			expectedOk:       true,
+		},
+		{
+			name:       "No room left between two todos",
+			lower:      &lower,
+			upper:      &tooClose,
+			expectedOk: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			position, ok := positionBetween(tc.lower, tc.upper)
+			assert.Equal(t, tc.expectedOk, ok)
+			if tc.expectedOk {
+				assert.Equal(t, tc.expectedPosition, position)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package todo
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Priority is the importance of a todo. It is stored as a number so that it can be sorted
+type Priority int
+
+const (
+	PriorityNone Priority = iota
+	PriorityLow
+	PriorityMedium
+	PriorityHigh
+	PriorityUrgent
+)
+
+var priorityNames = []string{"none", "low", "medium", "high", "urgent"}
+
+func (p Priority) String() string {
+	if p < PriorityNone || p > PriorityUrgent {
+		return fmt.Sprintf("Priority(%d)", int(p))
+	}
+	return priorityNames[p]
+}
+
+// ParsePriority returns the priority with the given name
+func ParsePriority(name string) (Priority, error) {
+	for i, priorityName := range priorityNames {
+		if priorityName == name {
+			return Priority(i), nil
+		}
+	}
+	return PriorityNone, ErrPriorityNotValid.With(name)
+}
+
+func (p Priority) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
+
+func (p *Priority) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return ErrPriorityNotValid
+	}
+
+	parsed, err := ParsePriority(name)
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
@@ -0,0 +1,705 @@
+package todo
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// QuickAdd is what was recognized in the title of a todo that is added in the quick mode.
+// The recognized parts are removed from the title, and the rest of the words are kept in their order
+type QuickAdd struct {
+	// Title is the cleaned title
+	Title      string     `json:"-"`
+	DueDate    *time.Time `json:"dueDate,omitempty"`
+	Recurrence *string    `json:"recurrence,omitempty"`
+	Tags       []string   `json:"tags,omitempty"`
+	Priority   *Priority  `json:"priority,omitempty"`
+	// Project is the name after @. It is only recognized if one of the projects has the name
+	Project *string `json:"project,omitempty"`
+	// Matches are the recognized parts of the title in their order
+	Matches []string `json:"matches"`
+}
+
+// QuickAddResult is the todo created in the quick mode with what was recognized in its title
+type QuickAddResult struct {
+	Todo
+	Recognized QuickAdd `json:"recognized"`
+}
+
+// dates without a time of the day are due at the end of the day
+const (
+	quickAddDefaultHour   = 23
+	quickAddDefaultMinute = 59
+)
+
+// maxQuickAddAmount limits the numbers in "in 3 days" and "every 2 weeks"
+const maxQuickAddAmount = 999
+
+// clockPattern matches the times like 9, 9am, 9:30 and 21:00. The am or pm can also be the next word
+var clockPattern = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm)?$`)
+
+// dayPattern matches the days of the month like 1, 1st and 22nd
+var dayPattern = regexp.MustCompile(`^(\d{1,2})(st|nd|rd|th)?$`)
+
+var weekdayNames = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// weekdayAbbreviations are only recognized after "every", since words like "sat" and "wed" are common in titles
+var weekdayAbbreviations = map[string]time.Weekday{
+	"sun":   time.Sunday,
+	"mon":   time.Monday,
+	"tue":   time.Tuesday,
+	"tues":  time.Tuesday,
+	"wed":   time.Wednesday,
+	"thu":   time.Thursday,
+	"thur":  time.Thursday,
+	"thurs": time.Thursday,
+	"fri":   time.Friday,
+	"sat":   time.Saturday,
+}
+
+var monthNames = map[string]time.Month{
+	"january": time.January, "jan": time.January,
+	"february": time.February, "feb": time.February,
+	"march": time.March, "mar": time.March,
+	"april": time.April, "apr": time.April,
+	"may":  time.May,
+	"june": time.June, "jun": time.June,
+	"july": time.July, "jul": time.July,
+	"august": time.August, "aug": time.August,
+	"september": time.September, "sep": time.September, "sept": time.September,
+	"october": time.October, "oct": time.October,
+	"november": time.November, "nov": time.November,
+	"december": time.December, "dec": time.December,
+}
+
+// weekdayOrdinals are the ordinals of "every 2nd tuesday" and "every last friday"
+var weekdayOrdinals = map[string]int{
+	"1st": 1, "first": 1,
+	"2nd": 2, "second": 2,
+	"3rd": 3, "third": 3,
+	"4th": 4, "fourth": 4,
+	"last": -1,
+}
+
+var frequencyUnits = map[string]string{
+	"day":   FreqDaily,
+	"week":  FreqWeekly,
+	"month": FreqMonthly,
+	"year":  FreqYearly,
+}
+
+// quickAddParser walks the words of a title and keeps the ones that are not recognized
+type quickAddParser struct {
+	words  []string
+	now    time.Time
+	result QuickAdd
+	kept   []string
+	// date is the midnight of the due day if a date is recognized
+	date *time.Time
+	// hour and minute are the time of the day if a time is recognized
+	hour, minute int
+	hasTime      bool
+	recurrence   *Recurrence
+	// firstDay returns the first day on or after the given midnight that the recurrence falls on.
+	// The due date is calculated with it when the title has a recurrence but not a date
+	firstDay func(from time.Time) time.Time
+	// isProject reports whether the name after @ is a project
+	isProject func(name string) bool
+}
+
+// ParseQuickAdd recognizes the due date, the time, the recurrence, the #tags, the !priority and the @project
+// in a title like "Pay rent every 1st of month at 9am #home !high". Dates are calculated relative to now
+// in its location, and the words that aren't recognized are kept as the title. An @name is only recognized
+// if isProject reports that it is a project, so that mentions like "@john" are kept in the title
+func ParseQuickAdd(title string, now time.Time, isProject func(name string) bool) *QuickAdd {
+	p := &quickAddParser{
+		words:     strings.Fields(title),
+		now:       now,
+		result:    QuickAdd{Matches: []string{}},
+		isProject: isProject,
+	}
+
+	matchers := []func(i int) int{p.matchTag, p.matchPriority, p.matchProject, p.matchRecurrence, p.matchDate, p.matchTime}
+	for i := 0; i < len(p.words); {
+		consumed := 0
+		for _, match := range matchers {
+			if consumed = match(i); consumed > 0 {
+				break
+			}
+		}
+
+		if consumed == 0 {
+			p.kept = append(p.kept, p.words[i])
+			i++
+			continue
+		}
+		p.result.Matches = append(p.result.Matches, strings.Join(p.words[i:i+consumed], " "))
+		i += consumed
+	}
+
+	p.result.Title = strings.Join(p.kept, " ")
+	p.resolveDueDate()
+	return &p.result
+}
+
+// word returns the lowercased word at i without the trailing punctuation. It is empty after the last word
+func (p *quickAddParser) word(i int) string {
+	if i >= len(p.words) {
+		return ""
+	}
+	return strings.ToLower(strings.TrimRight(p.words[i], ",.;"))
+}
+
+// today returns the midnight of the current day
+func (p *quickAddParser) today() time.Time {
+	year, month, day := p.now.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, p.now.Location())
+}
+
+// prefixed returns the rest of the word at i if it starts with the prefix, without the trailing punctuation
+func (p *quickAddParser) prefixed(i int, prefix string) (string, bool) {
+	word, found := strings.CutPrefix(p.words[i], prefix)
+	if !found {
+		return "", false
+	}
+	word = strings.TrimRight(word, ",.;:!?")
+	return word, word != ""
+}
+
+// matchTag recognizes #tags. Tags need to start with a letter so that "#1" is kept in the title
+func (p *quickAddParser) matchTag(i int) int {
+	name, ok := p.prefixed(i, "#")
+	if !ok {
+		return 0
+	}
+	if first, _ := utf8.DecodeRuneInString(name); !unicode.IsLetter(first) {
+		return 0
+	}
+	p.result.Tags = append(p.result.Tags, name)
+	return 1
+}
+
+// matchPriority recognizes the first priority like !high
+func (p *quickAddParser) matchPriority(i int) int {
+	if p.result.Priority != nil || !strings.HasPrefix(p.words[i], "!") {
+		return 0
+	}
+	priority, err := ParsePriority(strings.TrimPrefix(p.word(i), "!"))
+	if err != nil {
+		return 0
+	}
+	p.result.Priority = &priority
+	return 1
+}
+
+// matchProject recognizes the first project like @home
+func (p *quickAddParser) matchProject(i int) int {
+	if p.result.Project != nil {
+		return 0
+	}
+	name, ok := p.prefixed(i, "@")
+	if !ok || !p.isProject(name) {
+		return 0
+	}
+	p.result.Project = &name
+	return 1
+}
+
+// matchTime recognizes the times like "at 9", "9am", "at 9:30 pm", "21:00" and "noon"
+func (p *quickAddParser) matchTime(i int) int {
+	if p.hasTime {
+		return 0
+	}
+
+	offset := 0
+	if p.word(i) == "at" {
+		offset = 1
+	}
+
+	hour, minute, consumed := p.clockAt(i+offset, offset == 1)
+	if consumed == 0 {
+		return 0
+	}
+	p.hour, p.minute, p.hasTime = hour, minute, true
+	return offset + consumed
+}
+
+// clockAt parses the time at i. A number without minutes or am/pm is only a time if bare is allowed, like after "at"
+func (p *quickAddParser) clockAt(i int, bare bool) (int, int, int) {
+	switch p.word(i) {
+	case "noon":
+		return 12, 0, 1
+	case "midnight":
+		return 0, 0, 1
+	}
+
+	parts := clockPattern.FindStringSubmatch(p.word(i))
+	if parts == nil {
+		return 0, 0, 0
+	}
+
+	hour, _ := strconv.Atoi(parts[1])
+	minute := 0
+	if parts[2] != "" {
+		minute, _ = strconv.Atoi(parts[2])
+	}
+
+	consumed := 1
+	meridiem := parts[3]
+	if next := p.word(i + 1); meridiem == "" && (next == "am" || next == "pm") {
+		meridiem = next
+		consumed = 2
+	}
+
+	switch {
+	case meridiem != "":
+		if hour < 1 || hour > 12 {
+			return 0, 0, 0
+		}
+		hour %= 12
+		if meridiem == "pm" {
+			hour += 12
+		}
+	case parts[2] == "" && !bare:
+		return 0, 0, 0
+	}
+
+	if hour > 23 || minute > 59 {
+		return 0, 0, 0
+	}
+	return hour, minute, consumed
+}
+
+// matchDate recognizes the due day, optionally after "on" or "by", like "tomorrow", "on friday", "next monday",
+// "in 3 days", "2026-11-02", "nov 2" and "2nd of november"
+func (p *quickAddParser) matchDate(i int) int {
+	if p.date != nil {
+		return 0
+	}
+
+	switch p.word(i) {
+	case "on", "by":
+		if consumed := p.dateAt(i + 1); consumed > 0 {
+			return consumed + 1
+		}
+		return 0
+	case "next":
+		weekday, ok := weekdayNames[p.word(i+1)]
+		if !ok {
+			return 0
+		}
+		p.setDate(nextWeekday(p.today(), weekday))
+		return 2
+	case "in":
+		return p.relativeDateAt(i)
+	}
+	return p.dateAt(i)
+}
+
+func (p *quickAddParser) setDate(date time.Time) {
+	p.date = &date
+}
+
+// dateAt parses the day at i and returns the count of the words it has
+func (p *quickAddParser) dateAt(i int) int {
+	today := p.today()
+	word := p.word(i)
+
+	switch word {
+	case "today":
+		p.setDate(today)
+		return 1
+	case "tomorrow":
+		p.setDate(today.AddDate(0, 0, 1))
+		return 1
+	}
+
+	if weekday, ok := weekdayNames[word]; ok {
+		p.setDate(nextWeekday(today, weekday))
+		return 1
+	}
+
+	if date, err := time.ParseInLocation(time.DateOnly, word, p.now.Location()); err == nil {
+		p.setDate(date)
+		return 1
+	}
+
+	month, day, year, consumed := p.monthDayAt(i)
+	if consumed == 0 {
+		return 0
+	}
+
+	if year != 0 {
+		date := time.Date(year, month, day, 0, 0, 0, 0, p.now.Location())
+		if date.Day() != day {
+			return 0
+		}
+		p.setDate(date)
+		return consumed
+	}
+
+	date, ok := nextMonthDay(today, month, day)
+	if !ok {
+		return 0
+	}
+	p.setDate(date)
+	return consumed
+}
+
+// relativeDateAt parses the days like "in 3 days", "in a week" and "in 2 months" at i
+func (p *quickAddParser) relativeDateAt(i int) int {
+	amount, ok := p.amountAt(i + 1)
+	if !ok {
+		return 0
+	}
+
+	date := p.today()
+	switch strings.TrimSuffix(p.word(i+2), "s") {
+	case "day":
+		date = date.AddDate(0, 0, amount)
+	case "week":
+		date = date.AddDate(0, 0, 7*amount)
+	case "month":
+		date = date.AddDate(0, amount, 0)
+	case "year":
+		date = date.AddDate(amount, 0, 0)
+	default:
+		return 0
+	}
+	p.setDate(date)
+	return 3
+}
+
+// amountAt parses the number at i. "a" and "an" are one
+func (p *quickAddParser) amountAt(i int) (int, bool) {
+	word := p.word(i)
+	if word == "a" || word == "an" {
+		return 1, true
+	}
+
+	amount, err := strconv.Atoi(word)
+	if err != nil || amount < 1 || amount > maxQuickAddAmount {
+		return 0, false
+	}
+	return amount, true
+}
+
+// monthDayAt parses the days like "nov 2", "november 2nd, 2027", "2 nov" and "2nd of november".
+// The year is zero if it isn't written
+func (p *quickAddParser) monthDayAt(i int) (time.Month, int, int, int) {
+	var month time.Month
+	var day, consumed int
+
+	if m, ok := monthNames[p.word(i)]; ok {
+		d, ok := dayOfMonth(p.word(i + 1))
+		if !ok {
+			return 0, 0, 0, 0
+		}
+		month, day, consumed = m, d, 2
+	} else {
+		d, ok := dayOfMonth(p.word(i))
+		if !ok {
+			return 0, 0, 0, 0
+		}
+
+		monthIndex := i + 1
+		if p.word(monthIndex) == "of" {
+			monthIndex++
+		}
+		m, ok := monthNames[p.word(monthIndex)]
+		if !ok {
+			return 0, 0, 0, 0
+		}
+		month, day, consumed = m, d, monthIndex-i+1
+	}
+
+	year := 0
+	if word := p.word(i + consumed); len(word) == 4 {
+		if y, err := strconv.Atoi(word); err == nil {
+			year = y
+			consumed++
+		}
+	}
+	return month, day, year, consumed
+}
+
+// matchRecurrence recognizes "daily", "weekly", "monthly", "yearly" and the rules starting with "every" like
+// "every day", "every other week", "every 3 months", "every weekday", "every mon and wed", "every 2nd tuesday",
+// "every 1st of the month", "every month on the 15th" and "every dec 25th"
+func (p *quickAddParser) matchRecurrence(i int) int {
+	if p.recurrence != nil {
+		return 0
+	}
+
+	switch p.word(i) {
+	case "daily":
+		return p.setRecurrence(&Recurrence{Freq: FreqDaily, Interval: 1}, nil, 1)
+	case "weekly":
+		return p.setRecurrence(&Recurrence{Freq: FreqWeekly, Interval: 1}, nil, 1)
+	case "monthly":
+		return p.setRecurrence(&Recurrence{Freq: FreqMonthly, Interval: 1}, nil, 1)
+	case "yearly", "annually":
+		return p.setRecurrence(&Recurrence{Freq: FreqYearly, Interval: 1}, nil, 1)
+	}
+
+	if p.word(i) != "every" {
+		return 0
+	}
+
+	j := i + 1
+	word := p.word(j)
+
+	if freq, ok := frequencyUnits[word]; ok {
+		if freq == FreqMonthly {
+			if day, consumed := p.onMonthDayAt(j + 1); consumed > 0 {
+				return p.setRecurrence(&Recurrence{Freq: FreqMonthly, Interval: 1}, monthDayFirst(day), consumed+2)
+			}
+		}
+		return p.setRecurrence(&Recurrence{Freq: freq, Interval: 1}, nil, 2)
+	}
+
+	if word == "other" {
+		if freq, ok := frequencyUnits[p.word(j+1)]; ok {
+			return p.setRecurrence(&Recurrence{Freq: freq, Interval: 2}, nil, 3)
+		}
+		return 0
+	}
+
+	if amount, err := strconv.Atoi(word); err == nil && amount >= 1 && amount <= maxQuickAddAmount {
+		if freq, ok := frequencyUnits[strings.TrimSuffix(p.word(j+1), "s")]; ok {
+			return p.setRecurrence(&Recurrence{Freq: freq, Interval: amount}, nil, 3)
+		}
+		return 0
+	}
+
+	switch word {
+	case "weekday":
+		days := []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+		return p.setRecurrence(weeklyOn(days), weekdaysFirst(days), 2)
+	case "weekend":
+		days := []time.Weekday{time.Saturday, time.Sunday}
+		return p.setRecurrence(weeklyOn(days), weekdaysFirst(days), 2)
+	}
+
+	if days, consumed := p.weekdayListAt(j); consumed > 0 {
+		return p.setRecurrence(weeklyOn(days), weekdaysFirst(days), consumed+1)
+	}
+
+	if ordinal, ok := weekdayOrdinals[word]; ok {
+		if weekday, ok := recurrenceWeekday(p.word(j + 1)); ok {
+			consumed := 2 + p.ofMonthAt(j+2)
+			recurrence := &Recurrence{Freq: FreqMonthly, Interval: 1, ByDay: []WeekdayNum{{Ordinal: ordinal, Weekday: weekday}}}
+			return p.setRecurrence(recurrence, nthWeekdayFirst(recurrence), consumed+1)
+		}
+	}
+
+	if day, ok := dayOfMonth(word); ok {
+		if ofMonth := p.ofMonthAt(j + 1); ofMonth > 0 {
+			return p.setRecurrence(&Recurrence{Freq: FreqMonthly, Interval: 1}, monthDayFirst(day), ofMonth+2)
+		}
+	}
+
+	if month, day, year, consumed := p.monthDayAt(j); consumed > 0 && year == 0 {
+		if _, ok := nextMonthDay(p.today(), month, day); ok {
+			first := func(from time.Time) time.Time {
+				date, _ := nextMonthDay(from, month, day)
+				return date
+			}
+			return p.setRecurrence(&Recurrence{Freq: FreqYearly, Interval: 1}, first, consumed+1)
+		}
+	}
+	return 0
+}
+
+func (p *quickAddParser) setRecurrence(recurrence *Recurrence, firstDay func(from time.Time) time.Time, consumed int) int {
+	p.recurrence = recurrence
+	p.firstDay = firstDay
+	return consumed
+}
+
+// onMonthDayAt parses "on the 15th" and "on 15th" at i
+func (p *quickAddParser) onMonthDayAt(i int) (int, int) {
+	if p.word(i) != "on" {
+		return 0, 0
+	}
+
+	dayIndex := i + 1
+	if p.word(dayIndex) == "the" {
+		dayIndex++
+	}
+
+	// the day needs its ordinal suffix, so that "every month on 15 tasks" is not a day
+	day, ok := dayOfMonth(p.word(dayIndex))
+	if !ok || dayPattern.FindStringSubmatch(p.word(dayIndex))[2] == "" {
+		return 0, 0
+	}
+	return day, dayIndex - i + 1
+}
+
+// ofMonthAt parses "month", "of month", "of the month" and "of every month" at i. It returns the count of the words
+func (p *quickAddParser) ofMonthAt(i int) int {
+	j := i
+	if p.word(j) == "of" {
+		j++
+	}
+	if word := p.word(j); word == "the" || word == "each" || word == "every" {
+		j++
+	}
+	if p.word(j) != "month" {
+		return 0
+	}
+	return j - i + 1
+}
+
+// weekdayListAt parses the weekdays like "monday", "mon and wed" and "tue, thu and sat" at i
+func (p *quickAddParser) weekdayListAt(i int) ([]time.Weekday, int) {
+	var days []time.Weekday
+	j := i
+	for {
+		weekday, ok := recurrenceWeekday(p.word(j))
+		if !ok {
+			break
+		}
+		days = append(days, weekday)
+		j++
+
+		if _, ok := recurrenceWeekday(p.word(j + 1)); p.word(j) == "and" && ok {
+			j++
+		}
+	}
+	return days, j - i
+}
+
+// resolveDueDate sets the due date and the recurrence of the result. Without a date, the due date is the first day
+// of the recurrence, or the next time that the recognized time of the day comes
+func (p *quickAddParser) resolveDueDate() {
+	if p.recurrence != nil {
+		rule := p.recurrence.String()
+		p.result.Recurrence = &rule
+	}
+
+	if p.date == nil && p.recurrence == nil && !p.hasTime {
+		return
+	}
+
+	hour, minute := quickAddDefaultHour, quickAddDefaultMinute
+	if p.hasTime {
+		hour, minute = p.hour, p.minute
+	}
+	at := func(day time.Time) time.Time {
+		return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, p.now.Location())
+	}
+
+	if p.date != nil {
+		dueDate := at(*p.date)
+		p.result.DueDate = &dueDate
+		return
+	}
+
+	firstDay := p.firstDay
+	if firstDay == nil {
+		firstDay = func(from time.Time) time.Time { return from }
+	}
+
+	today := p.today()
+	dueDate := at(firstDay(today))
+	if dueDate.Before(p.now) {
+		dueDate = at(firstDay(today.AddDate(0, 0, 1)))
+	}
+	p.result.DueDate = &dueDate
+}
+
+// recurrenceWeekday returns the weekday with the name or the abbreviation
+func recurrenceWeekday(word string) (time.Weekday, bool) {
+	if weekday, ok := weekdayNames[word]; ok {
+		return weekday, true
+	}
+	weekday, ok := weekdayAbbreviations[word]
+	return weekday, ok
+}
+
+// dayOfMonth parses the days like 2 and 2nd
+func dayOfMonth(word string) (int, bool) {
+	parts := dayPattern.FindStringSubmatch(word)
+	if parts == nil {
+		return 0, false
+	}
+	day, _ := strconv.Atoi(parts[1])
+	return day, day >= 1 && day <= 31
+}
+
+// nextWeekday returns the first day with the weekday after the given day
+func nextWeekday(from time.Time, weekday time.Weekday) time.Time {
+	days := (int(weekday)-int(from.Weekday())+6)%7 + 1
+	return from.AddDate(0, 0, days)
+}
+
+// nextMonthDay returns the first date with the month and the day on or after the given day.
+// February 29 is looked for in the next leap year
+func nextMonthDay(from time.Time, month time.Month, day int) (time.Time, bool) {
+	for year := from.Year(); year <= from.Year()+8; year++ {
+		date := time.Date(year, month, day, 0, 0, 0, 0, from.Location())
+		if date.Day() == day && !date.Before(from) {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func weeklyOn(days []time.Weekday) *Recurrence {
+	recurrence := &Recurrence{Freq: FreqWeekly, Interval: 1}
+	for _, day := range days {
+		recurrence.ByDay = append(recurrence.ByDay, WeekdayNum{Weekday: day})
+	}
+	return recurrence
+}
+
+// weekdaysFirst returns the first day with one of the weekdays
+func weekdaysFirst(days []time.Weekday) func(from time.Time) time.Time {
+	return func(from time.Time) time.Time {
+		for offset := 0; offset < 7; offset++ {
+			date := from.AddDate(0, 0, offset)
+			for _, day := range days {
+				if date.Weekday() == day {
+					return date
+				}
+			}
+		}
+		return from
+	}
+}
+
+// monthDayFirst returns the first day with the day of the month. Months without the day are skipped
+func monthDayFirst(day int) func(from time.Time) time.Time {
+	return func(from time.Time) time.Time {
+		for months := 0; months <= 12; months++ {
+			date := time.Date(from.Year(), from.Month()+time.Month(months), day, 0, 0, 0, 0, from.Location())
+			if date.Day() == day && !date.Before(from) {
+				return date
+			}
+		}
+		return from
+	}
+}
+
+// nthWeekdayFirst returns the first day that the monthly rule with an ordinal weekday falls on
+func nthWeekdayFirst(recurrence *Recurrence) func(from time.Time) time.Time {
+	return func(from time.Time) time.Time {
+		if next, ok := recurrence.Next(from.AddDate(0, 0, -1)); ok {
+			return next
+		}
+		return from
+	}
+}
@@ -0,0 +1,252 @@
+package todo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParseQuickAdd(t *testing.T) {
+	// Sunday
+	now := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	at := func(month time.Month, day int, hour int, minute int) *time.Time {
+		date := time.Date(2026, month, day, hour, minute, 0, 0, time.UTC)
+		return &date
+	}
+	// only @home is a project
+	isProject := func(name string) bool { return name == "home" }
+	high := PriorityHigh
+	urgent := PriorityUrgent
+	home := "home"
+
+	tests := []struct {
+		name               string
+		input              string
+		expectedTitle      string
+		expectedDueDate    *time.Time
+		expectedRecurrence string
+		expectedTags       []string
+		expectedPriority   *Priority
+		expectedProject    *string
+		expectedMatches    []string
+	}{
+		{
+			name:            "Nothing to recognize",
+			input:           "Call  mom",
+			expectedTitle:   "Call mom",
+			expectedMatches: []string{},
+		},
+		{
+			name:               "Monthly rent",
+			input:              "Pay rent every 1st of month at 9am #home !high",
+			expectedTitle:      "Pay rent",
+			expectedDueDate:    at(time.November, 1, 9, 0),
+			expectedRecurrence: "FREQ=MONTHLY",
+			expectedTags:       []string{"home"},
+			expectedPriority:   &high,
+			expectedMatches:    []string{"every 1st of month", "at 9am", "#home", "!high"},
+		},
+		{
+			name:            "Tomorrow without a time is due at the end of the day",
+			input:           "Buy milk tomorrow",
+			expectedTitle:   "Buy milk",
+			expectedDueDate: at(time.October, 19, 23, 59),
+			expectedMatches: []string{"tomorrow"},
+		},
+		{
+			name:            "Today with a time",
+			input:           "Standup today at 9:30 pm",
+			expectedTitle:   "Standup",
+			expectedDueDate: at(time.October, 18, 21, 30),
+			expectedMatches: []string{"today", "at 9:30 pm"},
+		},
+		{
+			name:            "Time that has passed today is tomorrow",
+			input:           "Water the plants at 8",
+			expectedTitle:   "Water the plants",
+			expectedDueDate: at(time.October, 19, 8, 0),
+			expectedMatches: []string{"at 8"},
+		},
+		{
+			name:            "Noon",
+			input:           "Lunch with Ayşe noon",
+			expectedTitle:   "Lunch with Ayşe",
+			expectedDueDate: at(time.October, 18, 12, 0),
+			expectedMatches: []string{"noon"},
+		},
+		{
+			name:            "Weekday is the next one",
+			input:           "Send the report on friday 17:00",
+			expectedTitle:   "Send the report",
+			expectedDueDate: at(time.October, 23, 17, 0),
+			expectedMatches: []string{"on friday", "17:00"},
+		},
+		{
+			name:            "Next weekday",
+			input:           "Dentist next sunday",
+			expectedTitle:   "Dentist",
+			expectedDueDate: at(time.October, 25, 23, 59),
+			expectedMatches: []string{"next sunday"},
+		},
+		{
+			name:            "Relative date",
+			input:           "Renew the passport in 2 weeks",
+			expectedTitle:   "Renew the passport",
+			expectedDueDate: at(time.November, 1, 23, 59),
+			expectedMatches: []string{"in 2 weeks"},
+		},
+		{
+			name:            "Month and day that has passed this year",
+			input:           "Birthday gift oct 1st",
+			expectedTitle:   "Birthday gift",
+			expectedDueDate: func() *time.Time { d := time.Date(2027, time.October, 1, 23, 59, 0, 0, time.UTC); return &d }(),
+			expectedMatches: []string{"oct 1st"},
+		},
+		{
+			name:            "Day of month",
+			input:           "Flight 2nd of november at 6am",
+			expectedTitle:   "Flight",
+			expectedDueDate: at(time.November, 2, 6, 0),
+			expectedMatches: []string{"2nd of november", "at 6am"},
+		},
+		{
+			name:            "ISO date",
+			input:           "Tax return by 2026-12-31",
+			expectedTitle:   "Tax return",
+			expectedDueDate: at(time.December, 31, 23, 59),
+			expectedMatches: []string{"by 2026-12-31"},
+		},
+		{
+			name:               "Every day with a time that has passed starts tomorrow",
+			input:              "Stretch every day at 7am",
+			expectedTitle:      "Stretch",
+			expectedDueDate:    at(time.October, 19, 7, 0),
+			expectedRecurrence: "FREQ=DAILY",
+			expectedMatches:    []string{"every day", "at 7am"},
+		},
+		{
+			name:               "Every other week",
+			input:              "Clean the fridge every other week",
+			expectedTitle:      "Clean the fridge",
+			expectedDueDate:    at(time.October, 18, 23, 59),
+			expectedRecurrence: "FREQ=WEEKLY;INTERVAL=2",
+			expectedMatches:    []string{"every other week"},
+		},
+		{
+			name:               "Every few months",
+			input:              "Change the filter every 3 months",
+			expectedTitle:      "Change the filter",
+			expectedDueDate:    at(time.October, 18, 23, 59),
+			expectedRecurrence: "FREQ=MONTHLY;INTERVAL=3",
+			expectedMatches:    []string{"every 3 months"},
+		},
+		{
+			name:               "Weekdays",
+			input:              "Standup every weekday at 9:15",
+			expectedTitle:      "Standup",
+			expectedDueDate:    at(time.October, 19, 9, 15),
+			expectedRecurrence: "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR",
+			expectedMatches:    []string{"every weekday", "at 9:15"},
+		},
+		{
+			name:               "List of weekdays",
+			input:              "Gym every tue, thu and sat 6pm",
+			expectedTitle:      "Gym",
+			expectedDueDate:    at(time.October, 20, 18, 0),
+			expectedRecurrence: "FREQ=WEEKLY;BYDAY=TU,TH,SA",
+			expectedMatches:    []string{"every tue, thu and sat", "6pm"},
+		},
+		{
+			name:               "Ordinal weekday of the month",
+			input:              "Book club every last friday of the month",
+			expectedTitle:      "Book club",
+			expectedDueDate:    at(time.October, 30, 23, 59),
+			expectedRecurrence: "FREQ=MONTHLY;BYDAY=-1FR",
+			expectedMatches:    []string{"every last friday of the month"},
+		},
+		{
+			name:               "Every month on a day",
+			input:              "Invoice every month on the 15th",
+			expectedTitle:      "Invoice",
+			expectedDueDate:    at(time.November, 15, 23, 59),
+			expectedRecurrence: "FREQ=MONTHLY",
+			expectedMatches:    []string{"every month on the 15th"},
+		},
+		{
+			name:               "Every year on a date",
+			input:              "Anniversary every dec 3rd",
+			expectedTitle:      "Anniversary",
+			expectedDueDate:    at(time.December, 3, 23, 59),
+			expectedRecurrence: "FREQ=YEARLY",
+			expectedMatches:    []string{"every dec 3rd"},
+		},
+		{
+			name:               "Recurrence with a start date",
+			input:              "Review weekly from nov 2",
+			expectedTitle:      "Review from",
+			expectedDueDate:    at(time.November, 2, 23, 59),
+			expectedRecurrence: "FREQ=WEEKLY",
+			expectedMatches:    []string{"weekly", "nov 2"},
+		},
+		{
+			name:             "Project, tags and the first priority",
+			input:            "Fix the sink @home #plumbing #urgent-ish !urgent !low",
+			expectedTitle:    "Fix the sink !low",
+			expectedTags:     []string{"plumbing", "urgent-ish"},
+			expectedPriority: &urgent,
+			expectedProject:  &home,
+			expectedMatches:  []string{"@home", "#plumbing", "#urgent-ish", "!urgent"},
+		},
+		{
+			name:            "Mention that isn't a project is kept",
+			input:           "Ping @john about the invoice @home",
+			expectedTitle:   "Ping @john about the invoice",
+			expectedProject: &home,
+			expectedMatches: []string{"@home"},
+		},
+		{
+			name:            "Words that only look like dates and tags",
+			input:           "Meet at the park, #1 fan of may and sat wed email me@example.com 15",
+			expectedTitle:   "Meet at the park, #1 fan of may and sat wed email me@example.com 15",
+			expectedMatches: []string{},
+		},
+		{
+			name:            "Invalid date and time are kept",
+			input:           "Plan feb 30 at 25:00 !someday",
+			expectedTitle:   "Plan feb 30 at 25:00 !someday",
+			expectedMatches: []string{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := ParseQuickAdd(tc.input, now, isProject)
+
+			assert.Equal(t, tc.expectedTitle, result.Title)
+			assert.Equal(t, tc.expectedDueDate, result.DueDate)
+			if tc.expectedRecurrence == "" {
+				assert.Nil(t, result.Recurrence)
+			} else if assert.NotNil(t, result.Recurrence) {
+				assert.Equal(t, tc.expectedRecurrence, *result.Recurrence)
+				// the rules need to be valid for the todo to be created
+				_, err := ParseRecurrence(*result.Recurrence)
+				assert.Nil(t, err)
+			}
+			assert.Equal(t, tc.expectedTags, result.Tags)
+			assert.Equal(t, tc.expectedPriority, result.Priority)
+			assert.Equal(t, tc.expectedProject, result.Project)
+			assert.Equal(t, tc.expectedMatches, result.Matches)
+		})
+	}
+}
+
+func TestParseQuickAddTimezone(t *testing.T) {
+	location, _ := time.LoadLocation("Europe/Istanbul")
+	// it is already the 19th in Istanbul
+	now := time.Date(2026, 10, 18, 22, 30, 0, 0, time.UTC).In(location)
+
+	result := ParseQuickAdd("Call the bank tomorrow at 9am", now, func(name string) bool { return false })
+
+	assert.Equal(t, "Call the bank", result.Title)
+	assert.Equal(t, time.Date(2026, 10, 20, 9, 0, 0, 0, location), *result.DueDate)
+}
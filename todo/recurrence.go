@@ -0,0 +1,335 @@
+package todo
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Recurrence is the supported subset of RFC 5545 recurrence rules.
+// FREQ (DAILY, WEEKLY, MONTHLY and YEARLY), INTERVAL, BYDAY, COUNT and UNTIL are supported
+type Recurrence struct {
+	Freq     string
+	Interval int
+	ByDay    []WeekdayNum
+	Count    *int
+	Until    *time.Time
+}
+
+// WeekdayNum is a BYDAY value like MO or -1FR. Ordinal is 0 when every matching weekday is meant
+type WeekdayNum struct {
+	Ordinal int
+	Weekday time.Weekday
+}
+
+const (
+	FreqDaily   = "DAILY"
+	FreqWeekly  = "WEEKLY"
+	FreqMonthly = "MONTHLY"
+	FreqYearly  = "YEARLY"
+)
+
+var weekdayCodes = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// untilFormats are the DATE-TIME and DATE formats accepted for UNTIL
+var untilFormats = []string{"20060102T150405Z", "20060102T150405", "20060102"}
+
+// maxRecurrenceSteps limits the search for the next occurrence of rules that can't match any date
+const maxRecurrenceSteps = 1000
+
+// ParseRecurrence parses a rule like "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE". "RRULE:" prefix is optional
+func ParseRecurrence(rule string) (*Recurrence, error) {
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+	recurrence := &Recurrence{Interval: 1}
+
+	for _, part := range strings.Split(rule, ";") {
+		key, value, found := strings.Cut(part, "=")
+		if !found || value == "" {
+			return nil, ErrRecurrenceNotValid.With(part)
+		}
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			freq := strings.ToUpper(value)
+			if freq != FreqDaily && freq != FreqWeekly && freq != FreqMonthly && freq != FreqYearly {
+				return nil, ErrRecurrenceNotValid.With("FREQ should be one of DAILY, WEEKLY, MONTHLY and YEARLY")
+			}
+			recurrence.Freq = freq
+		case "INTERVAL":
+			interval, err := strconv.Atoi(value)
+			if err != nil || interval < 1 {
+				return nil, ErrRecurrenceNotValid.With("INTERVAL should be a positive number")
+			}
+			recurrence.Interval = interval
+		case "COUNT":
+			count, err := strconv.Atoi(value)
+			if err != nil || count < 1 {
+				return nil, ErrRecurrenceNotValid.With("COUNT should be a positive number")
+			}
+			recurrence.Count = &count
+		case "UNTIL":
+			until, err := parseUntil(value)
+			if err != nil {
+				return nil, err
+			}
+			recurrence.Until = &until
+		case "BYDAY":
+			byDay, err := parseByDay(value)
+			if err != nil {
+				return nil, err
+			}
+			recurrence.ByDay = byDay
+		default:
+			return nil, ErrRecurrenceNotValid.With(fmt.Sprintf("%s is not supported", key))
+		}
+	}
+
+	if recurrence.Freq == "" {
+		return nil, ErrRecurrenceNotValid.With("FREQ is required")
+	}
+
+	if recurrence.Count != nil && recurrence.Until != nil {
+		return nil, ErrRecurrenceNotValid.With("COUNT and UNTIL can't be used together")
+	}
+
+	if recurrence.Freq == FreqYearly && len(recurrence.ByDay) > 0 {
+		return nil, ErrRecurrenceNotValid.With("BYDAY is not supported with YEARLY")
+	}
+
+	for _, day := range recurrence.ByDay {
+		if day.Ordinal != 0 && recurrence.Freq != FreqMonthly {
+			return nil, ErrRecurrenceNotValid.With("BYDAY with a number is only supported with MONTHLY")
+		}
+	}
+
+	return recurrence, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	for _, format := range untilFormats {
+		if until, err := time.Parse(format, value); err == nil {
+			// a date means the whole day is included
+			if format == "20060102" {
+				until = until.Add(24*time.Hour - time.Nanosecond)
+			}
+			return until, nil
+		}
+	}
+	return time.Time{}, ErrRecurrenceNotValid.With("UNTIL should be a date like 20261231 or 20261231T090000Z")
+}
+
+func parseByDay(value string) ([]WeekdayNum, error) {
+	var byDay []WeekdayNum
+	for _, day := range strings.Split(strings.ToUpper(value), ",") {
+		if len(day) < 2 {
+			return nil, ErrRecurrenceNotValid.With("BYDAY is not valid: " + day)
+		}
+
+		weekday, ok := weekdayCodes[day[len(day)-2:]]
+		if !ok {
+			return nil, ErrRecurrenceNotValid.With("BYDAY is not valid: " + day)
+		}
+
+		ordinal := 0
+		if prefix := day[:len(day)-2]; prefix != "" {
+			parsed, err := strconv.Atoi(prefix)
+			if err != nil || parsed == 0 || parsed < -5 || parsed > 5 {
+				return nil, ErrRecurrenceNotValid.With("BYDAY is not valid: " + day)
+			}
+			ordinal = parsed
+		}
+		byDay = append(byDay, WeekdayNum{Ordinal: ordinal, Weekday: weekday})
+	}
+	return byDay, nil
+}
+
+// String returns the rule in its canonical form
+func (r *Recurrence) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+
+	if r.Interval > 1 {
+		parts = append(parts, fmt.Sprintf("INTERVAL=%d", r.Interval))
+	}
+
+	if len(r.ByDay) > 0 {
+		var days []string
+		for _, day := range r.ByDay {
+			days = append(days, day.String())
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+
+	if r.Count != nil {
+		parts = append(parts, fmt.Sprintf("COUNT=%d", *r.Count))
+	}
+
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(untilFormats[0]))
+	}
+
+	return strings.Join(parts, ";")
+}
+
+func (d WeekdayNum) String() string {
+	for code, weekday := range weekdayCodes {
+		if weekday == d.Weekday {
+			if d.Ordinal != 0 {
+				return strconv.Itoa(d.Ordinal) + code
+			}
+			return code
+		}
+	}
+	return ""
+}
+
+// Next returns the occurrence after current. The time of the day and the location of current are kept.
+// The second return value is false if the rule doesn't have any occurrence after current
+func (r *Recurrence) Next(current time.Time) (time.Time, bool) {
+	var next time.Time
+	var ok bool
+
+	switch r.Freq {
+	case FreqDaily:
+		next, ok = r.nextDaily(current)
+	case FreqWeekly:
+		next, ok = r.nextWeekly(current)
+	case FreqMonthly:
+		next, ok = r.nextMonthly(current)
+	case FreqYearly:
+		next, ok = r.nextYearly(current)
+	}
+
+	if !ok || (r.Until != nil && next.After(*r.Until)) {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+// matchesWeekday checks if the weekday is one of the BYDAY weekdays. It is used for the rules without ordinals
+func (r *Recurrence) matchesWeekday(weekday time.Weekday) bool {
+	for _, day := range r.ByDay {
+		if day.Weekday == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Recurrence) nextDaily(current time.Time) (time.Time, bool) {
+	for step := 1; step <= maxRecurrenceSteps; step++ {
+		next := current.AddDate(0, 0, step*r.Interval)
+		if len(r.ByDay) == 0 || r.matchesWeekday(next.Weekday()) {
+			return next, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (r *Recurrence) nextWeekly(current time.Time) (time.Time, bool) {
+	if len(r.ByDay) == 0 {
+		return current.AddDate(0, 0, 7*r.Interval), true
+	}
+
+	// weeks start on Monday as the default WKST of RFC 5545
+	daysSinceMonday := (int(current.Weekday()) + 6) % 7
+
+	// the rest of the current week
+	for day := daysSinceMonday + 1; day < 7; day++ {
+		next := current.AddDate(0, 0, day-daysSinceMonday)
+		if r.matchesWeekday(next.Weekday()) {
+			return next, true
+		}
+	}
+
+	// the first matching day of the next week of the interval
+	weekStart := current.AddDate(0, 0, 7*r.Interval-daysSinceMonday)
+	for day := 0; day < 7; day++ {
+		next := weekStart.AddDate(0, 0, day)
+		if r.matchesWeekday(next.Weekday()) {
+			return next, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (r *Recurrence) nextMonthly(current time.Time) (time.Time, bool) {
+	year, month, _ := current.Date()
+
+	for step := 0; step <= maxRecurrenceSteps; step++ {
+		// first day of the month of this step, normalized by time.Date
+		monthStart := time.Date(year, month+time.Month(step*r.Interval), 1, 0, 0, 0, 0, current.Location())
+
+		for _, day := range r.monthlyDays(current, monthStart) {
+			next := withDay(current, monthStart.Year(), monthStart.Month(), day)
+			if next.After(current) {
+				return next, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// monthlyDays returns the sorted days of the month that match the rule
+func (r *Recurrence) monthlyDays(current time.Time, monthStart time.Time) []int {
+	daysInMonth := monthStart.AddDate(0, 1, -1).Day()
+
+	// without BYDAY the day of the month is kept. Months without that day are skipped
+	if len(r.ByDay) == 0 {
+		if current.Day() > daysInMonth {
+			return nil
+		}
+		return []int{current.Day()}
+	}
+
+	var days []int
+	firstWeekday := monthStart.Weekday()
+	for _, byDay := range r.ByDay {
+		// the first day of the month with the weekday
+		first := 1 + (int(byDay.Weekday)-int(firstWeekday)+7)%7
+
+		var candidates []int
+		for day := first; day <= daysInMonth; day += 7 {
+			candidates = append(candidates, day)
+		}
+
+		switch {
+		case byDay.Ordinal == 0:
+			days = append(days, candidates...)
+		case byDay.Ordinal > 0 && byDay.Ordinal <= len(candidates):
+			days = append(days, candidates[byDay.Ordinal-1])
+		case byDay.Ordinal < 0 && -byDay.Ordinal <= len(candidates):
+			days = append(days, candidates[len(candidates)+byDay.Ordinal])
+		}
+	}
+
+	sort.Ints(days)
+	return days
+}
+
+func (r *Recurrence) nextYearly(current time.Time) (time.Time, bool) {
+	for step := 1; step <= maxRecurrenceSteps; step++ {
+		year := current.Year() + step*r.Interval
+		monthStart := time.Date(year, current.Month(), 1, 0, 0, 0, 0, current.Location())
+
+		// February 29 is skipped in the years that don't have it
+		if current.Day() <= monthStart.AddDate(0, 1, -1).Day() {
+			return withDay(current, year, current.Month(), current.Day()), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// withDay returns the given date with the time of the day and the location of t
+func withDay(t time.Time, year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
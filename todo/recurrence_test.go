@@ -0,0 +1,196 @@
+package todo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	tests := []struct {
+		name          string
+		rule          string
+		expectedRule  string
+		expectedError error
+	}{
+		{
+			name:         "Daily",
+			rule:         "FREQ=DAILY",
+			expectedRule: "FREQ=DAILY",
+		},
+		{
+			name:         "RRULE prefix and lowercase values",
+			rule:         "RRULE:FREQ=weekly;INTERVAL=2;BYDAY=mo,we",
+			expectedRule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE",
+		},
+		{
+			name:         "Monthly with ordinal weekday and count",
+			rule:         "FREQ=MONTHLY;BYDAY=-1FR;COUNT=3",
+			expectedRule: "FREQ=MONTHLY;BYDAY=-1FR;COUNT=3",
+		},
+		{
+			name:         "Until as a date",
+			rule:         "FREQ=YEARLY;UNTIL=20301231",
+			expectedRule: "FREQ=YEARLY;UNTIL=20301231T235959Z",
+		},
+		{
+			name:          "FREQ is missing",
+			rule:          "INTERVAL=2",
+			expectedError: ErrRecurrenceNotValid,
+		},
+		{
+			name:          "FREQ is not supported",
+			rule:          "FREQ=HOURLY",
+			expectedError: ErrRecurrenceNotValid,
+		},
+		{
+			name:          "Unsupported part",
+			rule:          "FREQ=DAILY;BYHOUR=9",
+			expectedError: ErrRecurrenceNotValid,
+		},
+		{
+			name:          "Interval is not positive",
+			rule:          "FREQ=DAILY;INTERVAL=0",
+			expectedError: ErrRecurrenceNotValid,
+		},
+		{
+			name:          "COUNT and UNTIL together",
+			rule:          "FREQ=DAILY;COUNT=2;UNTIL=20301231",
+			expectedError: ErrRecurrenceNotValid,
+		},
+		{
+			name:          "Ordinal weekday with WEEKLY",
+			rule:          "FREQ=WEEKLY;BYDAY=1MO",
+			expectedError: ErrRecurrenceNotValid,
+		},
+		{
+			name:          "Weekday is not valid",
+			rule:          "FREQ=WEEKLY;BYDAY=XX",
+			expectedError: ErrRecurrenceNotValid,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			recurrence, err := ParseRecurrence(tc.rule)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedRule, recurrence.String())
+		})
+	}
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	istanbul, _ := time.LoadLocation("Europe/Istanbul")
+	// Monday
+	monday := time.Date(2026, time.November, 2, 9, 30, 0, 0, istanbul)
+
+	tests := []struct {
+		name         string
+		rule         string
+		current      time.Time
+		expectedNext time.Time
+		expectedOk   bool
+	}{
+		{
+			name:         "Every day",
+			rule:         "FREQ=DAILY",
+			current:      monday,
+			expectedNext: time.Date(2026, time.November, 3, 9, 30, 0, 0, istanbul),
+			expectedOk:   true,
+		},
+		{
+			name:         "Every weekday skips the weekend",
+			rule:         "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR",
+			current:      time.Date(2026, time.November, 6, 9, 30, 0, 0, istanbul),
+			expectedNext: time.Date(2026, time.November, 9, 9, 30, 0, 0, istanbul),
+			expectedOk:   true,
+		},
+		{
+			name:         "Every week",
+			rule:         "FREQ=WEEKLY",
+			current:      monday,
+			expectedNext: time.Date(2026, time.November, 9, 9, 30, 0, 0, istanbul),
+			expectedOk:   true,
+		},
+		{
+			name:         "Next day in the same week",
+			rule:         "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH",
+			current:      monday,
+			expectedNext: time.Date(2026, time.November, 5, 9, 30, 0, 0, istanbul),
+			expectedOk:   true,
+		},
+		{
+			name:         "First day of the week after the interval",
+			rule:         "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH",
+			current:      time.Date(2026, time.November, 5, 9, 30, 0, 0, istanbul),
+			expectedNext: time.Date(2026, time.November, 16, 9, 30, 0, 0, istanbul),
+			expectedOk:   true,
+		},
+		{
+			name:         "Same day of the next month",
+			rule:         "FREQ=MONTHLY",
+			current:      time.Date(2026, time.November, 15, 9, 0, 0, 0, time.UTC),
+			expectedNext: time.Date(2026, time.December, 15, 9, 0, 0, 0, time.UTC),
+			expectedOk:   true,
+		},
+		{
+			name:         "Months without the day are skipped",
+			rule:         "FREQ=MONTHLY",
+			current:      time.Date(2027, time.January, 31, 9, 0, 0, 0, time.UTC),
+			expectedNext: time.Date(2027, time.March, 31, 9, 0, 0, 0, time.UTC),
+			expectedOk:   true,
+		},
+		{
+			name:         "Last Friday of the month",
+			rule:         "FREQ=MONTHLY;BYDAY=-1FR",
+			current:      time.Date(2026, time.November, 27, 9, 0, 0, 0, time.UTC),
+			expectedNext: time.Date(2026, time.December, 25, 9, 0, 0, 0, time.UTC),
+			expectedOk:   true,
+		},
+		{
+			name:         "First Monday of the month later in the same month",
+			rule:         "FREQ=MONTHLY;BYDAY=1MO",
+			current:      time.Date(2026, time.November, 1, 9, 0, 0, 0, time.UTC),
+			expectedNext: time.Date(2026, time.November, 2, 9, 0, 0, 0, time.UTC),
+			expectedOk:   true,
+		},
+		{
+			name:         "Every year",
+			rule:         "FREQ=YEARLY",
+			current:      time.Date(2026, time.November, 2, 9, 0, 0, 0, time.UTC),
+			expectedNext: time.Date(2027, time.November, 2, 9, 0, 0, 0, time.UTC),
+			expectedOk:   true,
+		},
+		{
+			name:         "February 29 every year",
+			rule:         "FREQ=YEARLY",
+			current:      time.Date(2028, time.February, 29, 9, 0, 0, 0, time.UTC),
+			expectedNext: time.Date(2032, time.February, 29, 9, 0, 0, 0, time.UTC),
+			expectedOk:   true,
+		},
+		{
+			name:       "Next occurrence is after UNTIL",
+			rule:       "FREQ=WEEKLY;UNTIL=20261105",
+			current:    monday,
+			expectedOk: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			recurrence, err := ParseRecurrence(tc.rule)
+			assert.Nil(t, err)
+
+			next, ok := recurrence.Next(tc.current)
+			assert.Equal(t, tc.expectedOk, ok)
+			if tc.expectedOk {
+				assert.True(t, tc.expectedNext.Equal(next), "expected %s, got %s", tc.expectedNext, next)
+				assert.Equal(t, tc.current.Location(), next.Location())
+			}
+		})
+	}
+}
@@ -5,28 +5,60 @@ import (
 	"errors"
 	"fmt"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/umtdemr/go-todo/project"
+	"github.com/umtdemr/go-todo/share"
 	"strings"
 	"time"
 )
 
 type IRepository interface {
 	CreateTodo(data *Todo, userId int64) (*Todo, error)
-	GetAllTodos(userId int64) ([]Todo, error)
+	GetAllTodos(userId int64, options *ListOptions) ([]Todo, error)
 	GetTodo(todoId int, userId int64) (*Todo, error)
 	UpdateTodo(data *UpdateTodoData, userId int64) (*Todo, error)
+	UpdateOccurrence(update *OccurrenceUpdate, userId int64) (*Todo, error)
 	RemoveTodo(todoId int, userId int64) (*Todo, error)
+	DeleteTodo(todoId int, userId int64) (*Todo, error)
+	GetTrash(userId int64) ([]Todo, error)
+	RestoreTodo(todoId int, userId int64) (*Todo, error)
+	PurgeTrash(before time.Time) (int64, error)
+	ArchiveCompleted(userId int64) (int64, error)
+	ArchiveCompletedBefore(before time.Time) (int64, error)
+	ReorderTodo(data *ReorderTodoData, userId int64) (*Todo, error)
+	GetDescendants(todoId int, userId int64) ([]Todo, error)
+	GetAncestorIds(todoId int, userId int64) ([]int, error)
+	GetBlockerIds(todoIds []int) ([]int, error)
+	GetDependencies(todoIds []int) ([]Dependency, error)
+	SumEstimates(userId int64, options *ListOptions) ([]project.EstimateSum, error)
+	GetBurndown(projectId int, options *BurndownOptions) ([]BurndownDay, error)
+	CreateView(data *CreateViewData, userId int64) (*View, error)
+	GetAllViews(userId int64) ([]View, error)
+	GetView(viewId int, userId int64) (*View, error)
+	UpdateView(data *UpdateViewData, userId int64) (*View, error)
+	RemoveView(viewId int, userId int64) (*View, error)
+	CreateTodoTree(todos []Todo, userId int64) ([]Todo, error)
+	CreateTemplate(data *CreateTemplateData, userId int64) (*Template, error)
+	GetAllTemplates(userId int64) ([]Template, error)
+	GetTemplate(templateId int, userId int64) (*Template, error)
+	UpdateTemplate(data *UpdateTemplateData, userId int64) (*Template, error)
+	RemoveTemplate(templateId int, userId int64) (*Template, error)
 }
 
 type Repository struct {
-	DB *pgx.Conn
+	DB *pgxpool.Pool
 }
 
-func NewTodoRepository(dbConn *pgx.Conn) (*Repository, error) {
+func NewTodoRepository(dbConn *pgxpool.Pool) (*Repository, error) {
 	return &Repository{dbConn}, nil
 }
 
 func (store *Repository) Init() error {
-	return store.CreateTodoTable()
+	if err := store.CreateTodoTable(); err != nil {
+		return err
+	}
+	return store.MigrateTodoTable()
 }
 
 func (store *Repository) CreateTodoTable() error {
@@ -43,25 +75,619 @@ func (store *Repository) CreateTodoTable() error {
 	return err
 }
 
-func (store *Repository) CreateTodo(data *Todo, userId int64) (*Todo, error) {
-	query := `INSERT INTO "todo"(title, user_id) VALUES (@title, @userId) RETURNING id, title, done, created_at, updated_at`
+// todoMigrations are applied in order after the todo table is created.
+// They need to be idempotent since they run on every start
+var todoMigrations = []string{
+	`ALTER TABLE "todo" ADD COLUMN IF NOT EXISTS due_date timestamptz`,
+	`ALTER TABLE "todo" ADD COLUMN IF NOT EXISTS due_timezone varchar(64)`,
+	`ALTER TABLE "todo" ADD COLUMN IF NOT EXISTS reminder_offset integer`,
+	`CREATE INDEX IF NOT EXISTS todo_user_id_due_date_idx ON "todo" (user_id, due_date)`,
+	`ALTER TABLE "todo" ADD COLUMN IF NOT EXISTS priority smallint NOT NULL DEFAULT 0`,
+	`ALTER TABLE "todo" ADD COLUMN IF NOT EXISTS position double precision`,
+	`UPDATE "todo" SET position = ranked.rank * 1024
+		FROM (SELECT id, row_number() OVER (PARTITION BY user_id ORDER BY id) AS rank FROM "todo" WHERE position IS NULL) ranked
+		WHERE "todo".id = ranked.id`,
+	`ALTER TABLE "todo" ALTER COLUMN position SET NOT NULL`,
+	`CREATE INDEX IF NOT EXISTS todo_user_id_position_idx ON "todo" (user_id, position)`,
+	`ALTER TABLE "todo" ADD COLUMN IF NOT EXISTS project_id integer REFERENCES "project"(id) ON DELETE SET NULL`,
+	`CREATE INDEX IF NOT EXISTS todo_project_id_idx ON "todo" (project_id)`,
+	`CREATE TABLE IF NOT EXISTS "todo_tag" (
+		todo_id integer NOT NULL REFERENCES "todo"(id) ON DELETE CASCADE,
+		tag_id integer NOT NULL REFERENCES "tag"(id) ON DELETE CASCADE,
+		PRIMARY KEY (todo_id, tag_id)
+	)`,
+	`CREATE INDEX IF NOT EXISTS todo_tag_tag_id_idx ON "todo_tag" (tag_id)`,
+	`ALTER TABLE "todo" ADD COLUMN IF NOT EXISTS parent_id integer REFERENCES "todo"(id) ON DELETE CASCADE`,
+	`ALTER TABLE "todo" ADD COLUMN IF NOT EXISTS auto_complete boolean NOT NULL DEFAULT false`,
+	`CREATE INDEX IF NOT EXISTS todo_parent_id_idx ON "todo" (parent_id)`,
+	`ALTER TABLE "todo" ADD COLUMN IF NOT EXISTS recurrence varchar(255)`,
+	`ALTER TABLE "todo" ADD COLUMN IF NOT EXISTS series_id integer REFERENCES "todo"(id) ON DELETE SET NULL`,
+	`ALTER TABLE "todo" ADD COLUMN IF NOT EXISTS occurrence integer NOT NULL DEFAULT 1`,
+	`CREATE INDEX IF NOT EXISTS todo_series_id_idx ON "todo" (series_id)`,
+	`ALTER TABLE "todo" ADD COLUMN IF NOT EXISTS deleted_at timestamptz`,
+	`CREATE INDEX IF NOT EXISTS todo_deleted_at_idx ON "todo" (deleted_at) WHERE deleted_at IS NOT NULL`,
+	`ALTER TABLE "todo" ADD COLUMN IF NOT EXISTS archived boolean NOT NULL DEFAULT false`,
+	`ALTER TABLE "todo" ADD COLUMN IF NOT EXISTS completed_at timestamptz`,
+	`UPDATE "todo" SET completed_at = updated_at WHERE done AND completed_at IS NULL`,
+	`CREATE INDEX IF NOT EXISTS todo_user_id_archived_idx ON "todo" (user_id, archived)`,
+	`ALTER TABLE "todo" ADD COLUMN IF NOT EXISTS search_vector tsvector`,
+	`CREATE OR REPLACE FUNCTION todo_search_vector_update() RETURNS trigger AS $$
+	BEGIN
+		NEW.search_vector := to_tsvector('simple', COALESCE(NEW.title, ''));
+		RETURN NEW;
+	END
+	$$ LANGUAGE plpgsql`,
+	`DROP TRIGGER IF EXISTS todo_search_vector_trigger ON "todo"`,
+	`CREATE TRIGGER todo_search_vector_trigger BEFORE INSERT OR UPDATE OF title ON "todo"
+		FOR EACH ROW EXECUTE FUNCTION todo_search_vector_update()`,
+	`UPDATE "todo" SET search_vector = to_tsvector('simple', COALESCE(title, '')) WHERE search_vector IS NULL`,
+	`CREATE INDEX IF NOT EXISTS todo_search_vector_idx ON "todo" USING gin (search_vector)`,
+	`ALTER TABLE "todo" ADD COLUMN IF NOT EXISTS notes text`,
+	`CREATE TABLE IF NOT EXISTS "todo_view" (
+		id serial PRIMARY KEY,
+		user_id integer NOT NULL REFERENCES "user"(id) ON DELETE CASCADE,
+		name varchar(100) NOT NULL,
+		criteria jsonb NOT NULL DEFAULT '{}',
+		created_at timestamp DEFAULT now(),
+		updated_at timestamp DEFAULT now(),
+		UNIQUE (user_id, name)
+	)`,
+	`ALTER TABLE "todo" ADD COLUMN IF NOT EXISTS assignee_id integer REFERENCES "user"(id) ON DELETE SET NULL`,
+	`CREATE INDEX IF NOT EXISTS todo_assignee_id_idx ON "todo" (assignee_id)`,
+	`ALTER TABLE "todo" ADD COLUMN IF NOT EXISTS workspace_id integer REFERENCES "workspace"(id)`,
+	`CREATE INDEX IF NOT EXISTS todo_workspace_id_idx ON "todo" (workspace_id)`,
+	`CREATE TABLE IF NOT EXISTS "todo_dependency" (
+		todo_id integer NOT NULL REFERENCES "todo"(id) ON DELETE CASCADE,
+		blocked_by_id integer NOT NULL REFERENCES "todo"(id) ON DELETE CASCADE,
+		created_at timestamp DEFAULT now(),
+		PRIMARY KEY (todo_id, blocked_by_id),
+		CHECK (todo_id <> blocked_by_id)
+	)`,
+	`CREATE INDEX IF NOT EXISTS todo_dependency_blocked_by_id_idx ON "todo_dependency" (blocked_by_id)`,
+	// the existing todos are put in the first or the last status of the default workflow
+	`ALTER TABLE "todo" ADD COLUMN IF NOT EXISTS status varchar(50)`,
+	`UPDATE "todo" SET status = CASE WHEN done THEN 'done' ELSE 'backlog' END WHERE status IS NULL`,
+	`ALTER TABLE "todo" ALTER COLUMN status SET NOT NULL`,
+	`CREATE INDEX IF NOT EXISTS todo_project_id_status_idx ON "todo" (project_id, status)`,
+	`ALTER TABLE "todo" ADD COLUMN IF NOT EXISTS estimate integer`,
+	// todo_change keeps the state of the todos after each change of the fields that burndowns are built from
+	`CREATE TABLE IF NOT EXISTS "todo_change" (
+		id bigserial PRIMARY KEY,
+		todo_id integer NOT NULL REFERENCES "todo"(id) ON DELETE CASCADE,
+		project_id integer,
+		estimate integer,
+		done boolean NOT NULL,
+		deleted boolean NOT NULL,
+		changed_at timestamptz NOT NULL DEFAULT now()
+	)`,
+	`CREATE INDEX IF NOT EXISTS todo_change_todo_id_idx ON "todo_change" (todo_id, changed_at)`,
+	`CREATE INDEX IF NOT EXISTS todo_change_project_id_idx ON "todo_change" (project_id)`,
+	// the existing todos get their history from the times they were created, completed and moved to the trash
+	`INSERT INTO "todo_change"(todo_id, project_id, estimate, done, deleted, changed_at)
+		SELECT t.id, t.project_id, t.estimate, c.done, c.deleted, c.changed_at
+		FROM "todo" t CROSS JOIN LATERAL (VALUES
+			(t.created_at::timestamptz, false, false),
+			(t.completed_at, true, false),
+			(t.deleted_at, t.done, true)
+		) AS c(changed_at, done, deleted)
+		WHERE c.changed_at IS NOT NULL AND NOT EXISTS (SELECT 1 FROM "todo_change" WHERE todo_id = t.id)`,
+	`CREATE OR REPLACE FUNCTION todo_change_record() RETURNS trigger AS $$
+	BEGIN
+		IF TG_OP = 'UPDATE' AND (OLD.project_id, OLD.estimate, OLD.done, OLD.deleted_at IS NULL)
+			IS NOT DISTINCT FROM (NEW.project_id, NEW.estimate, NEW.done, NEW.deleted_at IS NULL) THEN
+			RETURN NULL;
+		END IF;
+		INSERT INTO "todo_change"(todo_id, project_id, estimate, done, deleted)
+			VALUES (NEW.id, NEW.project_id, NEW.estimate, NEW.done, NEW.deleted_at IS NOT NULL);
+		RETURN NULL;
+	END
+	$$ LANGUAGE plpgsql`,
+	`DROP TRIGGER IF EXISTS todo_change_trigger ON "todo"`,
+	`CREATE TRIGGER todo_change_trigger AFTER INSERT OR UPDATE OF project_id, estimate, done, deleted_at ON "todo"
+		FOR EACH ROW EXECUTE FUNCTION todo_change_record()`,
+	`CREATE TABLE IF NOT EXISTS "todo_template" (
+		id serial PRIMARY KEY,
+		user_id integer NOT NULL REFERENCES "user"(id) ON DELETE CASCADE,
+		name varchar(100) NOT NULL,
+		items jsonb NOT NULL DEFAULT '[]',
+		created_at timestamp DEFAULT now(),
+		updated_at timestamp DEFAULT now(),
+		UNIQUE (user_id, name)
+	)`,
+	// the notes are searched too. Titles are weighted higher than the notes so that they rank first
+	`CREATE OR REPLACE FUNCTION todo_search_vector_update() RETURNS trigger AS $$
+	BEGIN
+		NEW.search_vector := setweight(to_tsvector('simple', COALESCE(NEW.title, '')), 'A') ||
+			setweight(to_tsvector('simple', COALESCE(NEW.notes, '')), 'B');
+		RETURN NEW;
+	END
+	$$ LANGUAGE plpgsql`,
+	`DROP TRIGGER IF EXISTS todo_search_vector_trigger ON "todo"`,
+	`CREATE TRIGGER todo_search_vector_trigger BEFORE INSERT OR UPDATE OF title, notes ON "todo"
+		FOR EACH ROW EXECUTE FUNCTION todo_search_vector_update()`,
+	// the vectors that were built from the titles only are recomputed, the rows that are already up to date are skipped
+	`UPDATE "todo" SET search_vector = setweight(to_tsvector('simple', COALESCE(title, '')), 'A') ||
+		setweight(to_tsvector('simple', COALESCE(notes, '')), 'B')
+		WHERE search_vector IS DISTINCT FROM setweight(to_tsvector('simple', COALESCE(title, '')), 'A') ||
+			setweight(to_tsvector('simple', COALESCE(notes, '')), 'B')`,
+	// the history is kept when a todo is deleted permanently so that the past burndowns don't change.
+	// A todo deleted without going through the trash is recorded as deleted
+	`ALTER TABLE "todo_change" DROP CONSTRAINT IF EXISTS todo_change_todo_id_fkey`,
+	`CREATE OR REPLACE FUNCTION todo_change_record() RETURNS trigger AS $$
+	BEGIN
+		IF TG_OP = 'DELETE' THEN
+			IF OLD.deleted_at IS NULL THEN
+				INSERT INTO "todo_change"(todo_id, project_id, estimate, done, deleted)
+					VALUES (OLD.id, OLD.project_id, OLD.estimate, OLD.done, true);
+			END IF;
+			RETURN NULL;
+		END IF;
+		IF TG_OP = 'UPDATE' AND (OLD.project_id, OLD.estimate, OLD.done, OLD.deleted_at IS NULL)
+			IS NOT DISTINCT FROM (NEW.project_id, NEW.estimate, NEW.done, NEW.deleted_at IS NULL) THEN
+			RETURN NULL;
+		END IF;
+		INSERT INTO "todo_change"(todo_id, project_id, estimate, done, deleted)
+			VALUES (NEW.id, NEW.project_id, NEW.estimate, NEW.done, NEW.deleted_at IS NOT NULL);
+		RETURN NULL;
+	END
+	$$ LANGUAGE plpgsql`,
+	`DROP TRIGGER IF EXISTS todo_change_trigger ON "todo"`,
+	`CREATE TRIGGER todo_change_trigger AFTER INSERT OR UPDATE OF project_id, estimate, done, deleted_at OR DELETE ON "todo"
+		FOR EACH ROW EXECUTE FUNCTION todo_change_record()`,
+}
+
+// MigrateTodoTable adds the columns and indexes that were introduced after the first version of the todo table
+func (store *Repository) MigrateTodoTable() error {
+	for _, migration := range todoMigrations {
+		if _, err := store.DB.Exec(context.Background(), migration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// querier is implemented by both pgxpool.Pool and pgx.Tx so that the helpers can be used in transactions
+type querier interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// visibleCondition matches the todos of the user and the todos shared with the user.
+// A todo is shared by sharing it, one of its parents or its project
+const visibleCondition = `(user_id = @userId OR id IN (SELECT id FROM shared_todo_ids(@userId)))`
+
+// ownedCondition matches the todos of the user, the todos in the projects of the user
+// and the todos in the workspaces that the user is an owner or an admin of
+const ownedCondition = `(user_id = @userId OR project_id IN (SELECT id FROM "project" WHERE user_id = @userId)
+	OR workspace_id IN (SELECT workspace_id FROM "workspace_member" WHERE user_id = @userId AND role IN ('owner', 'admin')))`
+
+// personalCondition matches the todos that are not in one of the workspaces of the user.
+// Todos shared from the workspaces that the user isn't a member of are listed with the personal ones
+const personalCondition = `(workspace_id IS NULL
+	OR workspace_id NOT IN (SELECT workspace_id FROM "workspace_member" WHERE user_id = @userId))`
+
+// getTodo fetches a single todo that is not in the trash with the given querier if the user can see it.
+// The role of the user on the todo is filled
+func getTodo(ctx context.Context, q querier, todoId int, userId int64) (*Todo, error) {
+	query := `SELECT ` + todoColumns + `, role FROM "todo", todo_role("todo".id, @userId) role
+		WHERE id = @todoId AND deleted_at IS NULL AND role IS NOT NULL`
 	args := pgx.NamedArgs{
-		"title":  data.Title,
+		"todoId": todoId,
 		"userId": userId,
 	}
 
-	rows := store.DB.QueryRow(context.Background(), query, args)
-	createdTodo, scanErr := ScanTodo(rows)
+	var role string
+	t, err := ScanTodo(q.QueryRow(ctx, query, args), &role)
+	if err != nil {
+		return nil, err
+	}
+	t.Role = share.Role(role)
+	return t, nil
+}
+
+// setTodoTags replaces the tags of the todo with the tags with the given names.
+// Tags belong to the owner of the todo, and the ones that the owner doesn't have yet are created
+func setTodoTags(ctx context.Context, q querier, todoId int, names []string) error {
+	args := pgx.NamedArgs{
+		"todoId": todoId,
+		"names":  names,
+	}
+
+	if _, err := q.Exec(ctx, `DELETE FROM todo_tag WHERE todo_id = @todoId`, args); err != nil {
+		return err
+	}
+
+	if len(names) == 0 {
+		return nil
+	}
+
+	createTagsQuery := `INSERT INTO "tag"(user_id, name) SELECT (SELECT user_id FROM "todo" WHERE id = @todoId), unnest(@names::text[])
+		ON CONFLICT (user_id, name) DO NOTHING`
+	if _, err := q.Exec(ctx, createTagsQuery, args); err != nil {
+		return err
+	}
+
+	linkTagsQuery := `INSERT INTO todo_tag(todo_id, tag_id)
+		SELECT @todoId, id FROM "tag" WHERE user_id = (SELECT user_id FROM "todo" WHERE id = @todoId) AND name = ANY(@names::text[])`
+	_, err := q.Exec(ctx, linkTagsQuery, args)
+	return err
+}
+
+// setTodoDependencies replaces the todos blocking the todo with the todos with the given ids
+func setTodoDependencies(ctx context.Context, q querier, todoId int, blockerIds []int) error {
+	args := pgx.NamedArgs{
+		"todoId":     todoId,
+		"blockerIds": blockerIds,
+	}
+
+	if _, err := q.Exec(ctx, `DELETE FROM todo_dependency WHERE todo_id = @todoId`, args); err != nil {
+		return err
+	}
+
+	if len(blockerIds) == 0 {
+		return nil
+	}
+
+	query := `INSERT INTO todo_dependency(todo_id, blocked_by_id) SELECT @todoId, unnest(@blockerIds::integer[])`
+	_, err := q.Exec(ctx, query, args)
+	return err
+}
+
+// insertTodo inserts the todo with its tags and its dependencies, and returns its id
+func insertTodo(ctx context.Context, q querier, data *Todo, userId int64) (int, error) {
+	// todos added to a shared parent, series or project belong to its owner and its workspace.
+	// New todos are placed at the end of the list of the owner
+	query := `WITH owner AS (
+			SELECT COALESCE(
+				(SELECT user_id FROM "todo" WHERE id = @parentId),
+				(SELECT user_id FROM "todo" WHERE id = @seriesId),
+				(SELECT user_id FROM "project" WHERE id = @projectId),
+				@userId
+			) AS id
+		), ws AS (
+			SELECT CASE
+				WHEN @parentId::integer IS NOT NULL THEN (SELECT workspace_id FROM "todo" WHERE id = @parentId)
+				WHEN @seriesId::integer IS NOT NULL THEN (SELECT workspace_id FROM "todo" WHERE id = @seriesId)
+				WHEN @projectId::integer IS NOT NULL THEN (SELECT workspace_id FROM "project" WHERE id = @projectId)
+				ELSE @workspaceId::integer
+			END AS id
+		)
+		INSERT INTO "todo"(
+			title, notes, user_id, due_date, due_timezone, reminder_offset, priority, project_id, parent_id, auto_complete,
+			recurrence, series_id, occurrence, assignee_id, workspace_id, status, done, completed_at, estimate, position
+		)
+		VALUES (
+			@title, @notes, (SELECT id FROM owner), @dueDate, @dueTimezone, @reminderOffset, @priority, @projectId, @parentId,
+			@autoComplete, @recurrence, @seriesId, @occurrence, @assigneeId, (SELECT id FROM ws),
+			@status, @done, CASE WHEN @done::boolean THEN now() END, @estimate,
+			(SELECT COALESCE(MAX(position), 0) + @positionGap FROM "todo" WHERE user_id = (SELECT id FROM owner))
+		) RETURNING id`
+	args := pgx.NamedArgs{
+		"title":          data.Title,
+		"notes":          data.Notes,
+		"userId":         userId,
+		"dueDate":        data.DueDate,
+		"dueTimezone":    data.DueTimezone,
+		"reminderOffset": data.ReminderOffset,
+		"priority":       data.Priority,
+		"projectId":      data.ProjectId,
+		"parentId":       data.ParentId,
+		"autoComplete":   data.AutoComplete,
+		"recurrence":     data.Recurrence,
+		"seriesId":       data.SeriesId,
+		"occurrence":     data.Occurrence,
+		"assigneeId":     data.AssigneeId,
+		"workspaceId":    data.WorkspaceId,
+		"status":         data.Status,
+		"done":           data.Done,
+		"estimate":       data.Estimate,
+		"positionGap":    positionGap,
+	}
+
+	var todoId int
+	if err := q.QueryRow(ctx, query, args).Scan(&todoId); err != nil {
+		return 0, err
+	}
+
+	if len(data.Tags) > 0 {
+		if err := setTodoTags(ctx, q, todoId, data.Tags); err != nil {
+			return 0, err
+		}
+	}
+
+	if len(data.BlockedBy) > 0 {
+		if err := setTodoDependencies(ctx, q, todoId, data.BlockedBy); err != nil {
+			return 0, err
+		}
+	}
+	return todoId, nil
+}
+
+func (store *Repository) CreateTodo(data *Todo, userId int64) (*Todo, error) {
+	ctx := context.Background()
+	tx, err := store.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	todoId, err := insertTodo(ctx, tx, data, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	createdTodo, scanErr := getTodo(ctx, tx, todoId, userId)
 	if scanErr != nil {
 		return nil, scanErr
 	}
 
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
 	return createdTodo, nil
 }
 
-func (store *Repository) GetAllTodos(userId int64) ([]Todo, error) {
-	query := `SELECT id, title, done, created_at, updated_at FROM "todo" WHERE user_id=@userId`
+// listConditions builds the WHERE conditions for the given list options and fills the args used by them
+func listConditions(options *ListOptions, args pgx.NamedArgs) []string {
+	conditions := []string{visibleCondition, "deleted_at IS NULL"}
+
+	if options == nil {
+		return append(conditions, "archived = false")
+	}
+
+	if options.OnlyArchived {
+		conditions = append(conditions, "archived = true")
+	} else if !options.IncludeArchived {
+		conditions = append(conditions, "archived = false")
+	}
+
+	if options.Overdue {
+		conditions = append(conditions, "done = false AND due_date < now()")
+	}
+
+	if options.DueToday {
+		// "today" is calculated in the requested timezone, falling back to the timezone of the todo
+		conditions = append(
+			conditions,
+			`(due_date AT TIME ZONE COALESCE(@timezone::text, due_timezone, 'UTC'))::date =
+			(now() AT TIME ZONE COALESCE(@timezone::text, due_timezone, 'UTC'))::date`,
+		)
+		args["timezone"] = options.Timezone
+	}
+
+	if options.DueBefore != nil {
+		conditions = append(conditions, "due_date < @dueBefore")
+		args["dueBefore"] = *options.DueBefore
+	}
+
+	if options.ProjectId != nil {
+		conditions = append(conditions, "project_id = @projectId")
+		args["projectId"] = *options.ProjectId
+	} else if options.Inbox {
+		conditions = append(conditions, "project_id IS NULL")
+	}
+
+	if options.ParentId != nil {
+		conditions = append(conditions, "parent_id = @parentId")
+		args["parentId"] = *options.ParentId
+	} else if options.TopLevel {
+		conditions = append(conditions, "parent_id IS NULL")
+	}
+
+	if options.SeriesId != nil {
+		conditions = append(conditions, "COALESCE(series_id, id) = @seriesId")
+		args["seriesId"] = *options.SeriesId
+	}
+
+	if options.AssignedToMe {
+		conditions = append(conditions, "assignee_id = @userId")
+	}
+
+	if options.WorkspaceId != nil {
+		conditions = append(conditions, "workspace_id = @workspaceId")
+		args["workspaceId"] = *options.WorkspaceId
+	} else if options.Personal {
+		conditions = append(conditions, personalCondition)
+	}
+
+	if options.After != nil {
+		conditions = append(conditions, cursorCondition(options.After, options.Sort, args))
+	}
+
+	if options.Filter != nil {
+		builder := &filterBuilder{args: args}
+		conditions = append(conditions, builder.build(options.Filter))
+	}
+
+	if len(options.Tags) > 0 {
+		args["tags"] = options.Tags
+		if options.MatchAllTags {
+			// the todo needs to have every tag, so the count of its matching tags should be equal to the count of the tags
+			conditions = append(conditions, `(SELECT COUNT(*) FROM todo_tag JOIN "tag" ON "tag".id = todo_tag.tag_id
+				WHERE todo_tag.todo_id = "todo".id AND "tag".name = ANY(@tags::text[])) = @tagCount`)
+			args["tagCount"] = len(options.Tags)
+		} else {
+			conditions = append(conditions, `EXISTS (SELECT 1 FROM todo_tag JOIN "tag" ON "tag".id = todo_tag.tag_id
+				WHERE todo_tag.todo_id = "todo".id AND "tag".name = ANY(@tags::text[]))`)
+		}
+	}
+
+	return conditions
+}
+
+// filterColumns maps the filter fields to their columns
+var filterColumns = map[string]string{
+	"done":     "done",
+	"archived": "archived",
+	"priority": "priority",
+	"due":      "due_date",
+	"created":  "created_at",
+	"updated":  "updated_at",
+	"title":    "title",
+	"status":   "status",
+	"project":  "project_id",
+	"parent":   "parent_id",
+}
+
+// filterSQLOperators maps the comparison operators of the filters to SQL
+var filterSQLOperators = map[string]string{
+	FilterEqual:          "=",
+	FilterNotEqual:       "<>",
+	FilterLess:           "<",
+	FilterLessOrEqual:    "<=",
+	FilterGreater:        ">",
+	FilterGreaterOrEqual: ">=",
+}
+
+// filterBuilder translates a parsed filter into a SQL condition. Values are always passed as named args
+type filterBuilder struct {
+	args  pgx.NamedArgs
+	count int
+}
+
+// arg adds the value as a new named arg and returns its placeholder
+func (b *filterBuilder) arg(value any) string {
+	name := fmt.Sprintf("filter%d", b.count)
+	b.count++
+	b.args[name] = value
+	return "@" + name
+}
+
+func (b *filterBuilder) build(node FilterNode) string {
+	switch n := node.(type) {
+	case FilterAnd:
+		return b.join(n.Nodes, " AND ")
+	case FilterOr:
+		return b.join(n.Nodes, " OR ")
+	case FilterNot:
+		// a NULL result of the negated condition shouldn't exclude the todo
+		return fmt.Sprintf("(%s) IS NOT TRUE", b.build(n.Node))
+	case FilterTerm:
+		return b.term(n)
+	}
+	return "true"
+}
+
+func (b *filterBuilder) join(nodes []FilterNode, separator string) string {
+	conditions := make([]string, len(nodes))
+	for i, node := range nodes {
+		conditions[i] = b.build(node)
+	}
+	return "(" + strings.Join(conditions, separator) + ")"
+}
+
+func (b *filterBuilder) term(term FilterTerm) string {
+	negate := term.Operator == FilterNotEqual
+
+	switch value := term.Value.(type) {
+	case nil:
+		if negate {
+			return filterColumns[term.Field] + " IS NOT NULL"
+		}
+		return filterColumns[term.Field] + " IS NULL"
+	case FilterTime:
+		return b.timeTerm(filterColumns[term.Field], term.Operator, value)
+	case string:
+		var condition string
+		if term.Field == "tag" {
+			condition = fmt.Sprintf(`EXISTS (SELECT 1 FROM todo_tag JOIN "tag" ON "tag".id = todo_tag.tag_id
+				WHERE todo_tag.todo_id = "todo".id AND "tag".name = %s)`, b.arg(value))
+		} else if term.Field == "status" {
+			condition = fmt.Sprintf("status = %s", b.arg(value))
+		} else {
+			// the value is searched in the title as it is, so the LIKE wildcards and the escape character are escaped
+			escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+			condition = fmt.Sprintf("title ILIKE %s", b.arg("%"+escaped+"%"))
+		}
+		if negate {
+			return "NOT " + condition
+		}
+		return condition
+	case int:
+		if negate {
+			return fmt.Sprintf("%s IS DISTINCT FROM %s", filterColumns[term.Field], b.arg(value))
+		}
+		return fmt.Sprintf("%s = %s", filterColumns[term.Field], b.arg(value))
+	}
+	return fmt.Sprintf("%s %s %s", filterColumns[term.Field], filterSQLOperators[term.Operator], b.arg(term.Value))
+}
+
+// timeTerm compares the column with a date as a whole day and with a timestamp as it is.
+// created_at and updated_at don't have a timezone, so the values are compared in UTC
+func (b *filterBuilder) timeTerm(column string, operator string, value FilterTime) string {
+	start := b.arg(value.Start.UTC())
+	if value.Start.Equal(value.End) {
+		return fmt.Sprintf("%s %s %s", column, filterSQLOperators[operator], start)
+	}
+
+	end := b.arg(value.End.UTC())
+	switch operator {
+	case FilterEqual:
+		return fmt.Sprintf("(%s >= %s AND %s < %s)", column, start, column, end)
+	case FilterNotEqual:
+		return fmt.Sprintf("(%s IS NULL OR %s < %s OR %s >= %s)", column, column, start, column, end)
+	case FilterLess:
+		return fmt.Sprintf("%s < %s", column, start)
+	case FilterLessOrEqual:
+		return fmt.Sprintf("%s < %s", column, end)
+	case FilterGreater:
+		return fmt.Sprintf("%s >= %s", column, end)
+	}
+	return fmt.Sprintf("%s >= %s", column, start)
+}
+
+// cursorCondition selects the todos that come after the cursor in the order of listOrder.
+// NULL values are sorted last in both directions, so they come after any value
+func cursorCondition(cursor *Cursor, sort *SortOption, args pgx.NamedArgs) string {
+	column := sortColumns[sortField(sort)]
+	comparison := ">"
+	if sort != nil && sort.Desc {
+		comparison = "<"
+	}
+	args["cursorId"] = cursor.Id
+
+	// the value is validated while decoding the cursor
+	value, _ := cursor.sortValue()
+	if value == nil {
+		return fmt.Sprintf("(%s IS NULL AND id %s @cursorId)", column, comparison)
+	}
+
+	args["cursorValue"] = value
+	return fmt.Sprintf(
+		"(%[1]s %[2]s @cursorValue OR (%[1]s = @cursorValue AND id %[2]s @cursorId) OR %[1]s IS NULL)",
+		column, comparison,
+	)
+}
+
+// listOrder returns the ORDER BY expression for the given sort option. id is always added for a stable order
+func listOrder(sort *SortOption) string {
+	if sort == nil {
+		return "position ASC, id ASC"
+	}
+
+	direction := "ASC"
+	if sort.Desc {
+		direction = "DESC"
+	}
+	return fmt.Sprintf("%s %s NULLS LAST, id %s", sortColumns[sort.Field], direction, direction)
+}
+
+func (store *Repository) GetAllTodos(userId int64, options *ListOptions) ([]Todo, error) {
 	args := pgx.NamedArgs{"userId": userId}
+	conditions := listConditions(options, args)
+	var sort *SortOption
+	if options != nil {
+		sort = options.Sort
+	}
+	query := `SELECT ` + todoColumns + ` FROM "todo" WHERE ` + strings.Join(conditions, " AND ") +
+		` ORDER BY ` + listOrder(sort)
+
+	if options != nil && options.Limit > 0 {
+		query += ` LIMIT @limit`
+		args["limit"] = options.Limit
+	}
 
 	rows, err := store.DB.Query(context.Background(), query, args)
 
@@ -73,14 +699,12 @@ func (store *Repository) GetAllTodos(userId int64) ([]Todo, error) {
 	todos := []Todo{}
 
 	for rows.Next() {
-		var t Todo
-
-		err := rows.Scan(&t.Id, &t.Title, &t.Done, &t.CreatedAt, &t.UpdatedAt)
+		t, err := ScanTodo(rows)
 
 		if err != nil {
 			return nil, err
 		}
-		todos = append(todos, t)
+		todos = append(todos, *t)
 	}
 
 	if err = rows.Err(); err != nil {
@@ -90,79 +714,906 @@ func (store *Repository) GetAllTodos(userId int64) ([]Todo, error) {
 	return todos, nil
 }
 
+// SumEstimates sums the estimates of the todos matching the list options for each estimate unit.
+// The cursor and the limit are ignored so that the sums cover the whole list.
+// Todos without a project are estimated in minutes
+func (store *Repository) SumEstimates(userId int64, options *ListOptions) ([]project.EstimateSum, error) {
+	var sumOptions *ListOptions
+	if options != nil {
+		copied := *options
+		copied.After = nil
+		copied.Limit = 0
+		sumOptions = &copied
+	}
+
+	args := pgx.NamedArgs{"userId": userId}
+	conditions := append(listConditions(sumOptions, args), "estimate IS NOT NULL")
+	query := `SELECT unit, SUM(estimate), COALESCE(SUM(estimate) FILTER (WHERE NOT done), 0), COUNT(*)
+		FROM (
+			SELECT estimate, done,
+				COALESCE((SELECT estimate_unit FROM "project" WHERE "project".id = "todo".project_id), 'minutes') AS unit
+			FROM "todo" WHERE ` + strings.Join(conditions, " AND ") + `
+		) estimated
+		GROUP BY unit
+		ORDER BY unit`
+
+	rows, err := store.DB.Query(context.Background(), query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sums := []project.EstimateSum{}
+	for rows.Next() {
+		var sum project.EstimateSum
+		if err := rows.Scan(&sum.Unit, &sum.Total, &sum.Remaining, &sum.Count); err != nil {
+			return nil, err
+		}
+		sums = append(sums, sum)
+	}
+	return sums, rows.Err()
+}
+
+// GetBurndown sums the estimates of the project at the end of each day from the todo change history.
+// The last change of each todo before the end of the day decides whether it was in the project, done or in the trash
+func (store *Repository) GetBurndown(projectId int, options *BurndownOptions) ([]BurndownDay, error) {
+	query := `WITH days AS (
+			SELECT d::date AS day FROM generate_series(@from::date, @to::date, interval '1 day') d
+		), history AS (
+			SELECT * FROM "todo_change"
+			WHERE todo_id IN (SELECT todo_id FROM "todo_change" WHERE project_id = @projectId)
+		)
+		SELECT to_char(days.day, 'YYYY-MM-DD'),
+			COALESCE(SUM(state.estimate) FILTER (WHERE NOT state.done), 0),
+			COALESCE(SUM(state.estimate), 0)
+		FROM days LEFT JOIN LATERAL (
+			SELECT DISTINCT ON (h.todo_id) h.project_id, h.estimate, h.done, h.deleted FROM history h
+			WHERE h.changed_at < ((days.day + 1)::timestamp AT TIME ZONE @timezone)
+			ORDER BY h.todo_id, h.changed_at DESC, h.id DESC
+		) state ON state.project_id = @projectId AND NOT state.deleted
+		GROUP BY days.day
+		ORDER BY days.day`
+	args := pgx.NamedArgs{
+		"projectId": projectId,
+		"from":      options.From.Format(time.DateOnly),
+		"to":        options.To.Format(time.DateOnly),
+		"timezone":  options.Timezone,
+	}
+
+	rows, err := store.DB.Query(context.Background(), query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := []BurndownDay{}
+	for rows.Next() {
+		var day BurndownDay
+		if err := rows.Scan(&day.Day, &day.Remaining, &day.Total); err != nil {
+			return nil, err
+		}
+		days = append(days, day)
+	}
+	return days, rows.Err()
+}
+
 func (store *Repository) UpdateTodo(data *UpdateTodoData, userId int64) (*Todo, error) {
+	ctx := context.Background()
+	tx, err := store.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	updatedTodo, err := updateTodo(ctx, tx, data, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return updatedTodo, nil
+}
+
+// UpdateOccurrence updates a recurring todo, creates its next occurrence and updates the other occurrences
+// of its series in the same transaction, so that the series isn't left without the next occurrence or half edited
+func (store *Repository) UpdateOccurrence(update *OccurrenceUpdate, userId int64) (*Todo, error) {
+	ctx := context.Background()
+	tx, err := store.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	updatedTodo, err := updateTodo(ctx, tx, update.Todo, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.NextOccurrence != nil {
+		next, err := update.NextOccurrence(updatedTodo)
+		if err != nil {
+			return nil, err
+		}
+		if next != nil {
+			if _, err := insertTodo(ctx, tx, next, userId); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, occurrenceId := range update.SeriesIds {
+		occurrenceData := *update.Series
+		occurrenceData.Id = &occurrenceId
+		// the series has nothing to update if only the fields specific to the occurrence are sent
+		if _, err := updateTodo(ctx, tx, &occurrenceData, userId); errors.Is(err, ErrNoFieldToUpdate) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return updatedTodo, nil
+}
+
+// updateTodo updates the fields of the todo that are sent, and returns the updated todo
+func updateTodo(ctx context.Context, q querier, data *UpdateTodoData, userId int64) (*Todo, error) {
 	var updateBuilder strings.Builder
 	updateBuilder.WriteString("UPDATE todo SET ")
 	var updates []string
 	var args []interface{}
 
-	if data.Title == nil && data.Done == nil {
-		return nil, errors.New("no field is provided")
+	// addUpdate adds a column update with the next positional argument
+	addUpdate := func(column string, value interface{}) {
+		args = append(args, value)
+		updates = append(updates, fmt.Sprintf("%s = $%d", column, len(args)))
 	}
 
 	if data.Title != nil {
-		updates = append(updates, "title = $1")
-		args = append(args, data.Title)
+		addUpdate("title", data.Title)
+	}
+
+	if data.Notes != nil {
+		addUpdate("notes", data.Notes)
 	}
 
 	if data.Done != nil {
-		updates = append(updates, fmt.Sprintf("done = $%d", len(args)+1))
-		args = append(args, data.Done)
+		addUpdate("done", data.Done)
+		// completed_at keeps the first completion time and is cleared when the todo is not done anymore
+		updates = append(updates, fmt.Sprintf("completed_at = CASE WHEN $%d::boolean THEN COALESCE(completed_at, now()) END", len(args)))
+
+		// a todo that is not done anymore can't stay in the archive
+		if !*data.Done && data.Archived == nil {
+			updates = append(updates, "archived = false")
+		}
 	}
 
-	updateBuilder.WriteString(strings.Join(updates, ", "))
-	updateBuilder.WriteString(",") // Add space before update
+	if data.Archived != nil {
+		addUpdate("archived", data.Archived)
+	}
 
-	updateBuilder.WriteString(fmt.Sprintf("updated_at = $%d", len(args)+1))
-	args = append(args, time.Now())
-	updateBuilder.WriteString(" ") // Add space before WHERE clause
+	if data.DueDate != nil {
+		addUpdate("due_date", data.DueDate)
+	}
 
-	updateBuilder.WriteString(fmt.Sprintf("WHERE id = %d and user_id = %d RETURNING id, title, done, created_at, updated_at", *data.Id, userId))
+	if data.DueTimezone != nil {
+		addUpdate("due_timezone", data.DueTimezone)
+	}
 
-	rows := store.DB.QueryRow(context.Background(), updateBuilder.String(), args...)
+	if data.ReminderOffset != nil {
+		addUpdate("reminder_offset", data.ReminderOffset)
+	}
 
-	updatedData, updateScanErr := ScanTodo(rows)
+	if data.Priority != nil {
+		addUpdate("priority", data.Priority)
+	}
 
-	if updateScanErr != nil {
-		return nil, updateScanErr
+	if data.ProjectId != nil {
+		addUpdate("project_id", data.ProjectId)
 	}
 
-	return updatedData, nil
-}
+	if data.ParentId != nil {
+		addUpdate("parent_id", data.ParentId)
+	}
 
-func (store *Repository) GetTodo(todoId int, userId int64) (*Todo, error) {
-	query := `SELECT id, title, done, created_at, updated_at FROM todo WHERE id = @todoId and user_id = @userId`
-	args := pgx.NamedArgs{
-		"todoId": todoId,
-		"userId": userId,
+	if data.AutoComplete != nil {
+		addUpdate("auto_complete", data.AutoComplete)
 	}
 
-	var singleTodo *Todo
-	singleTodo = new(Todo)
-	row := store.DB.QueryRow(context.Background(), query, args)
+	if data.Recurrence != nil {
+		addUpdate("recurrence", data.Recurrence)
+	}
 
-	err := row.Scan(&singleTodo.Id, &singleTodo.Title, &singleTodo.Done, &singleTodo.CreatedAt, &singleTodo.UpdatedAt)
-	if err != nil {
-		return nil, err
+	if data.AssigneeId != nil {
+		addUpdate("assignee_id", data.AssigneeId)
 	}
 
-	return singleTodo, nil
-}
+	if data.Status != nil {
+		addUpdate("status", data.Status)
+	}
 
-func (store *Repository) RemoveTodo(todoId int, userId int64) (*Todo, error) {
-	query := `DELETE FROM todo WHERE id = @todoId and user_id = @userId RETURNING id, title, done, created_at, updated_at`
+	if data.Estimate != nil {
+		addUpdate("estimate", data.Estimate)
+	}
 
-	args := pgx.NamedArgs{
-		"todoId": todoId,
-		"userId": userId,
+	for _, field := range data.Clear {
+		column, ok := clearableFields[field]
+		if !ok {
+			return nil, ErrClearFieldNotValid.With(field)
+		}
+		updates = append(updates, fmt.Sprintf("%s = NULL", column))
 	}
 
-	rows := store.DB.QueryRow(context.Background(), query, args)
-	removedTodo, removeScanErr := ScanTodo(rows)
+	if len(updates) == 0 && data.Tags == nil && data.BlockedBy == nil {
+		return nil, ErrNoFieldToUpdate
+	}
 
-	if removeScanErr != nil {
-		return nil, removeScanErr
+	updates = append(updates, fmt.Sprintf("updated_at = $%d", len(args)+1))
+	args = append(args, time.Now())
+
+	updateBuilder.WriteString(strings.Join(updates, ", "))
+	updateBuilder.WriteString(" ") // Add space before WHERE clause
+
+	// viewers can't change the todo
+	updateBuilder.WriteString(fmt.Sprintf(
+		"WHERE id = %d AND todo_role(id, %d) IN ('owner', 'editor') AND deleted_at IS NULL RETURNING id", *data.Id, userId,
+	))
+
+	var todoId int
+	if err := q.QueryRow(ctx, updateBuilder.String(), args...).Scan(&todoId); err != nil {
+		return nil, err
 	}
 
-	return removedTodo, nil
+	if data.Tags != nil {
+		if err := setTodoTags(ctx, q, todoId, *data.Tags); err != nil {
+			return nil, err
+		}
+	}
+
+	if data.BlockedBy != nil {
+		if err := setTodoDependencies(ctx, q, todoId, *data.BlockedBy); err != nil {
+			return nil, err
+		}
+	}
+
+	return getTodo(ctx, q, todoId, userId)
+}
+
+func (store *Repository) GetTodo(todoId int, userId int64) (*Todo, error) {
+	return getTodo(context.Background(), store.DB, todoId, userId)
+}
+
+// RemoveTodo moves the todo and its subtasks to the trash if the user owns the todo. They share the same deleted_at
+// so that they can be restored together
+func (store *Repository) RemoveTodo(todoId int, userId int64) (*Todo, error) {
+	ctx := context.Background()
+	tx, err := store.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `WITH RECURSIVE subtree AS (
+			SELECT id FROM "todo" WHERE id = @todoId AND todo_role(id, @userId) = 'owner' AND deleted_at IS NULL
+			UNION
+			SELECT child.id FROM "todo" child JOIN subtree ON child.parent_id = subtree.id WHERE child.deleted_at IS NULL
+		)
+		UPDATE "todo" SET deleted_at = now() WHERE id IN (SELECT id FROM subtree)`
+	args := pgx.NamedArgs{
+		"todoId": todoId,
+		"userId": userId,
+	}
+
+	commandTag, err := tx.Exec(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	if commandTag.RowsAffected() == 0 {
+		return nil, pgx.ErrNoRows
+	}
+
+	removedTodo, scanErr := ScanTodo(tx.QueryRow(ctx, `SELECT `+todoColumns+` FROM "todo" WHERE id = @todoId`, args))
+	if scanErr != nil {
+		return nil, scanErr
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return removedTodo, nil
+}
+
+// GetTrash returns the todos of the user in the trash, most recently removed first.
+// Subtasks that were removed together with their parent are not listed separately
+func (store *Repository) GetTrash(userId int64) ([]Todo, error) {
+	query := `SELECT ` + todoColumns + ` FROM "todo" WHERE ` + ownedCondition + ` AND deleted_at IS NOT NULL
+		AND NOT EXISTS (SELECT 1 FROM "todo" parent WHERE parent.id = "todo".parent_id AND parent.deleted_at = "todo".deleted_at)
+		ORDER BY deleted_at DESC, id DESC`
+	args := pgx.NamedArgs{"userId": userId}
+
+	rows, err := store.DB.Query(context.Background(), query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	todos := []Todo{}
+	for rows.Next() {
+		t, err := ScanTodo(rows)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, *t)
+	}
+
+	return todos, rows.Err()
+}
+
+// RestoreTodo takes the todo out of the trash with the subtasks that were removed together with it.
+// If the parent of the todo is still in the trash, the todo is restored as a top level todo
+func (store *Repository) RestoreTodo(todoId int, userId int64) (*Todo, error) {
+	ctx := context.Background()
+	tx, err := store.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	args := pgx.NamedArgs{
+		"todoId": todoId,
+		"userId": userId,
+	}
+
+	detachQuery := `UPDATE "todo" SET parent_id = NULL WHERE id = @todoId AND ` + ownedCondition + ` AND deleted_at IS NOT NULL
+		AND parent_id IN (SELECT id FROM "todo" WHERE deleted_at IS NOT NULL)`
+	if _, err := tx.Exec(ctx, detachQuery, args); err != nil {
+		return nil, err
+	}
+
+	restoreQuery := `WITH RECURSIVE subtree AS (
+			SELECT id, deleted_at FROM "todo" WHERE id = @todoId AND ` + ownedCondition + ` AND deleted_at IS NOT NULL
+			UNION
+			SELECT child.id, child.deleted_at FROM "todo" child JOIN subtree ON child.parent_id = subtree.id
+				WHERE child.deleted_at = subtree.deleted_at
+		)
+		UPDATE "todo" SET deleted_at = NULL, updated_at = now() WHERE id IN (SELECT id FROM subtree)`
+	if _, err := tx.Exec(ctx, restoreQuery, args); err != nil {
+		return nil, err
+	}
+
+	restoredTodo, scanErr := getTodo(ctx, tx, todoId, userId)
+	if scanErr != nil {
+		return nil, scanErr
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return restoredTodo, nil
+}
+
+// PurgeTrash permanently deletes the todos of all users that were moved to the trash before the given time
+func (store *Repository) PurgeTrash(before time.Time) (int64, error) {
+	query := `DELETE FROM "todo" WHERE deleted_at < @before`
+	args := pgx.NamedArgs{"before": before}
+
+	commandTag, err := store.DB.Exec(context.Background(), query, args)
+	if err != nil {
+		return 0, err
+	}
+	return commandTag.RowsAffected(), nil
+}
+
+// SearchTodos finds the todos of the user that have all the words of the query, best matches first.
+// The list options are applied as in GetAllTodos except the sort and the cursor
+func (store *Repository) SearchTodos(userId int64, query string, options *ListOptions) ([]SearchResult, error) {
+	terms := SearchTerms(query)
+	if len(terms) == 0 {
+		return []SearchResult{}, nil
+	}
+
+	args := pgx.NamedArgs{"userId": userId, "query": prefixTSQuery(terms)}
+	conditions := append(listConditions(options, args), "search_vector @@ search_query")
+
+	// the title and the notes are escaped before highlighting so that the snippets can be shown as HTML.
+	// Only the matching fragments of the notes are returned since they can be long
+	searchQuery := `SELECT ` + todoColumns + `,
+			ts_rank(search_vector, search_query) AS rank,
+			ts_headline('simple', replace(replace(replace(title, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), search_query,
+				'StartSel=<mark>, StopSel=</mark>, HighlightAll=true') AS snippet,
+			CASE WHEN to_tsvector('simple', COALESCE(notes, '')) @@ search_query THEN
+				ts_headline('simple', replace(replace(replace(notes, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), search_query,
+					'StartSel=<mark>, StopSel=</mark>, MaxFragments=2')
+			END AS notes_snippet
+		FROM "todo", to_tsquery('simple', @query) search_query
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY rank DESC, id DESC`
+
+	if options != nil && options.Limit > 0 {
+		searchQuery += ` LIMIT @limit`
+		args["limit"] = options.Limit
+	}
+
+	rows, err := store.DB.Query(context.Background(), searchQuery, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []SearchResult{}
+	for rows.Next() {
+		var result SearchResult
+		t, err := ScanTodo(rows, &result.Rank, &result.Snippet, &result.NotesSnippet)
+		if err != nil {
+			return nil, err
+		}
+		result.Todo = *t
+		results = append(results, result)
+	}
+
+	return results, rows.Err()
+}
+
+// ArchiveCompleted archives all the done todos of the user and returns how many todos are archived
+func (store *Repository) ArchiveCompleted(userId int64) (int64, error) {
+	query := `UPDATE "todo" SET archived = true, updated_at = now()
+		WHERE ` + ownedCondition + ` AND done AND NOT archived AND deleted_at IS NULL`
+	args := pgx.NamedArgs{"userId": userId}
+
+	commandTag, err := store.DB.Exec(context.Background(), query, args)
+	if err != nil {
+		return 0, err
+	}
+	return commandTag.RowsAffected(), nil
+}
+
+// ArchiveCompletedBefore archives the todos of all users that were completed before the given time
+func (store *Repository) ArchiveCompletedBefore(before time.Time) (int64, error) {
+	query := `UPDATE "todo" SET archived = true, updated_at = now()
+		WHERE done AND NOT archived AND deleted_at IS NULL AND completed_at < @before`
+	args := pgx.NamedArgs{"before": before}
+
+	commandTag, err := store.DB.Exec(context.Background(), query, args)
+	if err != nil {
+		return 0, err
+	}
+	return commandTag.RowsAffected(), nil
+}
+
+// DeleteTodo permanently deletes the todo whether it is in the trash or not if the user owns it.
+// Its subtasks are deleted by the database
+func (store *Repository) DeleteTodo(todoId int, userId int64) (*Todo, error) {
+	query := `DELETE FROM todo WHERE id = @todoId AND todo_role(id, @userId) = 'owner' RETURNING ` + todoColumns
+
+	args := pgx.NamedArgs{
+		"todoId": todoId,
+		"userId": userId,
+	}
+
+	rows := store.DB.QueryRow(context.Background(), query, args)
+	removedTodo, removeScanErr := ScanTodo(rows)
+
+	if removeScanErr != nil {
+		return nil, removeScanErr
+	}
+
+	return removedTodo, nil
+}
+
+// GetDescendants returns all the subtasks under the todo in any depth as a flat list.
+// Everyone who can see the todo can see its subtasks
+func (store *Repository) GetDescendants(todoId int, userId int64) ([]Todo, error) {
+	query := `WITH RECURSIVE descendants AS (
+			SELECT id FROM "todo" WHERE parent_id = @todoId AND todo_role(@todoId, @userId) IS NOT NULL AND deleted_at IS NULL
+			UNION
+			SELECT child.id FROM "todo" child JOIN descendants ON child.parent_id = descendants.id WHERE child.deleted_at IS NULL
+		)
+		SELECT ` + todoColumns + ` FROM "todo" WHERE id IN (SELECT id FROM descendants) ORDER BY position, id`
+	args := pgx.NamedArgs{
+		"todoId": todoId,
+		"userId": userId,
+	}
+
+	rows, err := store.DB.Query(context.Background(), query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	descendants := []Todo{}
+	for rows.Next() {
+		t, err := ScanTodo(rows)
+		if err != nil {
+			return nil, err
+		}
+		descendants = append(descendants, *t)
+	}
+
+	return descendants, rows.Err()
+}
+
+// GetAncestorIds returns the id of the todo and the ids of all its parents up to the top level todo.
+// It returns an empty list if the user can't change the todo
+func (store *Repository) GetAncestorIds(todoId int, userId int64) ([]int, error) {
+	query := `WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id FROM "todo" WHERE id = @todoId AND todo_role(id, @userId) IN ('owner', 'editor')
+			UNION
+			SELECT parent.id, parent.parent_id FROM "todo" parent JOIN ancestors ON parent.id = ancestors.parent_id
+		)
+		SELECT id FROM ancestors`
+	args := pgx.NamedArgs{
+		"todoId": todoId,
+		"userId": userId,
+	}
+
+	rows, err := store.DB.Query(context.Background(), query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[int])
+}
+
+// GetBlockerIds returns the ids of the todos that block the given todos directly or through the todos blocking them
+func (store *Repository) GetBlockerIds(todoIds []int) ([]int, error) {
+	query := `WITH RECURSIVE blockers AS (
+			SELECT blocked_by_id AS id FROM todo_dependency WHERE todo_id = ANY(@todoIds::integer[])
+			UNION
+			SELECT todo_dependency.blocked_by_id FROM todo_dependency JOIN blockers ON todo_dependency.todo_id = blockers.id
+		)
+		SELECT id FROM blockers`
+	args := pgx.NamedArgs{"todoIds": todoIds}
+
+	rows, err := store.DB.Query(context.Background(), query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[int])
+}
+
+// GetDependencies returns the dependencies between the given todos
+func (store *Repository) GetDependencies(todoIds []int) ([]Dependency, error) {
+	query := `SELECT todo_id, blocked_by_id FROM todo_dependency
+		WHERE todo_id = ANY(@todoIds::integer[]) AND blocked_by_id = ANY(@todoIds::integer[])
+		ORDER BY todo_id, blocked_by_id`
+	args := pgx.NamedArgs{"todoIds": todoIds}
+
+	rows, err := store.DB.Query(context.Background(), query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByPos[Dependency])
+}
+
+// ReorderTodo moves the todo next to the target todo. Only the moved todo is updated unless
+// there is no room left between the target and its neighbour; then the positions of the user are renumbered.
+// Positions belong to the owner, so only the todos of the user can be reordered
+func (store *Repository) ReorderTodo(data *ReorderTodoData, userId int64) (*Todo, error) {
+	ctx := context.Background()
+	tx, err := store.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	targetId := data.AfterId
+	if data.BeforeId != nil {
+		targetId = data.BeforeId
+	}
+
+	// the neighbour is the closest todo on the side the todo is moved to, ignoring the moved todo itself
+	neighbourQuery := `SELECT position FROM "todo" WHERE user_id = @userId AND id <> @todoId AND deleted_at IS NULL
+		AND position > @targetPosition ORDER BY position ASC LIMIT 1`
+	if data.BeforeId != nil {
+		neighbourQuery = `SELECT position FROM "todo" WHERE user_id = @userId AND id <> @todoId AND deleted_at IS NULL
+			AND position < @targetPosition ORDER BY position DESC LIMIT 1`
+	}
+
+	var position float64
+	for attempt := 0; ; attempt++ {
+		var targetPosition float64
+		targetArgs := pgx.NamedArgs{"targetId": *targetId, "userId": userId}
+		targetRow := tx.QueryRow(ctx, `SELECT position FROM "todo" WHERE id = @targetId AND user_id = @userId AND deleted_at IS NULL`, targetArgs)
+		if err := targetRow.Scan(&targetPosition); err != nil {
+			return nil, err
+		}
+
+		var neighbourPosition *float64
+		neighbourArgs := pgx.NamedArgs{"userId": userId, "todoId": *data.Id, "targetPosition": targetPosition}
+		neighbourErr := tx.QueryRow(ctx, neighbourQuery, neighbourArgs).Scan(&neighbourPosition)
+		if neighbourErr != nil && !errors.Is(neighbourErr, pgx.ErrNoRows) {
+			return nil, neighbourErr
+		}
+
+		var ok bool
+		if data.BeforeId != nil {
+			position, ok = positionBetween(neighbourPosition, &targetPosition)
+		} else {
+			position, ok = positionBetween(&targetPosition, neighbourPosition)
+		}
+
+		if ok {
+			break
+		}
+		if attempt > 0 {
+			return nil, errors.New("couldn't find a position for the todo")
+		}
+
+		renumberQuery := `UPDATE "todo" SET position = ranked.rank * @positionGap
+			FROM (SELECT id, row_number() OVER (ORDER BY position, id) AS rank FROM "todo" WHERE user_id = @userId) ranked
+			WHERE "todo".id = ranked.id`
+		renumberArgs := pgx.NamedArgs{"userId": userId, "positionGap": positionGap}
+		if _, err := tx.Exec(ctx, renumberQuery, renumberArgs); err != nil {
+			return nil, err
+		}
+	}
+
+	query := `UPDATE "todo" SET position = @position, updated_at = now()
+		WHERE id = @todoId AND user_id = @userId AND deleted_at IS NULL RETURNING ` + todoColumns
+	args := pgx.NamedArgs{"position": position, "todoId": *data.Id, "userId": userId}
+
+	reorderedTodo, scanErr := ScanTodo(tx.QueryRow(ctx, query, args))
+	if scanErr != nil {
+		return nil, scanErr
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return reorderedTodo, nil
+}
+
+// uniqueViolation is the postgres error code for unique constraint violations
+const uniqueViolation = "23505"
+
+// mapViewNameTaken returns ErrViewNameTaken if the error is caused by the unique constraint on the name of the view
+func mapViewNameTaken(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return ErrViewNameTaken
+	}
+	return err
+}
+
+func (store *Repository) CreateView(data *CreateViewData, userId int64) (*View, error) {
+	query := `INSERT INTO "todo_view"(name, criteria, user_id) VALUES (@name, @criteria, @userId) RETURNING ` + viewColumns
+	args := pgx.NamedArgs{
+		"name":     data.Name,
+		"criteria": data.Criteria,
+		"userId":   userId,
+	}
+
+	createdView, err := ScanView(store.DB.QueryRow(context.Background(), query, args))
+	if err != nil {
+		return nil, mapViewNameTaken(err)
+	}
+	return createdView, nil
+}
+
+func (store *Repository) GetAllViews(userId int64) ([]View, error) {
+	query := `SELECT ` + viewColumns + ` FROM "todo_view" WHERE user_id = @userId ORDER BY name`
+	args := pgx.NamedArgs{"userId": userId}
+
+	rows, err := store.DB.Query(context.Background(), query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	views := []View{}
+	for rows.Next() {
+		v, err := ScanView(rows)
+		if err != nil {
+			return nil, err
+		}
+		views = append(views, *v)
+	}
+
+	return views, rows.Err()
+}
+
+func (store *Repository) GetView(viewId int, userId int64) (*View, error) {
+	query := `SELECT ` + viewColumns + ` FROM "todo_view" WHERE id = @viewId AND user_id = @userId`
+	args := pgx.NamedArgs{
+		"viewId": viewId,
+		"userId": userId,
+	}
+
+	return ScanView(store.DB.QueryRow(context.Background(), query, args))
+}
+
+func (store *Repository) UpdateView(data *UpdateViewData, userId int64) (*View, error) {
+	var updates []string
+	args := pgx.NamedArgs{
+		"viewId": *data.Id,
+		"userId": userId,
+	}
+
+	if data.Name != nil {
+		updates = append(updates, "name = @name")
+		args["name"] = *data.Name
+	}
+
+	if data.Criteria != nil {
+		updates = append(updates, "criteria = @criteria")
+		args["criteria"] = *data.Criteria
+	}
+
+	if len(updates) == 0 {
+		return nil, ErrNoFieldToUpdate
+	}
+	updates = append(updates, "updated_at = now()")
+
+	query := `UPDATE "todo_view" SET ` + strings.Join(updates, ", ") +
+		` WHERE id = @viewId AND user_id = @userId RETURNING ` + viewColumns
+
+	updatedView, err := ScanView(store.DB.QueryRow(context.Background(), query, args))
+	if err != nil {
+		return nil, mapViewNameTaken(err)
+	}
+	return updatedView, nil
+}
+
+func (store *Repository) RemoveView(viewId int, userId int64) (*View, error) {
+	query := `DELETE FROM "todo_view" WHERE id = @viewId AND user_id = @userId RETURNING ` + viewColumns
+	args := pgx.NamedArgs{
+		"viewId": viewId,
+		"userId": userId,
+	}
+
+	return ScanView(store.DB.QueryRow(context.Background(), query, args))
+}
+
+// CreateTodoTree creates the todos and their subtasks in one transaction, so either all of them are created or none.
+// The parents of the subtasks are set to the created todos, and the created todos are returned with their subtasks
+func (store *Repository) CreateTodoTree(todos []Todo, userId int64) ([]Todo, error) {
+	ctx := context.Background()
+	tx, err := store.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	created, err := insertTodoTree(ctx, tx, todos, nil, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// insertTodoTree inserts the todos under the parent and then their subtasks under them
+func insertTodoTree(ctx context.Context, q querier, todos []Todo, parentId *int, userId int64) ([]Todo, error) {
+	created := make([]Todo, 0, len(todos))
+	for _, data := range todos {
+		data.ParentId = parentId
+		todoId, err := insertTodo(ctx, q, &data, userId)
+		if err != nil {
+			return nil, err
+		}
+
+		subtasks, err := insertTodoTree(ctx, q, data.Subtasks, &todoId, userId)
+		if err != nil {
+			return nil, err
+		}
+
+		// the todo is fetched after its subtasks so that its progress counts them
+		createdTodo, err := getTodo(ctx, q, todoId, userId)
+		if err != nil {
+			return nil, err
+		}
+		if len(subtasks) > 0 {
+			createdTodo.Subtasks = subtasks
+		}
+		created = append(created, *createdTodo)
+	}
+	return created, nil
+}
+
+// mapTemplateNameTaken returns ErrTemplateNameTaken if the error is caused by the unique constraint on the name
+// of the template
+func mapTemplateNameTaken(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return ErrTemplateNameTaken
+	}
+	return err
+}
+
+func (store *Repository) CreateTemplate(data *CreateTemplateData, userId int64) (*Template, error) {
+	query := `INSERT INTO "todo_template"(name, items, user_id) VALUES (@name, @items, @userId) RETURNING ` + templateColumns
+	args := pgx.NamedArgs{
+		"name":   data.Name,
+		"items":  data.Items,
+		"userId": userId,
+	}
+
+	createdTemplate, err := ScanTemplate(store.DB.QueryRow(context.Background(), query, args))
+	if err != nil {
+		return nil, mapTemplateNameTaken(err)
+	}
+	return createdTemplate, nil
+}
+
+func (store *Repository) GetAllTemplates(userId int64) ([]Template, error) {
+	query := `SELECT ` + templateColumns + ` FROM "todo_template" WHERE user_id = @userId ORDER BY name`
+	args := pgx.NamedArgs{"userId": userId}
+
+	rows, err := store.DB.Query(context.Background(), query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := []Template{}
+	for rows.Next() {
+		t, err := ScanTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, *t)
+	}
+
+	return templates, rows.Err()
+}
+
+func (store *Repository) GetTemplate(templateId int, userId int64) (*Template, error) {
+	query := `SELECT ` + templateColumns + ` FROM "todo_template" WHERE id = @templateId AND user_id = @userId`
+	args := pgx.NamedArgs{
+		"templateId": templateId,
+		"userId":     userId,
+	}
+
+	return ScanTemplate(store.DB.QueryRow(context.Background(), query, args))
+}
+
+func (store *Repository) UpdateTemplate(data *UpdateTemplateData, userId int64) (*Template, error) {
+	var updates []string
+	args := pgx.NamedArgs{
+		"templateId": *data.Id,
+		"userId":     userId,
+	}
+
+	if data.Name != nil {
+		updates = append(updates, "name = @name")
+		args["name"] = *data.Name
+	}
+
+	if data.Items != nil {
+		updates = append(updates, "items = @items")
+		args["items"] = *data.Items
+	}
+
+	if len(updates) == 0 {
+		return nil, ErrNoFieldToUpdate
+	}
+	updates = append(updates, "updated_at = now()")
+
+	query := `UPDATE "todo_template" SET ` + strings.Join(updates, ", ") +
+		` WHERE id = @templateId AND user_id = @userId RETURNING ` + templateColumns
+
+	updatedTemplate, err := ScanTemplate(store.DB.QueryRow(context.Background(), query, args))
+	if err != nil {
+		return nil, mapTemplateNameTaken(err)
+	}
+	return updatedTemplate, nil
+}
+
+func (store *Repository) RemoveTemplate(templateId int, userId int64) (*Template, error) {
+	query := `DELETE FROM "todo_template" WHERE id = @templateId AND user_id = @userId RETURNING ` + templateColumns
+	args := pgx.NamedArgs{
+		"templateId": templateId,
+		"userId":     userId,
+	}
+
+	return ScanTemplate(store.DB.QueryRow(context.Background(), query, args))
 }
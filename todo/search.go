@@ -0,0 +1,41 @@
+package todo
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Searcher finds the todos matching a text query. The postgres repository implements it with a tsvector column;
+// a repository with another backend can provide its own implementation
+type Searcher interface {
+	SearchTodos(userId int64, query string, options *ListOptions) ([]SearchResult, error)
+}
+
+// SearchResult is a todo matching the search query. Snippet is the HTML escaped title with
+// the matching words wrapped in <mark> tags. NotesSnippet is the part of the notes highlighted in the same way,
+// it is only set if the notes match the query
+type SearchResult struct {
+	Todo
+	Rank         float32 `json:"rank"`
+	Snippet      string  `json:"snippet"`
+	NotesSnippet *string `json:"notesSnippet,omitempty"`
+}
+
+// maxSearchTerms limits the count of the words used from the search query
+const maxSearchTerms = 10
+
+var searchTermRegex = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// SearchTerms returns the lowercased words of the search query. Punctuation and operators are ignored
+func SearchTerms(query string) []string {
+	return searchTermRegex.FindAllString(strings.ToLower(query), maxSearchTerms)
+}
+
+// prefixTSQuery builds a tsquery that matches the todos that have all the terms as a word or as a prefix of a word
+func prefixTSQuery(terms []string) string {
+	prefixed := make([]string, len(terms))
+	for i, term := range terms {
+		prefixed[i] = term + ":*"
+	}
+	return strings.Join(prefixed, " & ")
+}
@@ -0,0 +1,29 @@
+package todo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSearchTerms(t *testing.T) {
+	tests := []struct {
+		name            string
+		query           string
+		expectedTerms   []string
+		expectedTSQuery string
+	}{
+		{name: "Single word", query: "groceries", expectedTerms: []string{"groceries"}, expectedTSQuery: "groceries:*"},
+		{name: "Words are lowercased", query: "Buy MILK", expectedTerms: []string{"buy", "milk"}, expectedTSQuery: "buy:* & milk:*"},
+		{name: "Operators are ignored", query: "milk & !eggs | (bread:*)", expectedTerms: []string{"milk", "eggs", "bread"}, expectedTSQuery: "milk:* & eggs:* & bread:*"},
+		{name: "Unicode letters", query: "çiçekleri sula", expectedTerms: []string{"çiçekleri", "sula"}, expectedTSQuery: "çiçekleri:* & sula:*"},
+		{name: "No words", query: " !?& ", expectedTerms: nil, expectedTSQuery: ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			terms := SearchTerms(tc.query)
+			assert.Equal(t, tc.expectedTerms, terms)
+			assert.Equal(t, tc.expectedTSQuery, prefixTSQuery(terms))
+		})
+	}
+}
@@ -1,43 +1,1224 @@
 package todo
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/umtdemr/go-todo/email"
+	"github.com/umtdemr/go-todo/logger"
+	"github.com/umtdemr/go-todo/project"
+	"github.com/umtdemr/go-todo/share"
+	"github.com/umtdemr/go-todo/tag"
+	"github.com/umtdemr/go-todo/user"
+	"github.com/umtdemr/go-todo/workspace"
+	"slices"
+	"strings"
+	"time"
 )
 
 type IService interface {
-	GetAllTodos(userId int64) ([]Todo, error)
+	GetAllTodos(userId int64, options *ListOptions) ([]Todo, error)
+	ListTodos(userId int64, options *ListOptions) (*TodoPage, error)
+	SearchTodos(userId int64, query string, options *ListOptions) ([]SearchResult, error)
 	CreateTodo(data *CreateTodoData, userId int64) (*Todo, error)
 	UpdateTodo(data *UpdateTodoData, userId int64) (*Todo, error)
-	RemoveTodo(todoId int, userId int64) (*Todo, error)
+	RemoveTodo(todoId int, userId int64, permanent bool) (*Todo, error)
+	GetTrash(userId int64) ([]Todo, error)
+	RestoreTodo(todoId int, userId int64) (*Todo, error)
+	ArchiveCompleted(userId int64) (int64, error)
+	ReorderTodo(data *ReorderTodoData, userId int64) (*Todo, error)
 }
+
+// DefaultTrashRetention is how long the todos are kept in the trash when the retention is not configured
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+// ProjectGetter is used for checking the project of a todo and finding the @project of a quick add by its name.
+// It is implemented by the project service
+type ProjectGetter interface {
+	GetProject(projectId int, userId int64) (*project.Project, error)
+	GetAllProjects(userId int64, workspaceId *int, includeArchived bool) ([]project.Project, error)
+}
+
+// AttachmentCleaner deletes the files of the attachments whose todos are deleted permanently.
+// It is implemented by the attachment service
+type AttachmentCleaner interface {
+	RemoveDetachedAttachments() (int64, error)
+}
+
+// TimerStopper stops the running timers of the todos in the trash. The time entries of the todos which are deleted
+// permanently are deleted with them. It is implemented by the time tracking service
+type TimerStopper interface {
+	StopTrashedTimers() (int64, error)
+}
+
+// UserGetter is used for emailing the assignees of the todos. It is implemented by the user service
+type UserGetter interface {
+	GetUser(userId int64) *user.VisibleUser
+	GetPreferences(userId int64) (*user.Preferences, error)
+}
+
+// WorkspaceGetter is used for checking that the assignee of a todo is a member of its workspace.
+// It is implemented by the workspace service
+type WorkspaceGetter interface {
+	GetWorkspace(workspaceId int, userId int64) (*workspace.Workspace, error)
+}
+
 type Service struct {
 	Repository IRepository
+	Projects   ProjectGetter
+	// Searcher is the repository itself if it supports search
+	Searcher Searcher
+	// Attachments is optional. The files of the attachments are kept if it isn't set
+	Attachments AttachmentCleaner
+	// Timers is optional. The timers of the todos in the trash keep running if it isn't set
+	Timers TimerStopper
+	// Users is optional. Assignees are not notified if it isn't set
+	Users UserGetter
+	// Workspaces is optional. Only the users who can see the parent or the project of a new todo can be assigned to it
+	// if it isn't set
+	Workspaces WorkspaceGetter
+	// SendEmail sends the assignment emails. It is email.Send unless it is replaced in the tests
+	SendEmail func(data email.SendEmailData) error
+}
+
+func NewTodoService(repo IRepository, projects ProjectGetter) *Service {
+	service := &Service{Repository: repo, Projects: projects, SendEmail: email.Send}
+	if searcher, ok := repo.(Searcher); ok {
+		service.Searcher = searcher
+	}
+	return service
+}
+
+func (service *Service) GetAllTodos(userId int64, options *ListOptions) ([]Todo, error) {
+	if err := normalizeListOptions(options); err != nil {
+		return nil, err
+	}
+	return service.Repository.GetAllTodos(userId, options)
+}
+
+// normalizeListOptions validates the timezone and normalizes the tags of the list options
+func normalizeListOptions(options *ListOptions) error {
+	if options == nil {
+		return nil
+	}
+
+	if options.Timezone != nil {
+		if _, err := time.LoadLocation(*options.Timezone); err != nil {
+			return ErrTimezoneNotValid.With(*options.Timezone)
+		}
+	}
+
+	if len(options.Tags) > 0 {
+		tags, err := tag.NormalizeNames(options.Tags)
+		if err != nil {
+			return err
+		}
+		options.Tags = tags
+	}
+	return nil
 }
 
-func NewTodoService(repo IRepository) *Service {
-	return &Service{Repository: repo}
+// ListTodos returns a page of the todos. The page has a cursor for the next page if there are more todos
+func (service *Service) ListTodos(userId int64, options *ListOptions) (*TodoPage, error) {
+	limit := options.Limit
+
+	// one more todo is fetched to know if there is a next page
+	pageOptions := *options
+	if limit > 0 {
+		pageOptions.Limit = limit + 1
+	}
+
+	todos, err := service.GetAllTodos(userId, &pageOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	// the estimates are summed over all the todos in the list, not only over the ones in the page
+	estimates, err := service.Repository.SumEstimates(userId, &pageOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &TodoPage{Items: todos, Estimates: estimates}
+	if limit > 0 && len(todos) > limit {
+		page.Items = todos[:limit]
+		nextCursor := newCursor(&page.Items[limit-1], options.Sort).Encode()
+		page.NextCursor = &nextCursor
+	}
+	return page, nil
 }
 
-func (service *Service) GetAllTodos(userId int64) ([]Todo, error) {
-	return service.Repository.GetAllTodos(userId)
+// SearchTodos finds the todos matching the words of the query. Words are matched as prefixes
+func (service *Service) SearchTodos(userId int64, query string, options *ListOptions) ([]SearchResult, error) {
+	if service.Searcher == nil {
+		return nil, ErrSearchNotSupported
+	}
+
+	if len(SearchTerms(query)) == 0 {
+		return nil, ErrSearchQueryEmpty
+	}
+
+	if err := normalizeListOptions(options); err != nil {
+		return nil, err
+	}
+	return service.Searcher.SearchTodos(userId, query, options)
 }
 
 func (service *Service) CreateTodo(data *CreateTodoData, userId int64) (*Todo, error) {
 	if data.Title == "" {
-		return nil, errors.New("title need to be sent")
+		return nil, ErrTitleEmpty
+	}
+
+	if err := validateDueFields(data.DueTimezone, data.ReminderOffset); err != nil {
+		return nil, err
+	}
+
+	if err := validateNotes(data.Notes); err != nil {
+		return nil, err
+	}
+
+	if err := validateEstimate(data.Estimate); err != nil {
+		return nil, err
+	}
+
+	if err := service.validateProject(data.ProjectId, data.WorkspaceId, userId); err != nil {
+		return nil, err
+	}
+
+	if data.ParentId != nil {
+		if err := service.validateParent(nil, *data.ParentId, userId); err != nil {
+			return nil, err
+		}
+	}
+
+	if data.AssigneeId != nil {
+		err := service.validateAssignee(*data.AssigneeId, nil, data.ParentId, data.ProjectId, data.WorkspaceId, userId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	blockerIds, blocked, err := service.validateBlockers(nil, data.BlockedBy, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	workflow, err := service.workflowOf(data.ProjectId, userId)
+	if err != nil {
+		return nil, err
+	}
+	status := workflow.Initial()
+	if data.Status != nil {
+		if workflow.Status(*data.Status) == nil {
+			return nil, ErrStatusNotValid.With(*data.Status)
+		}
+		status = *data.Status
+	}
+	if status == workflow.Terminal() && blocked {
+		return nil, ErrTodoBlocked
+	}
+
+	recurrence, err := normalizeRecurrence(data.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+	if recurrence != nil && data.DueDate == nil {
+		return nil, ErrRecurrenceNeedsDueDate
 	}
 
 	createTodoData := NewTodo(data.Title)
-	return service.Repository.CreateTodo(createTodoData, userId)
+	createTodoData.Notes = data.Notes
+	createTodoData.DueDate = data.DueDate
+	createTodoData.DueTimezone = data.DueTimezone
+	createTodoData.ReminderOffset = data.ReminderOffset
+	if data.Priority != nil {
+		createTodoData.Priority = *data.Priority
+	}
+	createTodoData.Estimate = data.Estimate
+	createTodoData.ProjectId = data.ProjectId
+	createTodoData.ParentId = data.ParentId
+	if data.AutoComplete != nil {
+		createTodoData.AutoComplete = *data.AutoComplete
+	}
+	createTodoData.Recurrence = recurrence
+	createTodoData.AssigneeId = data.AssigneeId
+	createTodoData.WorkspaceId = data.WorkspaceId
+	createTodoData.BlockedBy = blockerIds
+	createTodoData.Status = status
+	createTodoData.Done = status == workflow.Terminal()
+
+	tags, err := tag.NormalizeNames(data.Tags)
+	if err != nil {
+		return nil, err
+	}
+	createTodoData.Tags = tags
+
+	created, err := service.Repository.CreateTodo(createTodoData, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if created.AssigneeId != nil {
+		service.notifyAssignee(created, userId)
+	}
+	return created, nil
 }
+
+// QuickAddTodo creates a todo from a title like "Pay rent every 1st of month at 9am #home !high".
+// The recognized parts are removed from the title and fill the fields that are not sent. Dates are calculated
+// in the due timezone, or in UTC if it isn't sent. An @name that isn't one of the projects is kept in the title
+func (service *Service) QuickAddTodo(data *CreateTodoData, userId int64) (*QuickAddResult, error) {
+	if err := validateDueFields(data.DueTimezone, nil); err != nil {
+		return nil, err
+	}
+
+	location := time.UTC
+	if data.DueTimezone != nil {
+		location, _ = time.LoadLocation(*data.DueTimezone)
+	}
+
+	// the projects are only fetched if the title has an @name
+	var projects []project.Project
+	var projectsLoaded bool
+	var projectsErr error
+	var projectId *int
+	isProject := func(name string) bool {
+		if !projectsLoaded {
+			projects, projectsErr = service.Projects.GetAllProjects(userId, data.WorkspaceId, false)
+			projectsLoaded = true
+		}
+		if id, ok := projectNamed(projects, name); ok {
+			projectId = &id
+			return true
+		}
+		return false
+	}
+
+	recognized := ParseQuickAdd(data.Title, time.Now().In(location), isProject)
+	if projectsErr != nil {
+		return nil, projectsErr
+	}
+	data.Title = recognized.Title
+
+	if data.DueDate == nil {
+		data.DueDate = recognized.DueDate
+	}
+	if data.Recurrence == nil {
+		data.Recurrence = recognized.Recurrence
+	}
+	if data.Priority == nil {
+		data.Priority = recognized.Priority
+	}
+	data.Tags = append(data.Tags, recognized.Tags...)
+
+	if data.ProjectId == nil {
+		data.ProjectId = projectId
+	}
+
+	created, err := service.CreateTodo(data, userId)
+	if err != nil {
+		return nil, err
+	}
+	return &QuickAddResult{Todo: *created, Recognized: *recognized}, nil
+}
+
+// projectNamed returns the id of the project with the name. Names are matched case-insensitively,
+// and dashes and underscores match spaces so that "@home-renovation" finds "Home renovation"
+func projectNamed(projects []project.Project, name string) (int, bool) {
+	normalize := strings.NewReplacer("-", " ", "_", " ")
+	for _, p := range projects {
+		if strings.EqualFold(p.Name, name) || strings.EqualFold(p.Name, normalize.Replace(name)) {
+			return p.Id, true
+		}
+	}
+	return 0, false
+}
+
 func (service *Service) UpdateTodo(data *UpdateTodoData, userId int64) (*Todo, error) {
-	return service.Repository.UpdateTodo(data, userId)
+	if data.Id == nil {
+		return nil, ErrIdRequired
+	}
+
+	scope := ScopeThis
+	if data.Scope != nil {
+		if *data.Scope != ScopeThis && *data.Scope != ScopeSeries {
+			return nil, ErrScopeNotValid
+		}
+		scope = *data.Scope
+	}
+
+	if err := validateDueFields(data.DueTimezone, data.ReminderOffset); err != nil {
+		return nil, err
+	}
+
+	if err := validateNotes(data.Notes); err != nil {
+		return nil, err
+	}
+
+	if err := validateEstimate(data.Estimate); err != nil {
+		return nil, err
+	}
+
+	if data.Tags != nil {
+		tags, err := tag.NormalizeNames(*data.Tags)
+		if err != nil {
+			return nil, err
+		}
+		data.Tags = &tags
+	}
+
+	if data.ParentId != nil {
+		if err := service.validateParent(data.Id, *data.ParentId, userId); err != nil {
+			return nil, err
+		}
+	}
+
+	recurrence, err := normalizeRecurrence(data.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+	data.Recurrence = recurrence
+
+	// the current state is needed for checking the due date of recurring todos and for noticing the completion
+	current, err := service.Repository.GetTodo(*data.Id, userId)
+	if err != nil {
+		return nil, err
+	}
+	if !current.Role.Includes(share.RoleEditor) {
+		return nil, ErrReadOnly
+	}
+
+	// todos can only be moved to the projects in their own workspace
+	if err := service.validateProject(data.ProjectId, current.WorkspaceId, userId); err != nil {
+		return nil, err
+	}
+
+	assigneeChanged := data.AssigneeId != nil && (current.AssigneeId == nil || *current.AssigneeId != *data.AssigneeId)
+	if assigneeChanged {
+		if err := service.validateAssignee(*data.AssigneeId, data.Id, data.ParentId, data.ProjectId, nil, userId); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := service.resolveStatus(data, current, userId); err != nil {
+		return nil, err
+	}
+
+	willBeBlocked := current.Blocked
+	if data.BlockedBy != nil {
+		blockerIds, blocked, err := service.validateBlockers(data.Id, *data.BlockedBy, userId)
+		if err != nil {
+			return nil, err
+		}
+		data.BlockedBy = &blockerIds
+		willBeBlocked = blocked
+	}
+
+	willBeDone := current.Done
+	if data.Done != nil {
+		willBeDone = *data.Done
+	}
+	if willBeDone && !current.Done && willBeBlocked {
+		return nil, ErrTodoBlocked
+	}
+	if data.Archived != nil && *data.Archived && !willBeDone {
+		return nil, ErrArchiveNotDone
+	}
+
+	willRecur := (data.Recurrence != nil || current.Recurrence != nil) && !slices.Contains(data.Clear, "recurrence")
+	willHaveDueDate := data.DueDate != nil || (current.DueDate != nil && !slices.Contains(data.Clear, "dueDate"))
+	if willRecur && !willHaveDueDate {
+		return nil, ErrRecurrenceNeedsDueDate
+	}
+
+	// the next occurrence and the edits of the series are applied in the same transaction as the todo,
+	// so the series doesn't end or get half edited if one of them fails
+	update := &OccurrenceUpdate{Todo: data}
+	if willBeDone && !current.Done && willRecur {
+		update.NextOccurrence = func(completed *Todo) (*Todo, error) {
+			return service.nextOccurrence(completed, userId)
+		}
+	}
+	if scope == ScopeSeries && current.SeriesId != nil {
+		update.Series, update.SeriesIds, err = service.seriesUpdate(current, data, userId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var updatedTodo *Todo
+	if update.NextOccurrence != nil || len(update.SeriesIds) > 0 {
+		updatedTodo, err = service.Repository.UpdateOccurrence(update, userId)
+	} else {
+		updatedTodo, err = service.Repository.UpdateTodo(data, userId)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if assigneeChanged {
+		service.notifyAssignee(updatedTodo, userId)
+	}
+
+	log := logger.Get()
+	justCompleted := !current.Done && updatedTodo.Done
+
+	if justCompleted && updatedTodo.ParentId != nil {
+		if completeErr := service.completeParent(*updatedTodo.ParentId, userId); completeErr != nil {
+			log.Error().Err(completeErr).Int("parentId", *updatedTodo.ParentId).Msg("Couldn't complete the parent todo")
+		}
+	}
+	return updatedTodo, nil
+}
+
+// nextOccurrence returns the next occurrence of a completed recurring todo with the shifted due date.
+// It returns nil if the series has ended or the next occurrence was already created
+func (service *Service) nextOccurrence(t *Todo, userId int64) (*Todo, error) {
+	if t.DueDate == nil {
+		return nil, ErrRecurrenceNeedsDueDate
+	}
+
+	recurrence, err := ParseRecurrence(*t.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+
+	if recurrence.Count != nil && t.Occurrence >= *recurrence.Count {
+		return nil, nil
+	}
+
+	nextDueDate, ok := recurrence.Next(*t.DueDate)
+	if !ok {
+		return nil, nil
+	}
+
+	// completing a reopened todo again shouldn't create the same occurrence twice
+	occurrences, err := service.Repository.GetAllTodos(userId, &ListOptions{SeriesId: t.SeriesId, IncludeArchived: true})
+	if err != nil {
+		return nil, err
+	}
+	for _, occurrence := range occurrences {
+		if occurrence.Occurrence > t.Occurrence {
+			return nil, nil
+		}
+	}
+
+	next := NewTodo(t.Title)
+	next.Notes = t.Notes
+	next.DueDate = &nextDueDate
+	next.DueTimezone = t.DueTimezone
+	next.ReminderOffset = t.ReminderOffset
+	next.Priority = t.Priority
+	next.Estimate = t.Estimate
+	next.ProjectId = t.ProjectId
+	next.Tags = t.Tags
+	next.ParentId = t.ParentId
+	next.AutoComplete = t.AutoComplete
+	next.Recurrence = t.Recurrence
+	next.SeriesId = t.SeriesId
+	next.AssigneeId = t.AssigneeId
+	next.WorkspaceId = t.WorkspaceId
+	next.Occurrence = t.Occurrence + 1
+
+	// the next occurrence starts over in the workflow
+	workflow, err := service.workflowOf(t.ProjectId, userId)
+	if err != nil {
+		return nil, err
+	}
+	next.Status = workflow.Initial()
+
+	return next, nil
+}
+
+// seriesUpdate returns the fields that are shared by the series and the ids of the other open occurrences of the series
+// to apply them to. Due dates and the done state stay specific to each occurrence
+func (service *Service) seriesUpdate(current *Todo, data *UpdateTodoData, userId int64) (*UpdateTodoData, []int, error) {
+	seriesData := &UpdateTodoData{
+		Title:          data.Title,
+		Notes:          data.Notes,
+		DueTimezone:    data.DueTimezone,
+		ReminderOffset: data.ReminderOffset,
+		Priority:       data.Priority,
+		Estimate:       data.Estimate,
+		ProjectId:      data.ProjectId,
+		Tags:           data.Tags,
+		AutoComplete:   data.AutoComplete,
+		Recurrence:     data.Recurrence,
+		AssigneeId:     data.AssigneeId,
+	}
+	for _, field := range data.Clear {
+		if field != "dueDate" {
+			seriesData.Clear = append(seriesData.Clear, field)
+		}
+	}
+
+	occurrences, err := service.Repository.GetAllTodos(userId, &ListOptions{SeriesId: current.SeriesId})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var ids []int
+	for _, occurrence := range occurrences {
+		if occurrence.Id != current.Id && !occurrence.Done {
+			ids = append(ids, occurrence.Id)
+		}
+	}
+	return seriesData, ids, nil
+}
+
+// completeParent marks the parent as done if it has auto complete enabled and all of its subtasks are done.
+// Completing the parent may complete its own parent too
+func (service *Service) completeParent(parentId int, userId int64) error {
+	parent, err := service.Repository.GetTodo(parentId, userId)
+	if err != nil {
+		return err
+	}
+
+	if parent.Done || !parent.AutoComplete || parent.Progress == nil || parent.Progress.Done < parent.Progress.Total {
+		return nil
+	}
+
+	done := true
+	_, err = service.UpdateTodo(&UpdateTodoData{Id: &parentId, Done: &done}, userId)
+	return err
+}
+
+// validateParent checks if the user can change the parent and the todo is not one of the parents of the parent.
+// todoId is nil for the todos that are being created
+func (service *Service) validateParent(todoId *int, parentId int, userId int64) error {
+	if todoId != nil && *todoId == parentId {
+		return ErrParentCycle
+	}
+
+	ancestorIds, err := service.Repository.GetAncestorIds(parentId, userId)
+	if err != nil {
+		return err
+	}
+
+	// the parent doesn't exist or the user can't change it
+	if len(ancestorIds) == 0 {
+		return ErrParentNotValid
+	}
+
+	if todoId != nil {
+		for _, ancestorId := range ancestorIds {
+			if ancestorId == *todoId {
+				return ErrParentCycle
+			}
+		}
+	}
+	return nil
+}
+
+// workflowOf returns the workflow of the project, or the default workflow for the todos without a project
+func (service *Service) workflowOf(projectId *int, userId int64) (*project.Workflow, error) {
+	if projectId == nil {
+		return project.DefaultWorkflow(), nil
+	}
+
+	p, err := service.Projects.GetProject(*projectId, userId)
+	if errors.Is(err, project.ErrProjectNotFound) {
+		return nil, ErrProjectNotValid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Workflow == nil {
+		return project.DefaultWorkflow(), nil
+	}
+	return p.Workflow, nil
+}
+
+// resolveStatus keeps the status and done of the todo in sync. Setting the status sets done, and setting done moves
+// the todo to the terminal status or back to an open one. The transitions of the workflow are checked unless the todo
+// is moved to another project, where it keeps its status only if the workflow of the new project has it
+func (service *Service) resolveStatus(data *UpdateTodoData, current *Todo, userId int64) error {
+	projectId := current.ProjectId
+	projectChanged := false
+	if data.ProjectId != nil {
+		projectId = data.ProjectId
+		projectChanged = current.ProjectId == nil || *current.ProjectId != *data.ProjectId
+	}
+	if slices.Contains(data.Clear, "projectId") {
+		projectId = nil
+		projectChanged = current.ProjectId != nil
+	}
+
+	if data.Status == nil && data.Done == nil && !projectChanged {
+		return nil
+	}
+
+	workflow, err := service.workflowOf(projectId, userId)
+	if err != nil {
+		return err
+	}
+
+	status := current.Status
+	done := current.Done
+	switch {
+	case data.Status != nil:
+		if workflow.Status(*data.Status) == nil {
+			return ErrStatusNotValid.With(*data.Status)
+		}
+		status = *data.Status
+		done = status == workflow.Terminal()
+		if data.Done != nil && *data.Done != done {
+			return ErrStatusNotValid.With("done doesn't match the status")
+		}
+	case data.Done != nil:
+		done = *data.Done
+		if done {
+			status = workflow.Terminal()
+		} else if status == workflow.Terminal() || workflow.Status(status) == nil {
+			status = workflow.Reopened()
+		}
+	default:
+		if done {
+			status = workflow.Terminal()
+		} else if status == workflow.Terminal() || workflow.Status(status) == nil {
+			status = workflow.Initial()
+		}
+	}
+
+	if !projectChanged && !workflow.CanMove(current.Status, status) {
+		return ErrTransitionNotAllowed.With(fmt.Sprintf("%s to %s", current.Status, status))
+	}
+
+	if status != current.Status {
+		data.Status = &status
+	}
+	if data.Done != nil || done != current.Done {
+		data.Done = &done
+	}
+	return nil
+}
+
+// validateBlockers checks that the user can see the blocking todos and that they don't make a cycle.
+// todoId is nil for the todos that are being created, since nothing can depend on them yet.
+// It returns the ids without duplicates and whether any of the blocking todos is not done
+func (service *Service) validateBlockers(todoId *int, blockerIds []int, userId int64) ([]int, bool, error) {
+	ids := slices.Clone(blockerIds)
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+
+	blocked := false
+	for _, blockerId := range ids {
+		if todoId != nil && blockerId == *todoId {
+			return nil, false, ErrDependencyCycle
+		}
+
+		blocker, err := service.Repository.GetTodo(blockerId, userId)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, ErrDependencyNotValid.With(fmt.Sprintf("%d", blockerId))
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		blocked = blocked || !blocker.Done
+	}
+
+	// the todo can't be blocked by a todo that is already waiting for it
+	if todoId != nil && len(ids) > 0 {
+		indirectIds, err := service.Repository.GetBlockerIds(ids)
+		if err != nil {
+			return nil, false, err
+		}
+		if slices.Contains(indirectIds, *todoId) {
+			return nil, false, ErrDependencyCycle
+		}
+	}
+	return ids, blocked, nil
+}
+
+// GetDependencyGraph returns the todos of the project in topological order with the dependencies between them
+func (service *Service) GetDependencyGraph(projectId int, userId int64) (*DependencyGraph, error) {
+	if _, err := service.Projects.GetProject(projectId, userId); err != nil {
+		if errors.Is(err, project.ErrProjectNotFound) {
+			return nil, ErrProjectNotValid
+		}
+		return nil, err
+	}
+
+	todos, err := service.Repository.GetAllTodos(userId, &ListOptions{ProjectId: &projectId})
+	if err != nil {
+		return nil, err
+	}
+
+	todoIds := make([]int, len(todos))
+	for i, t := range todos {
+		todoIds[i] = t.Id
+	}
+
+	dependencies, err := service.Repository.GetDependencies(todoIds)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DependencyGraph{Todos: sortByDependencies(todos, dependencies), Dependencies: dependencies}, nil
 }
 
-func (service *Service) RemoveTodo(todoId int, userId int64) (*Todo, error) {
-	return service.Repository.RemoveTodo(todoId, userId)
+// GetBoard returns the todos of the project grouped by the statuses of its workflow
+func (service *Service) GetBoard(projectId int, userId int64) (*Board, error) {
+	workflow, err := service.workflowOf(&projectId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	todos, err := service.Repository.GetAllTodos(userId, &ListOptions{ProjectId: &projectId})
+	if err != nil {
+		return nil, err
+	}
+	return buildBoard(projectId, workflow, todos), nil
 }
 
+// GetBurndown returns the remaining estimate of the project at the end of each day in the range
+func (service *Service) GetBurndown(projectId int, userId int64, options *BurndownOptions) (*Burndown, error) {
+	if err := validateBurndownOptions(options); err != nil {
+		return nil, err
+	}
+
+	p, err := service.Projects.GetProject(projectId, userId)
+	if errors.Is(err, project.ErrProjectNotFound) {
+		return nil, ErrProjectNotValid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	days, err := service.Repository.GetBurndown(projectId, options)
+	if err != nil {
+		return nil, err
+	}
+	return &Burndown{ProjectId: projectId, Unit: p.EstimateUnit, Days: days}, nil
+}
+
+// RemoveTodo moves the todo to the trash. It is deleted permanently with the files of its attachments if permanent is set
+func (service *Service) RemoveTodo(todoId int, userId int64, permanent bool) (*Todo, error) {
+	if permanent {
+		removed, err := service.Repository.DeleteTodo(todoId, userId)
+		if err != nil {
+			return nil, err
+		}
+		service.cleanAttachments()
+		return removed, nil
+	}
+
+	removed, err := service.Repository.RemoveTodo(todoId, userId)
+	if err != nil {
+		return nil, err
+	}
+	service.stopTimers()
+	return removed, nil
+}
+
+// stopTimers stops the timers of the todos that were just moved to the trash. Errors are only logged
+// since the removal is already done
+func (service *Service) stopTimers() {
+	if service.Timers == nil {
+		return
+	}
+
+	if _, err := service.Timers.StopTrashedTimers(); err != nil {
+		log := logger.Get()
+		log.Error().Err(err).Msg("Couldn't stop the timers of the removed todos")
+	}
+}
+
+// cleanAttachments deletes the files left behind by the permanently deleted todos.
+// The files that fail stay detached and are picked up by the next call
+func (service *Service) cleanAttachments() {
+	if service.Attachments == nil {
+		return
+	}
+
+	if _, err := service.Attachments.RemoveDetachedAttachments(); err != nil {
+		log := logger.Get()
+		log.Error().Err(err).Msg("Couldn't delete the attachments of the deleted todos")
+	}
+}
+
+// GetTrash returns the todos in the trash of the user
+func (service *Service) GetTrash(userId int64) ([]Todo, error) {
+	return service.Repository.GetTrash(userId)
+}
+
+// RestoreTodo takes the todo out of the trash
+func (service *Service) RestoreTodo(todoId int, userId int64) (*Todo, error) {
+	return service.Repository.RestoreTodo(todoId, userId)
+}
+
+// PurgeTrash permanently deletes the todos that have been in the trash for longer than the retention
+func (service *Service) PurgeTrash(retention time.Duration) (int64, error) {
+	purged, err := service.Repository.PurgeTrash(time.Now().Add(-retention))
+	if err != nil {
+		return 0, err
+	}
+
+	if purged > 0 {
+		service.cleanAttachments()
+	}
+	return purged, nil
+}
+
+// RunTrashPurge purges the trash right away and then once in every interval until the context is done
+func (service *Service) RunTrashPurge(ctx context.Context, retention time.Duration, interval time.Duration) {
+	log := logger.Get()
+	runPeriodically(ctx, interval, func() {
+		purged, err := service.PurgeTrash(retention)
+		if err != nil {
+			log.Error().Err(err).Msg("Couldn't purge the trash")
+		} else if purged > 0 {
+			log.Info().Int64("count", purged).Msg("Purged the todos in the trash")
+		}
+	})
+}
+
+// ArchiveCompleted archives all the done todos of the user
+func (service *Service) ArchiveCompleted(userId int64) (int64, error) {
+	return service.Repository.ArchiveCompleted(userId)
+}
+
+// AutoArchive archives the todos of all users that have been done for longer than the given duration
+func (service *Service) AutoArchive(after time.Duration) (int64, error) {
+	return service.Repository.ArchiveCompletedBefore(time.Now().Add(-after))
+}
+
+// RunAutoArchive auto archives the done todos right away and then once in every interval until the context is done
+func (service *Service) RunAutoArchive(ctx context.Context, after time.Duration, interval time.Duration) {
+	log := logger.Get()
+	runPeriodically(ctx, interval, func() {
+		archived, err := service.AutoArchive(after)
+		if err != nil {
+			log.Error().Err(err).Msg("Couldn't archive the done todos")
+		} else if archived > 0 {
+			log.Info().Int64("count", archived).Msg("Archived the done todos")
+		}
+	})
+}
+
+// runPeriodically runs the job right away and then once in every interval until the context is done
+func runPeriodically(ctx context.Context, interval time.Duration, job func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		job()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ReorderTodo places the todo right before or right after another todo of the user
+func (service *Service) ReorderTodo(data *ReorderTodoData, userId int64) (*Todo, error) {
+	if data.Id == nil {
+		return nil, ErrIdRequired
+	}
+
+	// exactly one of the targets needs to be sent
+	if (data.BeforeId == nil) == (data.AfterId == nil) {
+		return nil, ErrReorderTargetNotValid
+	}
+
+	if (data.BeforeId != nil && *data.BeforeId == *data.Id) || (data.AfterId != nil && *data.AfterId == *data.Id) {
+		return nil, ErrReorderTargetNotValid
+	}
+
+	return service.Repository.ReorderTodo(data, userId)
+}
+
+// GetTodo returns the todo with its subtasks nested under it
 func (service *Service) GetTodo(todoId int, userId int64) (*Todo, error) {
-	return service.Repository.GetTodo(todoId, userId)
+	t, err := service.Repository.GetTodo(todoId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.Progress == nil {
+		return t, nil
+	}
+
+	descendants, err := service.Repository.GetDescendants(todoId, userId)
+	if err != nil {
+		return nil, err
+	}
+	buildSubtaskTree(t, descendants)
+	return t, nil
+}
+
+// validateProject checks if the project exists, the user can add todos to it and it is not archived.
+// The project needs to be in the given workspace, or be a personal project if the workspace is nil
+func (service *Service) validateProject(projectId *int, workspaceId *int, userId int64) error {
+	if projectId == nil {
+		return nil
+	}
+
+	p, err := service.Projects.GetProject(*projectId, userId)
+	if errors.Is(err, project.ErrProjectNotFound) {
+		return ErrProjectNotValid
+	}
+	if err != nil {
+		return err
+	}
+
+	if p.Archived || !p.Role.Includes(share.RoleEditor) {
+		return ErrProjectNotValid
+	}
+
+	sameWorkspace := (p.WorkspaceId == nil && workspaceId == nil) ||
+		(p.WorkspaceId != nil && workspaceId != nil && *p.WorkspaceId == *workspaceId)
+	if !sameWorkspace {
+		return ErrProjectNotValid
+	}
+	return nil
+}
+
+// validateAssignee checks that the assignee can see the todo. Todos that are being created have a nil todoId,
+// and they are visible to the users who can see their parent, their project or their workspace
+func (service *Service) validateAssignee(
+	assigneeId int64, todoId *int, parentId *int, projectId *int, workspaceId *int, userId int64,
+) error {
+	if assigneeId == userId {
+		return nil
+	}
+
+	for _, id := range []*int{todoId, parentId} {
+		if id == nil {
+			continue
+		}
+		_, err := service.Repository.GetTodo(*id, assigneeId)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+	}
+
+	if projectId != nil {
+		_, err := service.Projects.GetProject(*projectId, assigneeId)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, project.ErrProjectNotFound) {
+			return err
+		}
+	}
+
+	if workspaceId != nil && service.Workspaces != nil {
+		_, err := service.Workspaces.GetWorkspace(*workspaceId, assigneeId)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, workspace.ErrWorkspaceNotFound) {
+			return err
+		}
+	}
+	return ErrAssigneeNotValid
+}
+
+// notifyAssignee emails the assignee of the todo unless they assigned it to themselves or they opted out.
+// The todo is already saved, so a failure is only logged
+func (service *Service) notifyAssignee(t *Todo, userId int64) {
+	if service.Users == nil || t.AssigneeId == nil || *t.AssigneeId == userId {
+		return
+	}
+
+	log := logger.Get()
+	preferences, err := service.Users.GetPreferences(*t.AssigneeId)
+	if err != nil {
+		log.Error().Err(err).Int64("assigneeId", *t.AssigneeId).Msg("Couldn't get the preferences of the assignee")
+		return
+	}
+	if !preferences.NotifyOnAssignment {
+		return
+	}
+
+	assignee := service.Users.GetUser(*t.AssigneeId)
+	assigner := service.Users.GetUser(userId)
+	if assignee == nil || assigner == nil {
+		return
+	}
+
+	sendErr := service.SendEmail(email.SendEmailData{
+		To:      []string{assignee.Email},
+		Subject: fmt.Sprintf("%s assigned a todo to you", assigner.Username),
+		Message: fmt.Sprintf("Hi %s,\r\n\r\n%s assigned \"%s\" to you.", assignee.Username, assigner.Username, t.Title),
+	})
+	if sendErr != nil && !errors.Is(sendErr, email.ErrServiceNotEnabled) {
+		log.Error().Err(sendErr).Int("todoId", t.Id).Msg("Couldn't email the assignee")
+	}
+}
+
+// normalizeRecurrence returns the canonical form of the recurrence rule if it is sent
+func normalizeRecurrence(rule *string) (*string, error) {
+	if rule == nil {
+		return nil, nil
+	}
+
+	recurrence, err := ParseRecurrence(*rule)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized := recurrence.String()
+	return &normalized, nil
+}
+
+// validateDueFields checks the timezone and the reminder offset of a todo if they are sent
+func validateDueFields(timezone *string, reminderOffset *int) error {
+	if timezone != nil {
+		if _, err := time.LoadLocation(*timezone); err != nil || *timezone == "" {
+			return ErrTimezoneNotValid
+		}
+	}
+
+	if reminderOffset != nil && *reminderOffset < 0 {
+		return ErrReminderOffsetNotValid
+	}
+	return nil
+}
+
+// CreateView saves a named view after checking that its criteria can be listed
+func (service *Service) CreateView(data *CreateViewData, userId int64) (*View, error) {
+	name, err := validateViewName(data.Name)
+	if err != nil {
+		return nil, err
+	}
+	data.Name = name
+
+	if err := data.Criteria.Validate(); err != nil {
+		return nil, err
+	}
+	return service.Repository.CreateView(data, userId)
+}
+
+func (service *Service) GetAllViews(userId int64) ([]View, error) {
+	return service.Repository.GetAllViews(userId)
+}
+
+func (service *Service) GetView(viewId int, userId int64) (*View, error) {
+	return service.Repository.GetView(viewId, userId)
+}
+
+func (service *Service) UpdateView(data *UpdateViewData, userId int64) (*View, error) {
+	if data.Id == nil {
+		return nil, ErrIdRequired
+	}
+
+	if data.Name != nil {
+		name, err := validateViewName(*data.Name)
+		if err != nil {
+			return nil, err
+		}
+		data.Name = &name
+	}
+
+	if data.Criteria != nil {
+		if err := data.Criteria.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return service.Repository.UpdateView(data, userId)
+}
+
+func (service *Service) RemoveView(viewId int, userId int64) (*View, error) {
+	return service.Repository.RemoveView(viewId, userId)
+}
+
+// CreateTemplate saves a named template after checking its items
+func (service *Service) CreateTemplate(data *CreateTemplateData, userId int64) (*Template, error) {
+	name, err := validateTemplateName(data.Name)
+	if err != nil {
+		return nil, err
+	}
+	data.Name = name
+
+	items, err := normalizeTemplateItems(data.Items)
+	if err != nil {
+		return nil, err
+	}
+	data.Items = items
+	return service.Repository.CreateTemplate(data, userId)
+}
+
+func (service *Service) GetAllTemplates(userId int64) ([]Template, error) {
+	return service.Repository.GetAllTemplates(userId)
+}
+
+func (service *Service) GetTemplate(templateId int, userId int64) (*Template, error) {
+	return service.Repository.GetTemplate(templateId, userId)
+}
+
+func (service *Service) UpdateTemplate(data *UpdateTemplateData, userId int64) (*Template, error) {
+	if data.Id == nil {
+		return nil, ErrIdRequired
+	}
+
+	if data.Name != nil {
+		name, err := validateTemplateName(*data.Name)
+		if err != nil {
+			return nil, err
+		}
+		data.Name = &name
+	}
+
+	if data.Items != nil {
+		items, err := normalizeTemplateItems(*data.Items)
+		if err != nil {
+			return nil, err
+		}
+		data.Items = &items
+	}
+	return service.Repository.UpdateTemplate(data, userId)
+}
+
+func (service *Service) RemoveTemplate(templateId int, userId int64) (*Template, error) {
+	return service.Repository.RemoveTemplate(templateId, userId)
+}
+
+// InstantiateTemplate creates the todos of the template in one go. The todos are put in the first status of the
+// workflow of the project, and their due dates are calculated from the start date in the due timezone
+func (service *Service) InstantiateTemplate(templateId int, data *InstantiateTemplateData, userId int64) ([]Todo, error) {
+	if data.StartDate == nil {
+		return nil, ErrStartDateRequired
+	}
+
+	if err := validateDueFields(data.DueTimezone, nil); err != nil {
+		return nil, err
+	}
+
+	if err := service.validateProject(data.ProjectId, data.WorkspaceId, userId); err != nil {
+		return nil, err
+	}
+
+	template, err := service.Repository.GetTemplate(templateId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	workflow, err := service.workflowOf(data.ProjectId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	start := *data.StartDate
+	if data.DueTimezone != nil {
+		location, _ := time.LoadLocation(*data.DueTimezone)
+		start = start.In(location)
+	}
+
+	base := NewTodo("")
+	base.DueTimezone = data.DueTimezone
+	base.ProjectId = data.ProjectId
+	base.WorkspaceId = data.WorkspaceId
+	base.Status = workflow.Initial()
+	base.Done = base.Status == workflow.Terminal()
+
+	return service.Repository.CreateTodoTree(templateTodos(template.Items, *base, start), userId)
 }
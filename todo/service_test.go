@@ -0,0 +1,1671 @@
+package todo
+
+import (
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/umtdemr/go-todo/email"
+	"github.com/umtdemr/go-todo/project"
+	"github.com/umtdemr/go-todo/share"
+	"github.com/umtdemr/go-todo/user"
+	"github.com/umtdemr/go-todo/workspace"
+	"strings"
+	"testing"
+	"time"
+)
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) CreateTodo(data *Todo, userId int64) (*Todo, error) {
+	args := m.Called(data, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Todo), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) GetAllTodos(userId int64, options *ListOptions) ([]Todo, error) {
+	args := m.Called(userId, options)
+	if args.Get(0) != nil {
+		return args.Get(0).([]Todo), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) GetTodo(todoId int, userId int64) (*Todo, error) {
+	args := m.Called(todoId, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Todo), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) UpdateTodo(data *UpdateTodoData, userId int64) (*Todo, error) {
+	args := m.Called(data, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Todo), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+// UpdateOccurrence records the next occurrence as a call to CreateOccurrence and the edits of the series
+// as a call to UpdateSeries like the repository applies them in the same transaction
+func (m *MockRepository) UpdateOccurrence(update *OccurrenceUpdate, userId int64) (*Todo, error) {
+	args := m.Called(update.Todo, userId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	updated := args.Get(0).(*Todo)
+	if update.NextOccurrence != nil {
+		next, err := update.NextOccurrence(updated)
+		if err != nil {
+			return nil, err
+		}
+		if next != nil {
+			m.MethodCalled("CreateOccurrence", next, userId)
+		}
+	}
+	if len(update.SeriesIds) > 0 {
+		m.MethodCalled("UpdateSeries", update.Series, update.SeriesIds, userId)
+	}
+	return updated, args.Error(1)
+}
+
+func (m *MockRepository) RemoveTodo(todoId int, userId int64) (*Todo, error) {
+	args := m.Called(todoId, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Todo), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) DeleteTodo(todoId int, userId int64) (*Todo, error) {
+	args := m.Called(todoId, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Todo), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) GetTrash(userId int64) ([]Todo, error) {
+	args := m.Called(userId)
+	if args.Get(0) != nil {
+		return args.Get(0).([]Todo), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) RestoreTodo(todoId int, userId int64) (*Todo, error) {
+	args := m.Called(todoId, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Todo), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) PurgeTrash(before time.Time) (int64, error) {
+	args := m.Called(before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepository) ArchiveCompleted(userId int64) (int64, error) {
+	args := m.Called(userId)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepository) ArchiveCompletedBefore(before time.Time) (int64, error) {
+	args := m.Called(before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepository) SearchTodos(userId int64, query string, options *ListOptions) ([]SearchResult, error) {
+	args := m.Called(userId, query, options)
+	if args.Get(0) != nil {
+		return args.Get(0).([]SearchResult), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) CreateView(data *CreateViewData, userId int64) (*View, error) {
+	args := m.Called(data, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*View), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) GetAllViews(userId int64) ([]View, error) {
+	args := m.Called(userId)
+	if args.Get(0) != nil {
+		return args.Get(0).([]View), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) GetView(viewId int, userId int64) (*View, error) {
+	args := m.Called(viewId, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*View), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) UpdateView(data *UpdateViewData, userId int64) (*View, error) {
+	args := m.Called(data, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*View), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) RemoveView(viewId int, userId int64) (*View, error) {
+	args := m.Called(viewId, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*View), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) CreateTodoTree(todos []Todo, userId int64) ([]Todo, error) {
+	args := m.Called(todos, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).([]Todo), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) CreateTemplate(data *CreateTemplateData, userId int64) (*Template, error) {
+	args := m.Called(data, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Template), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) GetAllTemplates(userId int64) ([]Template, error) {
+	args := m.Called(userId)
+	if args.Get(0) != nil {
+		return args.Get(0).([]Template), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) GetTemplate(templateId int, userId int64) (*Template, error) {
+	args := m.Called(templateId, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Template), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) UpdateTemplate(data *UpdateTemplateData, userId int64) (*Template, error) {
+	args := m.Called(data, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Template), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) RemoveTemplate(templateId int, userId int64) (*Template, error) {
+	args := m.Called(templateId, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Template), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) ReorderTodo(data *ReorderTodoData, userId int64) (*Todo, error) {
+	args := m.Called(data, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Todo), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) GetDescendants(todoId int, userId int64) ([]Todo, error) {
+	args := m.Called(todoId, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).([]Todo), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) GetAncestorIds(todoId int, userId int64) ([]int, error) {
+	args := m.Called(todoId, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).([]int), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) GetBlockerIds(todoIds []int) ([]int, error) {
+	args := m.Called(todoIds)
+	if args.Get(0) != nil {
+		return args.Get(0).([]int), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) GetDependencies(todoIds []int) ([]Dependency, error) {
+	args := m.Called(todoIds)
+	if args.Get(0) != nil {
+		return args.Get(0).([]Dependency), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) SumEstimates(userId int64, options *ListOptions) ([]project.EstimateSum, error) {
+	args := m.Called(userId, options)
+	return args.Get(0).([]project.EstimateSum), args.Error(1)
+}
+
+func (m *MockRepository) GetBurndown(projectId int, options *BurndownOptions) ([]BurndownDay, error) {
+	args := m.Called(projectId, options)
+	return args.Get(0).([]BurndownDay), args.Error(1)
+}
+
+type MockProjectGetter struct {
+	mock.Mock
+}
+
+func (m *MockProjectGetter) GetProject(projectId int, userId int64) (*project.Project, error) {
+	args := m.Called(projectId, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*project.Project), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockProjectGetter) GetAllProjects(userId int64, workspaceId *int, includeArchived bool) ([]project.Project, error) {
+	args := m.Called(userId, workspaceId, includeArchived)
+	if args.Get(0) != nil {
+		return args.Get(0).([]project.Project), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+type MockUserGetter struct {
+	mock.Mock
+}
+
+func (m *MockUserGetter) GetUser(userId int64) *user.VisibleUser {
+	args := m.Called(userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*user.VisibleUser)
+	}
+	return nil
+}
+
+func (m *MockUserGetter) GetPreferences(userId int64) (*user.Preferences, error) {
+	args := m.Called(userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*user.Preferences), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+type MockWorkspaceGetter struct {
+	mock.Mock
+}
+
+func (m *MockWorkspaceGetter) GetWorkspace(workspaceId int, userId int64) (*workspace.Workspace, error) {
+	args := m.Called(workspaceId, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*workspace.Workspace), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+type MockAttachmentCleaner struct {
+	mock.Mock
+}
+
+func (m *MockAttachmentCleaner) RemoveDetachedAttachments() (int64, error) {
+	args := m.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
+
+type MockTimerStopper struct {
+	mock.Mock
+}
+
+func (m *MockTimerStopper) StopTrashedTimers() (int64, error) {
+	args := m.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestCreateTodo(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockProjects := new(MockProjectGetter)
+	mockWorkspaces := new(MockWorkspaceGetter)
+	service := NewTodoService(mockRepo, mockProjects)
+	service.Workspaces = mockWorkspaces
+
+	dueDate := time.Now().Add(time.Hour)
+	validTimezone := "Europe/Istanbul"
+	invalidTimezone := "Mars/Olympus"
+	validOffset := 30
+	var teammate int64 = 2
+	negativeOffset := -5
+	projectId := 3
+	workspaceId := 4
+	recurrence := "FREQ=DAILY"
+	longNotes := strings.Repeat("ı", MaxNotesLength+1)
+
+	tests := []struct {
+		name          string
+		input         *CreateTodoData
+		setupMock     func()
+		expectedError error
+	}{
+		{
+			name:          "Title is empty",
+			input:         &CreateTodoData{},
+			setupMock:     func() {},
+			expectedError: ErrTitleEmpty,
+		},
+		{
+			name: "Timezone is not valid",
+			input: &CreateTodoData{
+				Title:       "title",
+				DueDate:     &dueDate,
+				DueTimezone: &invalidTimezone,
+			},
+			setupMock:     func() {},
+			expectedError: ErrTimezoneNotValid,
+		},
+		{
+			name: "Reminder offset is negative",
+			input: &CreateTodoData{
+				Title:          "title",
+				DueDate:        &dueDate,
+				ReminderOffset: &negativeOffset,
+			},
+			setupMock:     func() {},
+			expectedError: ErrReminderOffsetNotValid,
+		},
+		{
+			name: "Recurring todo without a due date",
+			input: &CreateTodoData{
+				Title:      "title",
+				Recurrence: &recurrence,
+			},
+			setupMock:     func() {},
+			expectedError: ErrRecurrenceNeedsDueDate,
+		},
+		{
+			name: "Notes are too long",
+			input: &CreateTodoData{
+				Title: "title",
+				Notes: &longNotes,
+			},
+			setupMock:     func() {},
+			expectedError: ErrNotesTooLong,
+		},
+		{
+			name: "Estimate is negative",
+			input: &CreateTodoData{
+				Title:    "title",
+				Estimate: &negativeOffset,
+			},
+			setupMock:     func() {},
+			expectedError: ErrEstimateNotValid,
+		},
+		{
+			name: "Project doesn't exist",
+			input: &CreateTodoData{
+				Title:     "title",
+				ProjectId: &projectId,
+			},
+			setupMock: func() {
+				mockProjects.On("GetProject", projectId, int64(1)).Return(nil, project.ErrProjectNotFound)
+			},
+			expectedError: ErrProjectNotValid,
+		},
+		{
+			name: "Project is archived",
+			input: &CreateTodoData{
+				Title:     "title",
+				ProjectId: &projectId,
+			},
+			setupMock: func() {
+				mockProjects.On("GetProject", projectId, int64(1)).Return(&project.Project{Archived: true}, nil)
+			},
+			expectedError: ErrProjectNotValid,
+		},
+		{
+			name: "Project is shared with the user as a viewer",
+			input: &CreateTodoData{
+				Title:     "title",
+				ProjectId: &projectId,
+			},
+			setupMock: func() {
+				mockProjects.On("GetProject", projectId, int64(1)).Return(&project.Project{Role: share.RoleViewer}, nil)
+			},
+			expectedError: ErrProjectNotValid,
+		},
+		{
+			name: "Assignee can't see a todo without a project",
+			input: &CreateTodoData{
+				Title:      "title",
+				AssigneeId: &teammate,
+			},
+			setupMock:     func() {},
+			expectedError: ErrAssigneeNotValid,
+		},
+		{
+			name: "Project is not in the workspace",
+			input: &CreateTodoData{
+				Title:       "title",
+				ProjectId:   &projectId,
+				WorkspaceId: &workspaceId,
+			},
+			setupMock: func() {
+				mockProjects.On("GetProject", projectId, int64(1)).Return(&project.Project{Role: share.RoleOwner}, nil)
+			},
+			expectedError: ErrProjectNotValid,
+		},
+		{
+			name: "Assignee isn't a member of the workspace",
+			input: &CreateTodoData{
+				Title:       "title",
+				AssigneeId:  &teammate,
+				WorkspaceId: &workspaceId,
+			},
+			setupMock: func() {
+				mockWorkspaces.On("GetWorkspace", workspaceId, teammate).Return(nil, workspace.ErrWorkspaceNotFound)
+			},
+			expectedError: ErrAssigneeNotValid,
+		},
+		{
+			name: "Member of the workspace is assigned",
+			input: &CreateTodoData{
+				Title:       "title",
+				AssigneeId:  &teammate,
+				WorkspaceId: &workspaceId,
+			},
+			setupMock: func() {
+				mockWorkspaces.On("GetWorkspace", workspaceId, teammate).
+					Return(&workspace.Workspace{Id: workspaceId, Role: workspace.RoleMember}, nil)
+				mockRepo.On("CreateTodo", mock.Anything, int64(1)).Return(&Todo{WorkspaceId: &workspaceId}, nil)
+			},
+			expectedError: nil,
+		},
+		{
+			name: "Valid input with a due date",
+			input: &CreateTodoData{
+				Title:          "title",
+				DueDate:        &dueDate,
+				DueTimezone:    &validTimezone,
+				ReminderOffset: &validOffset,
+			},
+			setupMock: func() {
+				mockRepo.On("CreateTodo", mock.Anything, int64(1)).Return(&Todo{}, nil)
+			},
+			expectedError: nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.setupMock()
+			_, err := service.CreateTodo(tc.input, 1)
+			assert.Equal(t, tc.expectedError, err)
+
+			mockRepo.ExpectedCalls = nil
+			mockProjects.ExpectedCalls = nil
+			mockWorkspaces.ExpectedCalls = nil
+		})
+	}
+}
+
+func TestReorderTodo(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewTodoService(mockRepo, new(MockProjectGetter))
+
+	todoId := 1
+	otherId := 2
+
+	tests := []struct {
+		name          string
+		input         *ReorderTodoData
+		setupMock     func()
+		expectedError error
+	}{
+		{
+			name:          "Id is not sent",
+			input:         &ReorderTodoData{BeforeId: &otherId},
+			setupMock:     func() {},
+			expectedError: ErrIdRequired,
+		},
+		{
+			name:          "No target is sent",
+			input:         &ReorderTodoData{Id: &todoId},
+			setupMock:     func() {},
+			expectedError: ErrReorderTargetNotValid,
+		},
+		{
+			name:          "Both targets are sent",
+			input:         &ReorderTodoData{Id: &todoId, BeforeId: &otherId, AfterId: &otherId},
+			setupMock:     func() {},
+			expectedError: ErrReorderTargetNotValid,
+		},
+		{
+			name:          "Target is the todo itself",
+			input:         &ReorderTodoData{Id: &todoId, AfterId: &todoId},
+			setupMock:     func() {},
+			expectedError: ErrReorderTargetNotValid,
+		},
+		{
+			name:  "Valid input",
+			input: &ReorderTodoData{Id: &todoId, BeforeId: &otherId},
+			setupMock: func() {
+				mockRepo.On("ReorderTodo", mock.Anything, int64(1)).Return(&Todo{}, nil)
+			},
+			expectedError: nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.setupMock()
+			_, err := service.ReorderTodo(tc.input, 1)
+			assert.Equal(t, tc.expectedError, err)
+
+			mockRepo.ExpectedCalls = nil
+		})
+	}
+}
+
+func TestUpdateTodoParent(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewTodoService(mockRepo, new(MockProjectGetter))
+
+	todoId := 1
+	parentId := 2
+
+	tests := []struct {
+		name          string
+		input         *UpdateTodoData
+		setupMock     func()
+		expectedError error
+	}{
+		{
+			name:          "Todo is its own parent",
+			input:         &UpdateTodoData{Id: &todoId, ParentId: &todoId},
+			setupMock:     func() {},
+			expectedError: ErrParentCycle,
+		},
+		{
+			name:  "Parent belongs to another user",
+			input: &UpdateTodoData{Id: &todoId, ParentId: &parentId},
+			setupMock: func() {
+				mockRepo.On("GetAncestorIds", parentId, int64(1)).Return([]int{}, nil)
+			},
+			expectedError: ErrParentNotValid,
+		},
+		{
+			name:  "Parent is a subtask of the todo",
+			input: &UpdateTodoData{Id: &todoId, ParentId: &parentId},
+			setupMock: func() {
+				mockRepo.On("GetAncestorIds", parentId, int64(1)).Return([]int{parentId, 5, todoId}, nil)
+			},
+			expectedError: ErrParentCycle,
+		},
+		{
+			name:  "Valid parent",
+			input: &UpdateTodoData{Id: &todoId, ParentId: &parentId},
+			setupMock: func() {
+				mockRepo.On("GetAncestorIds", parentId, int64(1)).Return([]int{parentId, 5}, nil)
+				mockRepo.On("GetTodo", todoId, int64(1)).Return(&Todo{Id: todoId, Role: share.RoleOwner}, nil)
+				mockRepo.On("UpdateTodo", mock.Anything, int64(1)).Return(&Todo{Id: todoId, ParentId: &parentId}, nil)
+			},
+			expectedError: nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.setupMock()
+			_, err := service.UpdateTodo(tc.input, 1)
+			assert.Equal(t, tc.expectedError, err)
+
+			mockRepo.ExpectedCalls = nil
+		})
+	}
+}
+
+func TestUpdateTodoCompletesParent(t *testing.T) {
+	todoId := 1
+	parentId := 2
+	done := true
+	// done todos are moved to the last status of the default workflow
+	doneStatus := "done"
+
+	tests := []struct {
+		name           string
+		parent         *Todo
+		expectComplete bool
+	}{
+		{
+			name:           "Parent has auto complete and all subtasks are done",
+			parent:         &Todo{Id: parentId, Role: share.RoleOwner, AutoComplete: true, Progress: &Progress{Done: 3, Total: 3}},
+			expectComplete: true,
+		},
+		{
+			name:           "Parent has auto complete but some subtasks are not done",
+			parent:         &Todo{Id: parentId, Role: share.RoleOwner, AutoComplete: true, Progress: &Progress{Done: 2, Total: 3}},
+			expectComplete: false,
+		},
+		{
+			name:           "Parent doesn't have auto complete",
+			parent:         &Todo{Id: parentId, Role: share.RoleOwner, Progress: &Progress{Done: 3, Total: 3}},
+			expectComplete: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			service := NewTodoService(mockRepo, new(MockProjectGetter))
+
+			mockRepo.On("GetTodo", todoId, int64(1)).Return(&Todo{Id: todoId, Role: share.RoleOwner, ParentId: &parentId}, nil)
+			mockRepo.On("UpdateTodo", &UpdateTodoData{Id: &todoId, Done: &done, Status: &doneStatus}, int64(1)).
+				Return(&Todo{Id: todoId, Done: true, ParentId: &parentId}, nil)
+			mockRepo.On("GetTodo", parentId, int64(1)).Return(tc.parent, nil)
+			mockRepo.On("UpdateTodo", &UpdateTodoData{Id: &parentId, Done: &done, Status: &doneStatus}, int64(1)).
+				Return(&Todo{Id: parentId, Done: true}, nil)
+
+			_, err := service.UpdateTodo(&UpdateTodoData{Id: &todoId, Done: &done, Status: &doneStatus}, 1)
+			assert.Nil(t, err)
+
+			if tc.expectComplete {
+				mockRepo.AssertCalled(t, "UpdateTodo", &UpdateTodoData{Id: &parentId, Done: &done, Status: &doneStatus}, int64(1))
+			} else {
+				mockRepo.AssertNotCalled(t, "UpdateTodo", &UpdateTodoData{Id: &parentId, Done: &done, Status: &doneStatus}, int64(1))
+			}
+		})
+	}
+}
+
+func TestUpdateTodoSpawnsNextOccurrence(t *testing.T) {
+	todoId := 1
+	done := true
+	dueDate := time.Date(2026, time.November, 2, 9, 0, 0, 0, time.UTC)
+	weekly := "FREQ=WEEKLY"
+	twice := "FREQ=WEEKLY;COUNT=2"
+
+	tests := []struct {
+		name          string
+		completed     *Todo
+		occurrences   []Todo
+		occurrenceErr error
+		expectSpawned bool
+	}{
+		{
+			name:          "Next occurrence is created with the shifted due date",
+			completed:     &Todo{Id: todoId, Title: "Water plants", Done: true, DueDate: &dueDate, Recurrence: &weekly, SeriesId: &todoId, Occurrence: 1},
+			occurrences:   []Todo{{Id: todoId, Occurrence: 1}},
+			expectSpawned: true,
+		},
+		{
+			name:          "Series has reached its COUNT",
+			completed:     &Todo{Id: todoId, Done: true, DueDate: &dueDate, Recurrence: &twice, SeriesId: &todoId, Occurrence: 2},
+			occurrences:   []Todo{{Id: todoId, Occurrence: 2}},
+			expectSpawned: false,
+		},
+		{
+			name:          "Next occurrence was already created",
+			completed:     &Todo{Id: todoId, Done: true, DueDate: &dueDate, Recurrence: &weekly, SeriesId: &todoId, Occurrence: 1},
+			occurrences:   []Todo{{Id: todoId, Occurrence: 1}, {Id: 7, Occurrence: 2}},
+			expectSpawned: false,
+		},
+		{
+			name:          "Completion fails if the next occurrence can't be created",
+			completed:     &Todo{Id: todoId, Done: true, DueDate: &dueDate, Recurrence: &weekly, SeriesId: &todoId, Occurrence: 1},
+			occurrenceErr: pgx.ErrTxClosed,
+			expectSpawned: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			service := NewTodoService(mockRepo, new(MockProjectGetter))
+
+			mockRepo.On("GetTodo", todoId, int64(1)).Return(&Todo{Id: todoId, Role: share.RoleOwner, DueDate: &dueDate, Recurrence: tc.completed.Recurrence}, nil)
+			mockRepo.On("UpdateOccurrence", mock.Anything, int64(1)).Return(tc.completed, nil)
+			mockRepo.On("GetAllTodos", int64(1), mock.Anything).Return(tc.occurrences, tc.occurrenceErr)
+			mockRepo.On("CreateOccurrence", mock.Anything, int64(1)).Return()
+
+			_, err := service.UpdateTodo(&UpdateTodoData{Id: &todoId, Done: &done}, 1)
+			assert.Equal(t, tc.occurrenceErr, err)
+			mockRepo.AssertNotCalled(t, "UpdateTodo", mock.Anything, mock.Anything)
+
+			if !tc.expectSpawned {
+				mockRepo.AssertNotCalled(t, "CreateOccurrence", mock.Anything, mock.Anything)
+				return
+			}
+
+			mockRepo.AssertCalled(t, "CreateOccurrence", mock.MatchedBy(func(next *Todo) bool {
+				return next.Title == tc.completed.Title &&
+					next.DueDate.Equal(dueDate.AddDate(0, 0, 7)) &&
+					*next.SeriesId == todoId &&
+					next.Occurrence == 2 &&
+					!next.Done
+			}), int64(1))
+		})
+	}
+}
+
+func TestUpdateTodoSeries(t *testing.T) {
+	todoId := 1
+	seriesId := 1
+	title := "Water the plants"
+	scope := ScopeSeries
+	dueDate := time.Date(2026, time.November, 2, 9, 0, 0, 0, time.UTC)
+	weekly := "FREQ=WEEKLY"
+
+	mockRepo := new(MockRepository)
+	service := NewTodoService(mockRepo, new(MockProjectGetter))
+
+	mockRepo.On("GetTodo", todoId, int64(1)).Return(&Todo{
+		Id: todoId, Role: share.RoleOwner, DueDate: &dueDate, Recurrence: &weekly, SeriesId: &seriesId,
+	}, nil)
+	mockRepo.On("GetAllTodos", int64(1), mock.Anything).Return([]Todo{
+		{Id: todoId}, {Id: 2, Done: true}, {Id: 3}, {Id: 4},
+	}, nil)
+	mockRepo.On("UpdateOccurrence", mock.Anything, int64(1)).Return(&Todo{Id: todoId, Title: title, SeriesId: &seriesId}, nil)
+	mockRepo.On("UpdateSeries", mock.Anything, mock.Anything, int64(1)).Return()
+
+	_, err := service.UpdateTodo(&UpdateTodoData{Id: &todoId, Title: &title, Scope: &scope}, 1)
+	assert.Nil(t, err)
+
+	// the other open occurrences are edited with the todo in one transaction
+	mockRepo.AssertNotCalled(t, "UpdateTodo", mock.Anything, mock.Anything)
+	mockRepo.AssertCalled(t, "UpdateSeries", mock.MatchedBy(func(series *UpdateTodoData) bool {
+		return *series.Title == title && series.Id == nil && series.Scope == nil
+	}), []int{3, 4}, int64(1))
+}
+
+func TestRemoveTodo(t *testing.T) {
+	tests := []struct {
+		name           string
+		permanent      bool
+		expectedMethod string
+	}{
+		{name: "Todo is moved to the trash", permanent: false, expectedMethod: "RemoveTodo"},
+		{name: "Todo is deleted permanently", permanent: true, expectedMethod: "DeleteTodo"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockAttachments := new(MockAttachmentCleaner)
+			mockTimers := new(MockTimerStopper)
+			service := NewTodoService(mockRepo, new(MockProjectGetter))
+			service.Attachments = mockAttachments
+			service.Timers = mockTimers
+
+			mockRepo.On(tc.expectedMethod, 1, int64(1)).Return(&Todo{Id: 1}, nil)
+			// attachments are kept in the trash to be restored with the todo, but its timers are stopped.
+			// Time entries of a deleted todo are deleted with it
+			if tc.permanent {
+				mockAttachments.On("RemoveDetachedAttachments").Return(int64(2), nil)
+			} else {
+				mockTimers.On("StopTrashedTimers").Return(int64(1), nil)
+			}
+
+			removedTodo, err := service.RemoveTodo(1, 1, tc.permanent)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, removedTodo.Id)
+			mockRepo.AssertExpectations(t)
+			mockAttachments.AssertExpectations(t)
+			mockTimers.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPurgeTrash(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockAttachments := new(MockAttachmentCleaner)
+	service := NewTodoService(mockRepo, new(MockProjectGetter))
+	service.Attachments = mockAttachments
+	retention := 7 * 24 * time.Hour
+
+	mockRepo.On("PurgeTrash", mock.MatchedBy(func(before time.Time) bool {
+		// the todos removed before the retention period are purged
+		expected := time.Now().Add(-retention)
+		return before.Sub(expected).Abs() < time.Minute
+	})).Return(int64(3), nil)
+	mockAttachments.On("RemoveDetachedAttachments").Return(int64(0), nil)
+
+	purged, err := service.PurgeTrash(retention)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), purged)
+	mockRepo.AssertExpectations(t)
+	mockAttachments.AssertExpectations(t)
+}
+
+func TestAssignTodo(t *testing.T) {
+	todoId := 1
+	var assigner int64 = 1
+	var teammate int64 = 2
+
+	tests := []struct {
+		name          string
+		assigneeId    int64
+		setupMock     func(repo *MockRepository, users *MockUserGetter)
+		expectedError error
+		expectedEmail bool
+	}{
+		{
+			name:       "Assignee can't see the todo",
+			assigneeId: teammate,
+			setupMock: func(repo *MockRepository, users *MockUserGetter) {
+				repo.On("GetTodo", todoId, teammate).Return(nil, pgx.ErrNoRows)
+			},
+			expectedError: ErrAssigneeNotValid,
+		},
+		{
+			name:       "Assignee is emailed",
+			assigneeId: teammate,
+			setupMock: func(repo *MockRepository, users *MockUserGetter) {
+				repo.On("GetTodo", todoId, teammate).Return(&Todo{Id: todoId, Role: share.RoleViewer}, nil)
+				users.On("GetPreferences", teammate).Return(&user.Preferences{NotifyOnAssignment: true}, nil)
+				users.On("GetUser", teammate).Return(&user.VisibleUser{Id: teammate, Username: "deniz", Email: "deniz@example.com"})
+				users.On("GetUser", assigner).Return(&user.VisibleUser{Id: assigner, Username: "umit"})
+			},
+			expectedEmail: true,
+		},
+		{
+			name:       "Assignee opted out of the emails",
+			assigneeId: teammate,
+			setupMock: func(repo *MockRepository, users *MockUserGetter) {
+				repo.On("GetTodo", todoId, teammate).Return(&Todo{Id: todoId, Role: share.RoleViewer}, nil)
+				users.On("GetPreferences", teammate).Return(&user.Preferences{NotifyOnAssignment: false}, nil)
+			},
+		},
+		{
+			name:       "User assigns the todo to themselves",
+			assigneeId: assigner,
+			setupMock:  func(repo *MockRepository, users *MockUserGetter) {},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockUsers := new(MockUserGetter)
+			service := NewTodoService(mockRepo, new(MockProjectGetter))
+			service.Users = mockUsers
+
+			var sent []email.SendEmailData
+			service.SendEmail = func(data email.SendEmailData) error {
+				sent = append(sent, data)
+				return nil
+			}
+
+			input := &UpdateTodoData{Id: &todoId, AssigneeId: &tc.assigneeId}
+			tc.setupMock(mockRepo, mockUsers)
+			mockRepo.On("GetTodo", todoId, assigner).Return(&Todo{Id: todoId, Role: share.RoleOwner}, nil)
+			mockRepo.On("UpdateTodo", input, assigner).Return(&Todo{Id: todoId, Title: "Review", AssigneeId: &tc.assigneeId}, nil)
+
+			_, err := service.UpdateTodo(input, assigner)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				mockRepo.AssertNotCalled(t, "UpdateTodo", mock.Anything, mock.Anything)
+			} else {
+				assert.Nil(t, err)
+			}
+
+			if tc.expectedEmail {
+				assert.Len(t, sent, 1)
+				assert.Equal(t, []string{"deniz@example.com"}, sent[0].To)
+				assert.Contains(t, sent[0].Message, `umit assigned "Review" to you`)
+			} else {
+				assert.Empty(t, sent)
+			}
+			mockUsers.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateTodoArchive(t *testing.T) {
+	todoId := 1
+	archived := true
+	done := true
+
+	tests := []struct {
+		name          string
+		input         *UpdateTodoData
+		current       *Todo
+		expectedError error
+	}{
+		{
+			name:          "Todo that is not done can't be archived",
+			input:         &UpdateTodoData{Id: &todoId, Archived: &archived},
+			current:       &Todo{Id: todoId, Role: share.RoleOwner},
+			expectedError: ErrArchiveNotDone,
+		},
+		{
+			name:          "Done todo is archived",
+			input:         &UpdateTodoData{Id: &todoId, Archived: &archived},
+			current:       &Todo{Id: todoId, Role: share.RoleOwner, Done: true},
+			expectedError: nil,
+		},
+		{
+			name:          "Todo is completed and archived at once",
+			input:         &UpdateTodoData{Id: &todoId, Done: &done, Archived: &archived},
+			current:       &Todo{Id: todoId, Role: share.RoleOwner},
+			expectedError: nil,
+		},
+		{
+			name:          "Todo shared with the user as a viewer can't be changed",
+			input:         &UpdateTodoData{Id: &todoId, Archived: &archived},
+			current:       &Todo{Id: todoId, Role: share.RoleViewer, Done: true},
+			expectedError: ErrReadOnly,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			service := NewTodoService(mockRepo, new(MockProjectGetter))
+
+			mockRepo.On("GetTodo", todoId, int64(1)).Return(tc.current, nil)
+			mockRepo.On("UpdateTodo", tc.input, int64(1)).Return(&Todo{Id: todoId, Done: true, Archived: true}, nil)
+
+			_, err := service.UpdateTodo(tc.input, 1)
+			assert.ErrorIs(t, err, tc.expectedError)
+
+			if tc.expectedError != nil {
+				mockRepo.AssertNotCalled(t, "UpdateTodo", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestUpdateTodoDependencies(t *testing.T) {
+	todoId := 1
+	done := true
+
+	tests := []struct {
+		name          string
+		input         *UpdateTodoData
+		current       *Todo
+		setupMock     func(mockRepo *MockRepository)
+		expectedError error
+	}{
+		{
+			name:          "Todo is blocked by itself",
+			input:         &UpdateTodoData{Id: &todoId, BlockedBy: &[]int{todoId}},
+			current:       &Todo{Id: todoId, Role: share.RoleOwner},
+			setupMock:     func(mockRepo *MockRepository) {},
+			expectedError: ErrDependencyCycle,
+		},
+		{
+			name:    "Blocking todo can't be seen by the user",
+			input:   &UpdateTodoData{Id: &todoId, BlockedBy: &[]int{2}},
+			current: &Todo{Id: todoId, Role: share.RoleOwner},
+			setupMock: func(mockRepo *MockRepository) {
+				mockRepo.On("GetTodo", 2, int64(1)).Return(nil, pgx.ErrNoRows)
+			},
+			expectedError: ErrDependencyNotValid,
+		},
+		{
+			name:    "Blocking todo is waiting for the todo",
+			input:   &UpdateTodoData{Id: &todoId, BlockedBy: &[]int{2}},
+			current: &Todo{Id: todoId, Role: share.RoleOwner},
+			setupMock: func(mockRepo *MockRepository) {
+				mockRepo.On("GetTodo", 2, int64(1)).Return(&Todo{Id: 2}, nil)
+				mockRepo.On("GetBlockerIds", []int{2}).Return([]int{3, todoId}, nil)
+			},
+			expectedError: ErrDependencyCycle,
+		},
+		{
+			name:    "Blocking todos are set without duplicates",
+			input:   &UpdateTodoData{Id: &todoId, BlockedBy: &[]int{3, 2, 3}},
+			current: &Todo{Id: todoId, Role: share.RoleOwner},
+			setupMock: func(mockRepo *MockRepository) {
+				mockRepo.On("GetTodo", 2, int64(1)).Return(&Todo{Id: 2}, nil)
+				mockRepo.On("GetTodo", 3, int64(1)).Return(&Todo{Id: 3}, nil)
+				mockRepo.On("GetBlockerIds", []int{2, 3}).Return([]int{4}, nil)
+				mockRepo.On("UpdateTodo", &UpdateTodoData{Id: &todoId, BlockedBy: &[]int{2, 3}}, int64(1)).
+					Return(&Todo{Id: todoId, BlockedBy: []int{2, 3}, Blocked: true}, nil)
+			},
+		},
+		{
+			name:          "Blocked todo can't be done",
+			input:         &UpdateTodoData{Id: &todoId, Done: &done},
+			current:       &Todo{Id: todoId, Role: share.RoleOwner, BlockedBy: []int{2}, Blocked: true},
+			setupMock:     func(mockRepo *MockRepository) {},
+			expectedError: ErrTodoBlocked,
+		},
+		{
+			name:    "Todo is done when its new blocking todos are done",
+			input:   &UpdateTodoData{Id: &todoId, Done: &done, BlockedBy: &[]int{3}},
+			current: &Todo{Id: todoId, Role: share.RoleOwner, BlockedBy: []int{2}, Blocked: true},
+			setupMock: func(mockRepo *MockRepository) {
+				mockRepo.On("GetTodo", 3, int64(1)).Return(&Todo{Id: 3, Done: true}, nil)
+				mockRepo.On("GetBlockerIds", []int{3}).Return([]int{}, nil)
+				mockRepo.On("UpdateTodo", mock.Anything, int64(1)).Return(&Todo{Id: todoId, Done: true}, nil)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			service := NewTodoService(mockRepo, new(MockProjectGetter))
+
+			mockRepo.On("GetTodo", todoId, int64(1)).Return(tc.current, nil)
+			tc.setupMock(mockRepo)
+
+			_, err := service.UpdateTodo(tc.input, 1)
+			assert.ErrorIs(t, err, tc.expectedError)
+
+			if tc.expectedError != nil {
+				mockRepo.AssertNotCalled(t, "UpdateTodo", mock.Anything, mock.Anything)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateTodoStatus(t *testing.T) {
+	todoId := 1
+	projectId := 4
+	otherProjectId := 5
+	done := true
+	notDone := false
+	review := "review"
+	doing := "doing"
+	shipped := "shipped"
+	unknown := "unknown"
+
+	workflow := &project.Workflow{Statuses: []project.Status{
+		{Name: "backlog", Next: []string{"doing"}},
+		{Name: "doing"},
+		{Name: "review", Next: []string{"doing", "shipped"}},
+		{Name: "shipped", Next: []string{"review"}},
+	}}
+
+	tests := []struct {
+		name           string
+		input          *UpdateTodoData
+		current        *Todo
+		expectedStatus *string
+		expectedDone   *bool
+		expectedError  error
+	}{
+		{
+			name:          "Status is not in the workflow",
+			input:         &UpdateTodoData{Id: &todoId, Status: &unknown},
+			current:       &Todo{Id: todoId, Role: share.RoleOwner, ProjectId: &projectId, Status: "backlog"},
+			expectedError: ErrStatusNotValid,
+		},
+		{
+			name:          "Workflow doesn't allow the transition",
+			input:         &UpdateTodoData{Id: &todoId, Status: &review},
+			current:       &Todo{Id: todoId, Role: share.RoleOwner, ProjectId: &projectId, Status: "backlog"},
+			expectedError: ErrTransitionNotAllowed,
+		},
+		{
+			name:          "Done doesn't match the status",
+			input:         &UpdateTodoData{Id: &todoId, Status: &doing, Done: &done},
+			current:       &Todo{Id: todoId, Role: share.RoleOwner, ProjectId: &projectId, Status: "backlog"},
+			expectedError: ErrStatusNotValid,
+		},
+		{
+			name:           "Moving to the terminal status marks the todo as done",
+			input:          &UpdateTodoData{Id: &todoId, Status: &shipped},
+			current:        &Todo{Id: todoId, Role: share.RoleOwner, ProjectId: &projectId, Status: "review"},
+			expectedStatus: &shipped,
+			expectedDone:   &done,
+		},
+		{
+			name:           "Marking as done moves the todo to the terminal status",
+			input:          &UpdateTodoData{Id: &todoId, Done: &done},
+			current:        &Todo{Id: todoId, Role: share.RoleOwner, ProjectId: &projectId, Status: "doing"},
+			expectedStatus: &shipped,
+			expectedDone:   &done,
+		},
+		{
+			name:           "Reopened todo is moved to the first transition of the terminal status",
+			input:          &UpdateTodoData{Id: &todoId, Done: &notDone},
+			current:        &Todo{Id: todoId, Role: share.RoleOwner, ProjectId: &projectId, Status: "shipped", Done: true},
+			expectedStatus: &review,
+			expectedDone:   &notDone,
+		},
+		{
+			name:           "Todo moved to another project starts over if the status isn't in its workflow",
+			input:          &UpdateTodoData{Id: &todoId, ProjectId: &otherProjectId},
+			current:        &Todo{Id: todoId, Role: share.RoleOwner, ProjectId: &projectId, Status: "doing"},
+			expectedStatus: func() *string { s := "backlog"; return &s }(),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockProjects := new(MockProjectGetter)
+			service := NewTodoService(mockRepo, mockProjects)
+
+			mockRepo.On("GetTodo", todoId, int64(1)).Return(tc.current, nil)
+			mockProjects.On("GetProject", projectId, int64(1)).
+				Return(&project.Project{Id: projectId, Role: share.RoleOwner, Workflow: workflow}, nil)
+			// the other project has the default workflow
+			mockProjects.On("GetProject", otherProjectId, int64(1)).
+				Return(&project.Project{Id: otherProjectId, Role: share.RoleOwner}, nil)
+			mockRepo.On("UpdateTodo", tc.input, int64(1)).Return(&Todo{Id: todoId}, nil)
+
+			_, err := service.UpdateTodo(tc.input, 1)
+			assert.ErrorIs(t, err, tc.expectedError)
+
+			if tc.expectedError != nil {
+				mockRepo.AssertNotCalled(t, "UpdateTodo", mock.Anything, mock.Anything)
+				return
+			}
+			assert.Equal(t, tc.expectedStatus, tc.input.Status)
+			assert.Equal(t, tc.expectedDone, tc.input.Done)
+		})
+	}
+}
+
+func TestGetDependencyGraph(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockProjects := new(MockProjectGetter)
+	service := NewTodoService(mockRepo, mockProjects)
+	projectId := 4
+
+	mockProjects.On("GetProject", projectId, int64(1)).Return(&project.Project{Id: projectId, Role: share.RoleViewer}, nil)
+	mockRepo.On("GetAllTodos", int64(1), &ListOptions{ProjectId: &projectId}).Return([]Todo{{Id: 1}, {Id: 2}, {Id: 3}}, nil)
+	mockRepo.On("GetDependencies", []int{1, 2, 3}).Return([]Dependency{{TodoId: 1, BlockedById: 3}}, nil)
+
+	graph, err := service.GetDependencyGraph(projectId, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []Dependency{{TodoId: 1, BlockedById: 3}}, graph.Dependencies)
+	assert.Equal(t, []Todo{{Id: 2}, {Id: 3}, {Id: 1}}, graph.Todos)
+
+	mockProjects.On("GetProject", 5, int64(1)).Return(nil, project.ErrProjectNotFound)
+	_, err = service.GetDependencyGraph(5, 1)
+	assert.ErrorIs(t, err, ErrProjectNotValid)
+}
+
+func TestGetBurndown(t *testing.T) {
+	projectId := 4
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	days := []BurndownDay{{Day: "2026-10-01", Remaining: 8, Total: 13}, {Day: "2026-10-02", Remaining: 5, Total: 13}}
+
+	tests := []struct {
+		name          string
+		options       BurndownOptions
+		setupMock     func(repo *MockRepository, projects *MockProjectGetter)
+		expectedError error
+	}{
+		{
+			name:          "Range ends before it starts",
+			options:       BurndownOptions{From: from, To: from.AddDate(0, 0, -1)},
+			setupMock:     func(repo *MockRepository, projects *MockProjectGetter) {},
+			expectedError: ErrBurndownRangeNotValid,
+		},
+		{
+			name:          "Range is longer than a year",
+			options:       BurndownOptions{From: from, To: from.AddDate(1, 0, 2)},
+			setupMock:     func(repo *MockRepository, projects *MockProjectGetter) {},
+			expectedError: ErrBurndownRangeNotValid,
+		},
+		{
+			name:          "Timezone is not valid",
+			options:       BurndownOptions{From: from, To: from, Timezone: "Mars/Olympus"},
+			setupMock:     func(repo *MockRepository, projects *MockProjectGetter) {},
+			expectedError: ErrTimezoneNotValid,
+		},
+		{
+			name:    "User can't see the project",
+			options: BurndownOptions{From: from, To: from.AddDate(0, 0, 1)},
+			setupMock: func(repo *MockRepository, projects *MockProjectGetter) {
+				projects.On("GetProject", projectId, int64(1)).Return(nil, project.ErrProjectNotFound)
+			},
+			expectedError: ErrProjectNotValid,
+		},
+		{
+			name:    "Burndown is in the estimate unit of the project",
+			options: BurndownOptions{From: from, To: from.AddDate(0, 0, 1)},
+			setupMock: func(repo *MockRepository, projects *MockProjectGetter) {
+				projects.On("GetProject", projectId, int64(1)).
+					Return(&project.Project{Id: projectId, EstimateUnit: project.EstimateUnitPoints}, nil)
+				repo.On("GetBurndown", projectId, mock.Anything).Return(days, nil)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockProjects := new(MockProjectGetter)
+			service := NewTodoService(mockRepo, mockProjects)
+			tc.setupMock(mockRepo, mockProjects)
+
+			burndown, err := service.GetBurndown(projectId, 1, &tc.options)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				mockRepo.AssertNotCalled(t, "GetBurndown", mock.Anything, mock.Anything)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, project.EstimateUnitPoints, burndown.Unit)
+			assert.Equal(t, days, burndown.Days)
+			assert.Equal(t, "UTC", tc.options.Timezone)
+		})
+	}
+}
+
+func TestAutoArchive(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewTodoService(mockRepo, new(MockProjectGetter))
+	after := 14 * 24 * time.Hour
+
+	mockRepo.On("ArchiveCompletedBefore", mock.MatchedBy(func(before time.Time) bool {
+		// the todos completed before the given duration are archived
+		expected := time.Now().Add(-after)
+		return before.Sub(expected).Abs() < time.Minute
+	})).Return(int64(2), nil)
+
+	archived, err := service.AutoArchive(after)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), archived)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestListTodos(t *testing.T) {
+	todos := []Todo{{Id: 1, Position: 1024}, {Id: 2, Position: 2048}, {Id: 3, Position: 3072}}
+
+	tests := []struct {
+		name               string
+		limit              int
+		found              []Todo
+		expectedIds        []int
+		expectedNextCursor bool
+	}{
+		{name: "There is a next page", limit: 2, found: todos, expectedIds: []int{1, 2}, expectedNextCursor: true},
+		{name: "Last page", limit: 3, found: todos, expectedIds: []int{1, 2, 3}, expectedNextCursor: false},
+		{name: "No limit", limit: 0, found: todos, expectedIds: []int{1, 2, 3}, expectedNextCursor: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			service := NewTodoService(mockRepo, new(MockProjectGetter))
+
+			mockRepo.On("GetAllTodos", int64(1), mock.MatchedBy(func(options *ListOptions) bool {
+				// one more todo is asked for to find out if there is a next page
+				if tc.limit == 0 {
+					return options.Limit == 0
+				}
+				return options.Limit == tc.limit+1
+			})).Return(tc.found, nil)
+			estimates := []project.EstimateSum{{Unit: project.EstimateUnitMinutes, Total: 90, Remaining: 30, Count: 2}}
+			mockRepo.On("SumEstimates", int64(1), mock.Anything).Return(estimates, nil)
+
+			page, err := service.ListTodos(1, &ListOptions{Limit: tc.limit})
+			assert.Nil(t, err)
+			assert.Equal(t, estimates, page.Estimates)
+
+			var ids []int
+			for _, item := range page.Items {
+				ids = append(ids, item.Id)
+			}
+			assert.Equal(t, tc.expectedIds, ids)
+
+			if !tc.expectedNextCursor {
+				assert.Nil(t, page.NextCursor)
+				return
+			}
+
+			cursor, err := DecodeCursor(*page.NextCursor, nil)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedIds[len(tc.expectedIds)-1], cursor.Id)
+		})
+	}
+}
+
+func TestSearchTodos(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		expectedError error
+	}{
+		{name: "Query is searched", query: "milk", expectedError: nil},
+		{name: "Query without any word", query: "&& !", expectedError: ErrSearchQueryEmpty},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			service := NewTodoService(mockRepo, new(MockProjectGetter))
+
+			mockRepo.On("SearchTodos", int64(1), tc.query, mock.Anything).Return([]SearchResult{{Todo: Todo{Id: 1}}}, nil)
+
+			results, err := service.SearchTodos(1, tc.query, &ListOptions{})
+			assert.ErrorIs(t, err, tc.expectedError)
+
+			if tc.expectedError != nil {
+				mockRepo.AssertNotCalled(t, "SearchTodos", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			assert.Len(t, results, 1)
+		})
+	}
+}
+
+func TestCreateView(t *testing.T) {
+	done := false
+	sort := "-priority"
+	badSort := "colour"
+	badFilter := "priority>=hgh"
+	createdAfter := time.Date(2026, time.November, 2, 0, 0, 0, 0, time.UTC)
+	createdBefore := createdAfter.AddDate(0, 0, 7)
+
+	tests := []struct {
+		name          string
+		input         *CreateViewData
+		expectedError error
+	}{
+		{
+			name: "View is created",
+			input: &CreateViewData{Name: " This week at work ", Criteria: ViewCriteria{
+				Done: &done, CreatedAfter: &createdAfter, CreatedBefore: &createdBefore, Sort: &sort,
+			}},
+			expectedError: nil,
+		},
+		{
+			name:          "Empty name",
+			input:         &CreateViewData{Name: "  "},
+			expectedError: ErrViewNameLength,
+		},
+		{
+			name:          "Sort is not valid",
+			input:         &CreateViewData{Name: "View", Criteria: ViewCriteria{Sort: &badSort}},
+			expectedError: ErrViewCriteriaNotValid,
+		},
+		{
+			name:          "Filter is not valid",
+			input:         &CreateViewData{Name: "View", Criteria: ViewCriteria{Filter: &badFilter}},
+			expectedError: ErrViewCriteriaNotValid,
+		},
+		{
+			name:          "Range ends before it starts",
+			input:         &CreateViewData{Name: "View", Criteria: ViewCriteria{CreatedAfter: &createdBefore, CreatedBefore: &createdAfter}},
+			expectedError: ErrViewCriteriaNotValid,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			service := NewTodoService(mockRepo, new(MockProjectGetter))
+
+			mockRepo.On("CreateView", tc.input, int64(1)).Return(&View{Id: 1, Name: "This week at work"}, nil)
+
+			_, err := service.CreateView(tc.input, 1)
+			assert.ErrorIs(t, err, tc.expectedError)
+
+			if tc.expectedError != nil {
+				mockRepo.AssertNotCalled(t, "CreateView", mock.Anything, mock.Anything)
+				return
+			}
+			assert.Equal(t, "This week at work", tc.input.Name)
+		})
+	}
+}
+
+func TestCreateTemplate(t *testing.T) {
+	farOffset := MaxTemplateDueOffset + 1
+	longNotes := strings.Repeat("ı", MaxNotesLength+1)
+	tooMany := make([]TemplateItem, MaxTemplateItems)
+	for i := range tooMany {
+		tooMany[i] = TemplateItem{Title: "item"}
+	}
+
+	tests := []struct {
+		name          string
+		input         *CreateTemplateData
+		expectedError error
+	}{
+		{
+			name: "Template is created",
+			input: &CreateTemplateData{Name: " Release ", Items: []TemplateItem{
+				{Title: " Tag the release ", Tags: []string{" work ", "work"}, Subtasks: []TemplateItem{{Title: "Write the changelog"}}},
+			}},
+			expectedError: nil,
+		},
+		{
+			name:          "Empty name",
+			input:         &CreateTemplateData{Name: " ", Items: []TemplateItem{{Title: "item"}}},
+			expectedError: ErrTemplateNameLength,
+		},
+		{
+			name:          "No items",
+			input:         &CreateTemplateData{Name: "Release"},
+			expectedError: ErrTemplateItemsNotValid,
+		},
+		{
+			name: "Subtask without a title",
+			input: &CreateTemplateData{Name: "Release", Items: []TemplateItem{
+				{Title: "Tag the release", Subtasks: []TemplateItem{{Title: "  "}}},
+			}},
+			expectedError: ErrTemplateItemsNotValid,
+		},
+		{
+			name:          "Due offset is too far",
+			input:         &CreateTemplateData{Name: "Release", Items: []TemplateItem{{Title: "item", DueOffset: &farOffset}}},
+			expectedError: ErrTemplateItemsNotValid,
+		},
+		{
+			name:          "Notes are too long",
+			input:         &CreateTemplateData{Name: "Release", Items: []TemplateItem{{Title: "item", Notes: &longNotes}}},
+			expectedError: ErrNotesTooLong,
+		},
+		{
+			name: "Too many items with the subtasks",
+			input: &CreateTemplateData{Name: "Release", Items: []TemplateItem{
+				{Title: "Tag the release", Subtasks: tooMany},
+			}},
+			expectedError: ErrTemplateItemsNotValid,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			service := NewTodoService(mockRepo, new(MockProjectGetter))
+
+			mockRepo.On("CreateTemplate", tc.input, int64(1)).Return(&Template{Id: 1, Name: "Release"}, nil)
+
+			_, err := service.CreateTemplate(tc.input, 1)
+			assert.ErrorIs(t, err, tc.expectedError)
+
+			if tc.expectedError != nil {
+				mockRepo.AssertNotCalled(t, "CreateTemplate", mock.Anything, mock.Anything)
+				return
+			}
+			assert.Equal(t, "Release", tc.input.Name)
+			assert.Equal(t, "Tag the release", tc.input.Items[0].Title)
+			assert.Equal(t, []string{"work"}, tc.input.Items[0].Tags)
+		})
+	}
+}
+
+func TestInstantiateTemplate(t *testing.T) {
+	templateId := 5
+	projectId := 3
+	startDate := time.Date(2026, 10, 20, 9, 0, 0, 0, time.UTC)
+	timezone := "Europe/Istanbul"
+	invalidTimezone := "Mars/Olympus"
+	offset := 2
+	template := &Template{Id: templateId, Name: "Release", Items: []TemplateItem{
+		{Title: "Tag the release", DueOffset: &offset, Subtasks: []TemplateItem{{Title: "Write the changelog"}}},
+	}}
+
+	tests := []struct {
+		name          string
+		input         *InstantiateTemplateData
+		setupMock     func(repo *MockRepository, projects *MockProjectGetter)
+		expectedError error
+	}{
+		{
+			name:          "Start date is missing",
+			input:         &InstantiateTemplateData{},
+			setupMock:     func(repo *MockRepository, projects *MockProjectGetter) {},
+			expectedError: ErrStartDateRequired,
+		},
+		{
+			name:          "Timezone is not valid",
+			input:         &InstantiateTemplateData{StartDate: &startDate, DueTimezone: &invalidTimezone},
+			setupMock:     func(repo *MockRepository, projects *MockProjectGetter) {},
+			expectedError: ErrTimezoneNotValid,
+		},
+		{
+			name:  "Project is read only",
+			input: &InstantiateTemplateData{StartDate: &startDate, ProjectId: &projectId},
+			setupMock: func(repo *MockRepository, projects *MockProjectGetter) {
+				projects.On("GetProject", projectId, int64(1)).Return(&project.Project{Role: share.RoleViewer}, nil)
+			},
+			expectedError: ErrProjectNotValid,
+		},
+		{
+			name:  "Template doesn't exist",
+			input: &InstantiateTemplateData{StartDate: &startDate},
+			setupMock: func(repo *MockRepository, projects *MockProjectGetter) {
+				repo.On("GetTemplate", templateId, int64(1)).Return(nil, pgx.ErrNoRows)
+			},
+			expectedError: pgx.ErrNoRows,
+		},
+		{
+			name:  "Todos are created in the project",
+			input: &InstantiateTemplateData{StartDate: &startDate, DueTimezone: &timezone, ProjectId: &projectId},
+			setupMock: func(repo *MockRepository, projects *MockProjectGetter) {
+				projects.On("GetProject", projectId, int64(1)).Return(&project.Project{Role: share.RoleEditor}, nil)
+				repo.On("GetTemplate", templateId, int64(1)).Return(template, nil)
+				repo.On("CreateTodoTree", mock.Anything, int64(1)).Return([]Todo{{Id: 1}}, nil)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockProjects := new(MockProjectGetter)
+			service := NewTodoService(mockRepo, mockProjects)
+			tc.setupMock(mockRepo, mockProjects)
+
+			_, err := service.InstantiateTemplate(templateId, tc.input, 1)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				mockRepo.AssertNotCalled(t, "CreateTodoTree", mock.Anything, mock.Anything)
+				return
+			}
+			assert.Nil(t, err)
+
+			todos := mockRepo.Calls[len(mockRepo.Calls)-1].Arguments.Get(0).([]Todo)
+			assert.Len(t, todos, 1)
+			assert.Equal(t, &projectId, todos[0].ProjectId)
+			assert.Equal(t, project.DefaultWorkflow().Initial(), todos[0].Status)
+			assert.True(t, todos[0].DueDate.Equal(startDate.AddDate(0, 0, offset)))
+			assert.Equal(t, &timezone, todos[0].DueTimezone)
+			assert.Len(t, todos[0].Subtasks, 1)
+			assert.Nil(t, todos[0].Subtasks[0].DueDate)
+			assert.Nil(t, todos[0].Subtasks[0].DueTimezone)
+		})
+	}
+}
+
+func TestQuickAddTodo(t *testing.T) {
+	projectId := 3
+	explicitPriority := PriorityLow
+	projects := []project.Project{{Id: 2, Name: "Work"}, {Id: projectId, Name: "Home renovation"}}
+
+	tests := []struct {
+		name              string
+		input             *CreateTodoData
+		setupMock         func(repo *MockRepository, projects *MockProjectGetter)
+		expectedError     error
+		expectedTitle     string
+		expectedPriority  Priority
+		expectedTags      []string
+		expectedProjectId *int
+	}{
+		{
+			name:  "Project is found by its name",
+			input: &CreateTodoData{Title: "Paint the walls @home-renovation #diy !high", Tags: []string{"paint"}},
+			setupMock: func(repo *MockRepository, mockProjects *MockProjectGetter) {
+				mockProjects.On("GetAllProjects", int64(1), (*int)(nil), false).Return(projects, nil)
+				mockProjects.On("GetProject", projectId, int64(1)).Return(&project.Project{Role: share.RoleOwner}, nil)
+				repo.On("CreateTodo", mock.Anything, int64(1)).Return(&Todo{Id: 1, Title: "Paint the walls"}, nil)
+			},
+			expectedTitle:     "Paint the walls",
+			expectedPriority:  PriorityHigh,
+			expectedTags:      []string{"paint", "diy"},
+			expectedProjectId: &projectId,
+		},
+		{
+			name:  "Sent fields are kept",
+			input: &CreateTodoData{Title: "Call the plumber !urgent", Priority: &explicitPriority},
+			setupMock: func(repo *MockRepository, mockProjects *MockProjectGetter) {
+				repo.On("CreateTodo", mock.Anything, int64(1)).Return(&Todo{Id: 1, Title: "Call the plumber"}, nil)
+			},
+			expectedTitle:    "Call the plumber",
+			expectedPriority: PriorityLow,
+			expectedTags:     []string{},
+		},
+		{
+			name:  "Name that isn't a project is kept in the title",
+			input: &CreateTodoData{Title: "Ping @john about the invoice"},
+			setupMock: func(repo *MockRepository, mockProjects *MockProjectGetter) {
+				mockProjects.On("GetAllProjects", int64(1), (*int)(nil), false).Return(projects, nil)
+				repo.On("CreateTodo", mock.Anything, int64(1)).Return(&Todo{Id: 1, Title: "Ping @john about the invoice"}, nil)
+			},
+			expectedTitle:    "Ping @john about the invoice",
+			expectedPriority: PriorityNone,
+			expectedTags:     []string{},
+		},
+		{
+			name:          "Nothing is left for the title",
+			input:         &CreateTodoData{Title: "tomorrow #home"},
+			setupMock:     func(repo *MockRepository, mockProjects *MockProjectGetter) {},
+			expectedError: ErrTitleEmpty,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockProjects := new(MockProjectGetter)
+			service := NewTodoService(mockRepo, mockProjects)
+			tc.setupMock(mockRepo, mockProjects)
+
+			result, err := service.QuickAddTodo(tc.input, 1)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				mockRepo.AssertNotCalled(t, "CreateTodo", mock.Anything, mock.Anything)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedTitle, result.Title)
+
+			created := mockRepo.Calls[len(mockRepo.Calls)-1].Arguments.Get(0).(*Todo)
+			assert.Equal(t, tc.expectedTitle, created.Title)
+			assert.Equal(t, tc.expectedPriority, created.Priority)
+			assert.Equal(t, tc.expectedTags, created.Tags)
+			assert.Equal(t, tc.expectedProjectId, created.ProjectId)
+			if tc.expectedProjectId == nil {
+				assert.Nil(t, result.Recognized.Project)
+			}
+		})
+	}
+}
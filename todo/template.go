@@ -0,0 +1,161 @@
+package todo
+
+import (
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/umtdemr/go-todo/tag"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Template is a named checklist like "Release" whose todos can be created again and again with InstantiateTemplate
+type Template struct {
+	Id        int            `json:"id"`
+	Name      string         `json:"name"`
+	Items     []TemplateItem `json:"items"`
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+}
+
+// TemplateItem is a todo in a template. Its subtasks are created as the subtasks of the todo.
+// The items are stored as json
+type TemplateItem struct {
+	Title string  `json:"title"`
+	Notes *string `json:"notes,omitempty"`
+	// DueOffset is the number of days from the start date to the due date. The todo has no due date if it is nil
+	DueOffset *int           `json:"dueOffset,omitempty"`
+	Tags      []string       `json:"tags,omitempty"`
+	Subtasks  []TemplateItem `json:"subtasks,omitempty"`
+}
+
+type CreateTemplateData struct {
+	Name  string         `json:"name"`
+	Items []TemplateItem `json:"items"`
+}
+
+type UpdateTemplateData struct {
+	Id   *int    `json:"id,omitempty"`
+	Name *string `json:"name,omitempty"`
+	// Items replaces all the items of the template when it is sent
+	Items *[]TemplateItem `json:"items,omitempty"`
+}
+
+// InstantiateTemplateData is the start date that the due dates are calculated from and where the todos are created
+type InstantiateTemplateData struct {
+	StartDate *time.Time `json:"startDate,omitempty"`
+	// DueTimezone is the timezone of the due dates. The days are added in it, so the due dates keep their hour
+	// over daylight saving changes
+	DueTimezone *string `json:"dueTimezone,omitempty"`
+	ProjectId   *int    `json:"projectId,omitempty"`
+	// WorkspaceId is the active workspace of the request, not a part of the body
+	WorkspaceId *int `json:"-"`
+}
+
+const maxTemplateNameLength = 100
+
+// MaxTemplateItems is the maximum count of the items in a template, including the subtasks
+const MaxTemplateItems = 200
+
+// MaxTemplateDueOffset is the maximum number of days between the start date and the due date of an item
+const MaxTemplateDueOffset = 3650
+
+// templateColumns are the columns selected for scanning a template with ScanTemplate
+const templateColumns = `id, name, items, created_at, updated_at`
+
+func ScanTemplate(row pgx.Row) (*Template, error) {
+	t := new(Template)
+	err := row.Scan(&t.Id, &t.Name, &t.Items, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// validateTemplateName trims the name and checks its length
+func validateTemplateName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if nameLength := utf8.RuneCountInString(name); nameLength < 1 || nameLength > maxTemplateNameLength {
+		return "", ErrTemplateNameLength
+	}
+	return name, nil
+}
+
+// normalizeTemplateItems checks the items of a template and returns them with trimmed titles and normalized tags
+func normalizeTemplateItems(items []TemplateItem) ([]TemplateItem, error) {
+	if len(items) == 0 {
+		return nil, ErrTemplateItemsNotValid.With("template needs to have at least one item")
+	}
+
+	count := 0
+	normalized, err := normalizeTemplateLevel(items, &count)
+	if err != nil {
+		return nil, err
+	}
+	if count > MaxTemplateItems {
+		return nil, ErrTemplateItemsNotValid.With(fmt.Sprintf("template can have at most %d items", MaxTemplateItems))
+	}
+	return normalized, nil
+}
+
+// normalizeTemplateLevel normalizes the items of one level of the tree and counts them in count
+func normalizeTemplateLevel(items []TemplateItem, count *int) ([]TemplateItem, error) {
+	normalized := make([]TemplateItem, len(items))
+	for i, item := range items {
+		*count++
+
+		item.Title = strings.TrimSpace(item.Title)
+		if item.Title == "" {
+			return nil, ErrTemplateItemsNotValid.With("title of an item is empty")
+		}
+
+		if err := validateNotes(item.Notes); err != nil {
+			return nil, err
+		}
+
+		if item.DueOffset != nil && (*item.DueOffset < -MaxTemplateDueOffset || *item.DueOffset > MaxTemplateDueOffset) {
+			return nil, ErrTemplateItemsNotValid.With(
+				fmt.Sprintf("due offset of %q should be between -%d and %d days", item.Title, MaxTemplateDueOffset, MaxTemplateDueOffset),
+			)
+		}
+
+		tags, err := tag.NormalizeNames(item.Tags)
+		if err != nil {
+			return nil, err
+		}
+		item.Tags = tags
+
+		if len(item.Subtasks) > 0 {
+			subtasks, err := normalizeTemplateLevel(item.Subtasks, count)
+			if err != nil {
+				return nil, err
+			}
+			item.Subtasks = subtasks
+		}
+		normalized[i] = item
+	}
+	return normalized, nil
+}
+
+// templateTodos turns the items into todos that are copies of base with the fields of the items.
+// Due dates are the start date moved by the due offsets of the items, and the todos without one don't get a timezone
+func templateTodos(items []TemplateItem, base Todo, start time.Time) []Todo {
+	todos := make([]Todo, len(items))
+	for i, item := range items {
+		t := base
+		t.Title = item.Title
+		t.Notes = item.Notes
+		t.Tags = item.Tags
+		if item.DueOffset != nil {
+			dueDate := start.AddDate(0, 0, *item.DueOffset)
+			t.DueDate = &dueDate
+		} else {
+			t.DueTimezone = nil
+		}
+		if len(item.Subtasks) > 0 {
+			t.Subtasks = templateTodos(item.Subtasks, base, start)
+		}
+		todos[i] = t
+	}
+	return todos
+}
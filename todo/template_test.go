@@ -0,0 +1,31 @@
+package todo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestTemplateTodos(t *testing.T) {
+	location, _ := time.LoadLocation("Europe/Berlin")
+	// daylight saving time ends in Berlin on the 25th of October 2026
+	start := time.Date(2026, 10, 23, 9, 0, 0, 0, location)
+	before, after := -1, 3
+	items := []TemplateItem{
+		{Title: "Pack", DueOffset: &before, Tags: []string{"travel"}},
+		{Title: "Fly", DueOffset: &after, Subtasks: []TemplateItem{{Title: "Check in"}}},
+	}
+
+	todos := templateTodos(items, Todo{Status: "backlog"}, start)
+
+	assert.Len(t, todos, 2)
+	assert.Equal(t, "Pack", todos[0].Title)
+	assert.Equal(t, []string{"travel"}, todos[0].Tags)
+	assert.Equal(t, time.Date(2026, 10, 22, 9, 0, 0, 0, location), *todos[0].DueDate)
+	// the due date keeps its hour in the timezone after the clocks are changed
+	assert.Equal(t, 9, todos[1].DueDate.Hour())
+	assert.Equal(t, 26, todos[1].DueDate.Day())
+	assert.Equal(t, "Check in", todos[1].Subtasks[0].Title)
+	assert.Equal(t, "backlog", todos[1].Subtasks[0].Status)
+	assert.Nil(t, todos[1].Subtasks[0].DueDate)
+}
@@ -2,46 +2,320 @@ package todo
 
 import (
 	"github.com/jackc/pgx/v5"
+	"github.com/umtdemr/go-todo/share"
+	"strings"
 	"time"
 )
 
 type Todo struct {
-	Id        int       `json:"id"`
-	Title     string    `json:"title"`
-	Done      bool      `json:"done"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	Id    int    `json:"id"`
+	Title string `json:"title"`
+	Done  bool   `json:"done"`
+	// Status is the status of the todo in the workflow of its project. Done is true in the last status of the workflow
+	Status string `json:"status"`
+	// Notes is the Markdown source. NotesHTML is only filled when the rendering is asked for
+	Notes          *string    `json:"notes"`
+	NotesHTML      *string    `json:"notesHtml,omitempty"`
+	DueDate        *time.Time `json:"dueDate"`
+	DueTimezone    *string    `json:"dueTimezone"`
+	ReminderOffset *int       `json:"reminderOffset"`
+	Priority       Priority   `json:"priority"`
+	// Estimate is the planned effort in the estimate unit of the project of the todo
+	Estimate  *int     `json:"estimate"`
+	Position  float64  `json:"position"`
+	ProjectId *int     `json:"projectId"`
+	Tags      []string `json:"tags"`
+	ParentId  *int     `json:"parentId"`
+	// AutoComplete marks the todo as done when all of its subtasks are done
+	AutoComplete bool      `json:"autoComplete"`
+	Progress     *Progress `json:"progress"`
+	// AssigneeId is the user who is responsible for the todo. The assignee needs to be able to see the todo
+	AssigneeId *int64 `json:"assigneeId"`
+	// WorkspaceId is the workspace that the todo belongs to. It is nil for the personal todos
+	WorkspaceId *int `json:"workspaceId"`
+	// BlockedBy is the ids of the todos that need to be done before this todo.
+	// Blocked is true while any of them is not done
+	BlockedBy []int `json:"blockedBy"`
+	Blocked   bool  `json:"blocked"`
+	// Subtasks are only filled when a single todo is fetched
+	Subtasks []Todo `json:"subtasks,omitempty"`
+	// Role is the role of the user on the todo. It is only filled when a single todo is fetched
+	Role share.Role `json:"role,omitempty"`
+	// Recurrence is an RRULE. SeriesId is the id of the first todo of the recurring series
+	Recurrence *string `json:"recurrence"`
+	SeriesId   *int    `json:"seriesId"`
+	Occurrence int     `json:"occurrence"`
+	// Archived todos are hidden from the list unless they are asked for. Only done todos can be archived
+	Archived    bool       `json:"archived"`
+	CompletedAt *time.Time `json:"completedAt"`
+	// DeletedAt is set when the todo is in the trash
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
 }
 
 type CreateTodoData struct {
-	Title string `json:"title"`
+	Title          string     `json:"title"`
+	Notes          *string    `json:"notes,omitempty"`
+	DueDate        *time.Time `json:"dueDate,omitempty"`
+	DueTimezone    *string    `json:"dueTimezone,omitempty"`
+	ReminderOffset *int       `json:"reminderOffset,omitempty"`
+	Priority       *Priority  `json:"priority,omitempty"`
+	Estimate       *int       `json:"estimate,omitempty"`
+	ProjectId      *int       `json:"projectId,omitempty"`
+	Tags           []string   `json:"tags,omitempty"`
+	ParentId       *int       `json:"parentId,omitempty"`
+	AutoComplete   *bool      `json:"autoComplete,omitempty"`
+	Recurrence     *string    `json:"recurrence,omitempty"`
+	AssigneeId     *int64     `json:"assigneeId,omitempty"`
+	BlockedBy      []int      `json:"blockedBy,omitempty"`
+	// Status is the first status of the workflow if it is not sent
+	Status *string `json:"status,omitempty"`
+	// WorkspaceId is the active workspace of the request, not a part of the body.
+	// Subtasks and todos in a project are put in the workspace of their parent or their project instead
+	WorkspaceId *int `json:"-"`
 }
 
 type UpdateTodoData struct {
 	Id    *int    `json:"id,omitempty"`
 	Title *string `json:"title,omitempty"`
+	Notes *string `json:"notes,omitempty"`
 	Done  *bool   `json:"done,omitempty"`
+	// Status moves the todo in the workflow of its project. Done is set from the status, and setting done moves the todo
+	// to the last status or back to an open one
+	Status         *string    `json:"status,omitempty"`
+	DueDate        *time.Time `json:"dueDate,omitempty"`
+	DueTimezone    *string    `json:"dueTimezone,omitempty"`
+	ReminderOffset *int       `json:"reminderOffset,omitempty"`
+	Priority       *Priority  `json:"priority,omitempty"`
+	Estimate       *int       `json:"estimate,omitempty"`
+	ProjectId      *int       `json:"projectId,omitempty"`
+	// Tags replaces the tags of the todo when it is sent. An empty list removes all the tags
+	Tags         *[]string `json:"tags,omitempty"`
+	ParentId     *int      `json:"parentId,omitempty"`
+	AutoComplete *bool     `json:"autoComplete,omitempty"`
+	Recurrence   *string   `json:"recurrence,omitempty"`
+	Archived     *bool     `json:"archived,omitempty"`
+	AssigneeId   *int64    `json:"assigneeId,omitempty"`
+	// BlockedBy replaces the todos blocking the todo when it is sent. An empty list removes all the dependencies
+	BlockedBy *[]int `json:"blockedBy,omitempty"`
+	// Scope is either "this" for updating only this occurrence of a recurring todo
+	// or "series" for updating the open occurrences of the series too
+	Scope *string `json:"scope,omitempty"`
+	// Clear holds the names of the nullable fields that should be set to null
+	Clear []string `json:"clear,omitempty"`
+}
+
+// OccurrenceUpdate is an update of a recurring todo that is applied in one transaction
+// with the creation of its next occurrence and the edits of its series
+type OccurrenceUpdate struct {
+	Todo *UpdateTodoData
+	// NextOccurrence returns the next occurrence to create for the updated todo. Nothing is created if it is nil
+	// or returns nil
+	NextOccurrence func(updated *Todo) (*Todo, error)
+	// Series is applied to the other occurrences of the series with the SeriesIds
+	Series    *UpdateTodoData
+	SeriesIds []int
+}
+
+// Progress is the count of the direct subtasks of a todo
+type Progress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
 }
 
 type DeleteTodoData struct {
 	Id *string `json:"id,omitempty"`
 }
 
-func ScanTodo(row pgx.Row) (*Todo, error) {
+// ReorderTodoData moves the todo with the Id either before BeforeId or after AfterId
+type ReorderTodoData struct {
+	Id       *int `json:"id,omitempty"`
+	BeforeId *int `json:"beforeId,omitempty"`
+	AfterId  *int `json:"afterId,omitempty"`
+}
+
+// SortOption is the order of the listed todos
+type SortOption struct {
+	Field string
+	Desc  bool
+}
+
+// ListOptions holds the filters that can be applied while listing todos
+type ListOptions struct {
+	Overdue   bool
+	DueToday  bool
+	DueBefore *time.Time
+	// Timezone is used for deciding what "today" is. If it is nil, the timezone of each todo is used
+	Timezone *string
+	// ProjectId lists the todos of a project. Inbox lists the todos without a project
+	ProjectId *int
+	Inbox     bool
+	// Tags lists the todos with any of the tags, or all of them if MatchAllTags is set
+	Tags         []string
+	MatchAllTags bool
+	// ParentId lists the subtasks of a todo. TopLevel lists the todos without a parent
+	ParentId *int
+	TopLevel bool
+	// SeriesId lists the occurrences of a recurring todo
+	SeriesId *int
+	// AssignedToMe lists the todos assigned to the user who lists them
+	AssignedToMe bool
+	// WorkspaceId lists the todos of a workspace. Personal lists the todos that are not in a workspace of the user.
+	// All the visible todos are listed if neither is set
+	WorkspaceId *int
+	Personal    bool
+	// archived todos are excluded unless IncludeArchived is set. OnlyArchived lists only the archived todos
+	IncludeArchived bool
+	OnlyArchived    bool
+	Sort            *SortOption
+	// Limit is the maximum count of todos to return. 0 means no limit. After lists the todos after the cursor
+	Limit int
+	After *Cursor
+	// Filter is a parsed filter expression that is applied together with the other options
+	Filter FilterNode
+}
+
+// sortColumns maps the sort fields of the list to their columns
+var sortColumns = map[string]string{
+	"priority":   "priority",
+	"position":   "position",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+	"due_date":   "due_date",
+}
+
+// ParseSortOption parses sort values like "priority" or "-due_date". "-" prefix means descending order
+func ParseSortOption(value string) (*SortOption, error) {
+	option := &SortOption{Field: value}
+	if strings.HasPrefix(value, "-") {
+		option.Field = strings.TrimPrefix(value, "-")
+		option.Desc = true
+	}
+
+	if _, ok := sortColumns[option.Field]; !ok {
+		return nil, ErrSortNotValid.With(value)
+	}
+	return option, nil
+}
+
+// clearableFields maps the fields that can be cleared with UpdateTodoData.Clear to their columns
+var clearableFields = map[string]string{
+	"dueDate":        "due_date",
+	"dueTimezone":    "due_timezone",
+	"reminderOffset": "reminder_offset",
+	"projectId":      "project_id",
+	"parentId":       "parent_id",
+	"recurrence":     "recurrence",
+	"notes":          "notes",
+	"assigneeId":     "assignee_id",
+	"estimate":       "estimate",
+}
+
+const (
+	ScopeThis   = "this"
+	ScopeSeries = "series"
+)
+
+// todoColumns are the columns selected for scanning a todo with ScanTodo.
+// Tags are aggregated in the same query to avoid fetching them for each todo.
+// Subtasks are counted if they share the trash state of the todo, so trashed todos keep the progress they had.
+// Blocking todos in the trash don't block the todo anymore
+const todoColumns = `id, title, notes, done, status, due_date, due_timezone, reminder_offset, priority, estimate, position,
+	project_id, ARRAY(SELECT "tag".name FROM todo_tag JOIN "tag" ON "tag".id = todo_tag.tag_id
+		WHERE todo_tag.todo_id = "todo".id ORDER BY "tag".name) AS tags,
+	parent_id, auto_complete,
+	(SELECT COUNT(*) FROM "todo" child WHERE child.parent_id = "todo".id
+		AND child.deleted_at IS NOT DISTINCT FROM "todo".deleted_at) AS subtask_count,
+	(SELECT COUNT(*) FROM "todo" child WHERE child.parent_id = "todo".id
+		AND child.deleted_at IS NOT DISTINCT FROM "todo".deleted_at AND child.done) AS done_subtask_count,
+	recurrence, COALESCE(series_id, CASE WHEN recurrence IS NOT NULL THEN id END) AS series_id, occurrence,
+	archived, completed_at, deleted_at, created_at, updated_at, assignee_id, workspace_id,
+	ARRAY(SELECT blocker.id FROM todo_dependency JOIN "todo" blocker ON blocker.id = todo_dependency.blocked_by_id
+		WHERE todo_dependency.todo_id = "todo".id AND blocker.deleted_at IS NULL ORDER BY blocker.id) AS blocked_by,
+	EXISTS (SELECT 1 FROM todo_dependency JOIN "todo" blocker ON blocker.id = todo_dependency.blocked_by_id
+		WHERE todo_dependency.todo_id = "todo".id AND blocker.deleted_at IS NULL AND NOT blocker.done) AS blocked`
+
+// ScanTodo scans a row selected with todoColumns. extra is scanned from the columns selected after todoColumns
+func ScanTodo(row pgx.Row, extra ...any) (*Todo, error) {
 	var t *Todo
 	t = new(Todo) // initialize it since we need to pass values into a pointer
-	err := row.Scan(&t.Id, &t.Title, &t.Done, &t.CreatedAt, &t.UpdatedAt)
+	var progress Progress
+	dest := []any{
+		&t.Id,
+		&t.Title,
+		&t.Notes,
+		&t.Done,
+		&t.Status,
+		&t.DueDate,
+		&t.DueTimezone,
+		&t.ReminderOffset,
+		&t.Priority,
+		&t.Estimate,
+		&t.Position,
+		&t.ProjectId,
+		&t.Tags,
+		&t.ParentId,
+		&t.AutoComplete,
+		&progress.Total,
+		&progress.Done,
+		&t.Recurrence,
+		&t.SeriesId,
+		&t.Occurrence,
+		&t.Archived,
+		&t.CompletedAt,
+		&t.DeletedAt,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+		&t.AssigneeId,
+		&t.WorkspaceId,
+		&t.BlockedBy,
+		&t.Blocked,
+	}
+	err := row.Scan(append(dest, extra...)...)
 	if err != nil {
 		return nil, err
 	}
+
+	if progress.Total > 0 {
+		t.Progress = &progress
+	}
+
+	// show the due date in the timezone it was set in
+	if t.DueDate != nil && t.DueTimezone != nil {
+		if loc, locErr := time.LoadLocation(*t.DueTimezone); locErr == nil {
+			dueDate := t.DueDate.In(loc)
+			t.DueDate = &dueDate
+		}
+	}
 	return t, nil
 }
 
+// buildSubtaskTree nests the given descendants under the todo they belong to, keeping their order
+func buildSubtaskTree(root *Todo, descendants []Todo) {
+	children := make(map[int][]Todo)
+	for _, descendant := range descendants {
+		if descendant.ParentId != nil {
+			children[*descendant.ParentId] = append(children[*descendant.ParentId], descendant)
+		}
+	}
+
+	var attach func(t *Todo)
+	attach = func(t *Todo) {
+		t.Subtasks = children[t.Id]
+		for i := range t.Subtasks {
+			attach(&t.Subtasks[i])
+		}
+	}
+	attach(root)
+}
+
 func NewTodo(title string) *Todo {
 	return &Todo{
-		Id:        1,
-		Title:     title,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		Id:         1,
+		Title:      title,
+		Occurrence: 1,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
 	}
 }
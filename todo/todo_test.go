@@ -0,0 +1,30 @@
+package todo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBuildSubtaskTree(t *testing.T) {
+	rootId := 1
+	childId := 2
+	grandChildId := 4
+
+	root := &Todo{Id: rootId}
+	descendants := []Todo{
+		{Id: childId, ParentId: &rootId},
+		{Id: 3, ParentId: &rootId},
+		{Id: grandChildId, ParentId: &childId},
+		{Id: 5, ParentId: &grandChildId},
+	}
+
+	buildSubtaskTree(root, descendants)
+
+	assert.Len(t, root.Subtasks, 2)
+	assert.Equal(t, childId, root.Subtasks[0].Id)
+	assert.Equal(t, 3, root.Subtasks[1].Id)
+	assert.Len(t, root.Subtasks[0].Subtasks, 1)
+	assert.Equal(t, grandChildId, root.Subtasks[0].Subtasks[0].Id)
+	assert.Equal(t, 5, root.Subtasks[0].Subtasks[0].Subtasks[0].Id)
+	assert.Empty(t, root.Subtasks[1].Subtasks)
+}
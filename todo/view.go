@@ -0,0 +1,126 @@
+package todo
+
+import (
+	"github.com/jackc/pgx/v5"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// View is a saved list of todos with a name like "This week at work"
+type View struct {
+	Id        int          `json:"id"`
+	Name      string       `json:"name"`
+	Criteria  ViewCriteria `json:"criteria"`
+	CreatedAt time.Time    `json:"createdAt"`
+	UpdatedAt time.Time    `json:"updatedAt"`
+}
+
+// ViewCriteria are the conditions the todos of a view need to match. They are stored as json
+type ViewCriteria struct {
+	Done *bool `json:"done,omitempty"`
+	// Title is a text that the titles of the todos need to contain
+	Title         *string    `json:"title,omitempty"`
+	CreatedAfter  *time.Time `json:"createdAfter,omitempty"`
+	CreatedBefore *time.Time `json:"createdBefore,omitempty"`
+	UpdatedAfter  *time.Time `json:"updatedAfter,omitempty"`
+	UpdatedBefore *time.Time `json:"updatedBefore,omitempty"`
+	// Filter is an expression in the syntax of the filter list option for the other conditions
+	Filter *string `json:"filter,omitempty"`
+	// Sort is a sort value of the list like "-priority"
+	Sort *string `json:"sort,omitempty"`
+}
+
+type CreateViewData struct {
+	Name     string       `json:"name"`
+	Criteria ViewCriteria `json:"criteria"`
+}
+
+type UpdateViewData struct {
+	Id       *int          `json:"id,omitempty"`
+	Name     *string       `json:"name,omitempty"`
+	Criteria *ViewCriteria `json:"criteria,omitempty"`
+}
+
+const maxViewNameLength = 100
+
+// viewColumns are the columns selected for scanning a view with ScanView
+const viewColumns = `id, name, criteria, created_at, updated_at`
+
+func ScanView(row pgx.Row) (*View, error) {
+	v := new(View)
+	err := row.Scan(&v.Id, &v.Name, &v.Criteria, &v.CreatedAt, &v.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// validateViewName trims the name and checks its length
+func validateViewName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if nameLength := utf8.RuneCountInString(name); nameLength < 1 || nameLength > maxViewNameLength {
+		return "", ErrViewNameLength
+	}
+	return name, nil
+}
+
+// Validate checks that the criteria can be turned into list options
+func (c *ViewCriteria) Validate() error {
+	if c.Sort != nil {
+		if _, err := ParseSortOption(*c.Sort); err != nil {
+			return ErrViewCriteriaNotValid.With(err.Error())
+		}
+	}
+
+	if c.CreatedAfter != nil && c.CreatedBefore != nil && !c.CreatedAfter.Before(*c.CreatedBefore) {
+		return ErrViewCriteriaNotValid.With("createdAfter should be before createdBefore")
+	}
+
+	if c.UpdatedAfter != nil && c.UpdatedBefore != nil && !c.UpdatedAfter.Before(*c.UpdatedBefore) {
+		return ErrViewCriteriaNotValid.With("updatedAfter should be before updatedBefore")
+	}
+
+	if expression := c.FilterExpression(); expression != "" {
+		if _, err := ParseFilter(expression, time.UTC); err != nil {
+			return ErrViewCriteriaNotValid.With(err.Error())
+		}
+	}
+	return nil
+}
+
+// FilterExpression returns the criteria as a filter expression, so that a view is listed in the same way
+// as a list request with a filter. It is empty if the criteria don't have any condition
+func (c *ViewCriteria) FilterExpression() string {
+	var terms []string
+
+	if c.Done != nil {
+		terms = append(terms, "done:"+strconv.FormatBool(*c.Done))
+	}
+
+	if c.Title != nil && *c.Title != "" {
+		terms = append(terms, "title:"+strconv.Quote(*c.Title))
+	}
+
+	timeTerms := []struct {
+		prefix string
+		value  *time.Time
+	}{
+		{"created>=", c.CreatedAfter},
+		{"created<", c.CreatedBefore},
+		{"updated>=", c.UpdatedAfter},
+		{"updated<", c.UpdatedBefore},
+	}
+	for _, timeTerm := range timeTerms {
+		if timeTerm.value != nil {
+			terms = append(terms, timeTerm.prefix+timeTerm.value.Format(time.RFC3339Nano))
+		}
+	}
+
+	if c.Filter != nil && strings.TrimSpace(*c.Filter) != "" {
+		terms = append(terms, "("+*c.Filter+")")
+	}
+
+	return strings.Join(terms, " ")
+}
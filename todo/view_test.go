@@ -0,0 +1,45 @@
+package todo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestViewCriteriaFilterExpression(t *testing.T) {
+	done := true
+	title := `report "Q4"`
+	filter := "tag:work OR tag:home"
+	updatedAfter := time.Date(2026, time.November, 2, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name               string
+		criteria           ViewCriteria
+		expectedExpression string
+	}{
+		{name: "No criteria", criteria: ViewCriteria{}, expectedExpression: ""},
+		{
+			name:               "All criteria",
+			criteria:           ViewCriteria{Done: &done, Title: &title, UpdatedAfter: &updatedAfter, Filter: &filter},
+			expectedExpression: `done:true title:"report \"Q4\"" updated>=2026-11-02T08:00:00Z (tag:work OR tag:home)`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			expression := tc.criteria.FilterExpression()
+			assert.Equal(t, tc.expectedExpression, expression)
+
+			if expression == "" {
+				return
+			}
+
+			// the expression is listed through the filter, so it needs to parse back to the criteria
+			node, err := ParseFilter(expression, time.UTC)
+			assert.Nil(t, err)
+			and := node.(FilterAnd)
+			assert.Equal(t, title, and.Nodes[1].(FilterTerm).Value)
+			assert.Equal(t, FilterTime{Start: updatedAfter, End: updatedAfter}, and.Nodes[2].(FilterTerm).Value)
+		})
+	}
+}
@@ -24,6 +24,7 @@ func (route *APIRoute) RegisterAPIRoutes(router *mux.Router) {
 	router.HandleFunc("/user/login", route.handleLogin)
 	router.HandleFunc("/user/reset-password-request", route.handleResetPasswordRequest)
 	router.HandleFunc("/user/new-password", route.handleNewPassword)
+	router.Handle("/user/preferences", route.Service.AuthMiddleware(http.HandlerFunc(route.handlePreferences)))
 }
 
 // handleCreateUser handles the create user request
@@ -174,3 +175,44 @@ func (route *APIRoute) handleNewPassword(w http.ResponseWriter, r *http.Request)
 	server.RespondOK(w, message)
 	return
 }
+
+// handlePreferences responds with the preferences of the user with GET and changes them with POST
+func (route *APIRoute) handlePreferences(w http.ResponseWriter, r *http.Request) {
+	// only GET and POST requests are allowed
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		err := server.ErrNotValidMethod.With("only GET and POST requests are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	authenticatedUser := r.Context().Value("user").(*VisibleUser)
+
+	if r.Method == http.MethodGet {
+		preferences, err := route.Service.GetPreferences(authenticatedUser.Id)
+		if err != nil {
+			server.RespondWithError(w, fmt.Sprintf("error while getting the preferences: %v", err), http.StatusBadRequest)
+			return
+		}
+		server.RespondOK(w, preferences)
+		return
+	}
+
+	var updateData UpdatePreferencesData
+	decodeErr := server.DecodeBody(r, &updateData)
+	if decodeErr != nil {
+		server.RespondWithError(w, "couldn't decode the body", http.StatusBadRequest)
+		return
+	}
+
+	preferences, err := route.Service.UpdatePreferences(authenticatedUser.Id, &updateData)
+	if err != nil {
+		var e UserError
+		if errors.As(err, &e) {
+			server.RespondWithErrorFields(w, fmt.Sprintf("validation error: %v", e.Error()), http.StatusBadRequest, e.fields)
+			return
+		}
+		server.RespondWithError(w, fmt.Sprintf("error while updating the preferences: %v", err), http.StatusBadRequest)
+		return
+	}
+	server.RespondOK(w, preferences)
+}
@@ -14,6 +14,7 @@ const (
 	jwtNotValid
 	usernameOrPasswordWrong
 	userNotFound
+	noPreference
 )
 
 type UserError struct {
@@ -47,6 +48,8 @@ func (e UserError) Error() string {
 		return "username or password is incorrect"
 	case userNotFound:
 		return "user not found"
+	case noPreference:
+		return "no preference is provided"
 	}
 	return "error in user"
 }
@@ -62,4 +65,5 @@ var (
 	ErrTokenNotValid               = UserError{kind: jwtNotValid}
 	ErrUsernameOrPasswordIncorrect = UserError{kind: usernameOrPasswordWrong, fields: Fields{"username", "password"}}
 	ErrUserNotFound                = UserError{kind: userNotFound}
+	ErrNoPreference                = UserError{kind: noPreference}
 )
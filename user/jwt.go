@@ -98,3 +98,49 @@ func ValidateResetPasswordToken(tokenString string) (string, error) {
 	}
 	return "", ErrTokenNotValid
 }
+
+// GenerateInvitationToken creates the token that is emailed to the invited address.
+// It is only accepted for the invitation with the given id until it expires
+func GenerateInvitationToken(invitationId int, email string, expiresAt time.Time) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"invitationId":      invitationId,
+		"email":             email,
+		"exp":               expiresAt.Unix(),
+		"isInvitationToken": true, // identifier. We seek if the props exists. Value is not important here
+	})
+
+	return token.SignedString(jwtSecret)
+}
+
+// ValidateInvitationToken returns the invitation id and the invited email of the token
+func ValidateInvitationToken(tokenString string) (int, string, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected siging method: %v", token.Header["alg"])
+		}
+		return jwtSecret, nil
+	})
+
+	if err != nil || !token.Valid {
+		return 0, "", ErrTokenNotValid
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return 0, "", ErrTokenNotValid
+	}
+
+	_, isExpirationExists := claims["exp"] // no need to check if the token expired since it's automatically checked
+	_, isInvitationToken := claims["isInvitationToken"]
+	if !isExpirationExists || !isInvitationToken {
+		return 0, "", ErrTokenNotValid
+	}
+
+	// numbers are decoded as float64 from the claims
+	invitationId, isIdValid := claims["invitationId"].(float64)
+	email, isEmailValid := claims["email"].(string)
+	if !isIdValid || !isEmailValid {
+		return 0, "", ErrTokenNotValid
+	}
+
+	return int(invitationId), email, nil
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func TestGenerateNewJWT(t *testing.T) {
@@ -62,3 +63,49 @@ func TestValidateJWT(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateInvitationToken(t *testing.T) {
+	validToken, _ := GenerateInvitationToken(7, "invited@test.com", time.Now().Add(time.Hour))
+	expiredToken, _ := GenerateInvitationToken(7, "invited@test.com", time.Now().Add(-time.Hour))
+	loginToken, _ := GenerateNewJWT("testuser")
+	resetToken, _ := GenerateResetPasswordToken("invited@test.com")
+
+	tests := []struct {
+		name                 string
+		tokenString          string
+		expectedError        error
+		expectedInvitationId int
+		expectedEmail        string
+	}{
+		{
+			name:                 "Valid Token",
+			tokenString:          validToken,
+			expectedInvitationId: 7,
+			expectedEmail:        "invited@test.com",
+		},
+		{
+			name:          "Expired Token",
+			tokenString:   expiredToken,
+			expectedError: ErrTokenNotValid,
+		},
+		{
+			name:          "Login Token",
+			tokenString:   loginToken,
+			expectedError: ErrTokenNotValid,
+		},
+		{
+			name:          "Reset Password Token",
+			tokenString:   resetToken,
+			expectedError: ErrTokenNotValid,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			invitationId, email, err := ValidateInvitationToken(tc.tokenString)
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedInvitationId, invitationId)
+			assert.Equal(t, tc.expectedEmail, email)
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type IRepository interface {
@@ -12,17 +13,31 @@ type IRepository interface {
 	GetUserByUsername(username string) *VisibleUser
 	GetUserByEmail(email string) *VisibleUser
 	UpdateUserPassword(userId int64, newPassword string) error
+	GetUserById(userId int64) *VisibleUser
+	GetPreferences(userId int64) (*Preferences, error)
+	UpdatePreferences(userId int64, data *UpdatePreferencesData) (*Preferences, error)
 }
 
 type Repository struct {
-	db *pgx.Conn
+	db *pgxpool.Pool
 }
 
-func NewUserRepository(dbConn *pgx.Conn) (*Repository, error) {
+func NewUserRepository(dbConn *pgxpool.Pool) (*Repository, error) {
 	return &Repository{dbConn}, nil
 }
 func (repository *Repository) Init() error {
-	return repository.CreateUserTable()
+	if err := repository.CreateUserTable(); err != nil {
+		return err
+	}
+	return repository.MigrateUserTable()
+}
+
+// MigrateUserTable adds the columns that were introduced after the first version of the user table
+func (repository *Repository) MigrateUserTable() error {
+	query := `ALTER TABLE "user" ADD COLUMN IF NOT EXISTS notify_on_assignment boolean NOT NULL DEFAULT true`
+
+	_, err := repository.db.Exec(context.Background(), query)
+	return err
 }
 
 func (repository *Repository) CreateUserTable() error {
@@ -121,6 +136,52 @@ func (repository *Repository) GetUserByEmail(email string) *VisibleUser {
 	return &user
 }
 
+func (repository *Repository) GetUserById(userId int64) *VisibleUser {
+	var user VisibleUser
+
+	query := `SELECT id, username, email, created_at FROM "user" WHERE id=@id`
+	args := pgx.NamedArgs{"id": userId}
+
+	queryRow := repository.db.QueryRow(context.Background(), query, args)
+
+	err := queryRow.Scan(&user.Id, &user.Username, &user.Email, &user.CreatedAt)
+	if err != nil {
+		return nil
+	}
+
+	return &user
+}
+
+func (repository *Repository) GetPreferences(userId int64) (*Preferences, error) {
+	var preferences Preferences
+
+	query := `SELECT notify_on_assignment FROM "user" WHERE id = @id`
+	args := pgx.NamedArgs{"id": userId}
+
+	err := repository.db.QueryRow(context.Background(), query, args).Scan(&preferences.NotifyOnAssignment)
+	if err != nil {
+		return nil, err
+	}
+	return &preferences, nil
+}
+
+func (repository *Repository) UpdatePreferences(userId int64, data *UpdatePreferencesData) (*Preferences, error) {
+	var preferences Preferences
+
+	query := `UPDATE "user" SET notify_on_assignment = COALESCE(@notifyOnAssignment, notify_on_assignment)
+		WHERE id = @id RETURNING notify_on_assignment`
+	args := pgx.NamedArgs{
+		"id":                 userId,
+		"notifyOnAssignment": data.NotifyOnAssignment,
+	}
+
+	err := repository.db.QueryRow(context.Background(), query, args).Scan(&preferences.NotifyOnAssignment)
+	if err != nil {
+		return nil, err
+	}
+	return &preferences, nil
+}
+
 func (repository *Repository) UpdateUserPassword(userId int64, newPassword string) error {
 	query := `UPDATE "user" SET password = @password WHERE id = @id`
 	args := pgx.NamedArgs{
@@ -5,6 +5,7 @@ import (
 	"github.com/alexedwards/argon2id"
 	"github.com/jackc/pgx/v5"
 	"regexp"
+	"strings"
 )
 
 type Service struct {
@@ -63,6 +64,30 @@ func (service *Service) CreateUser(data *CreateUserData) error {
 	return service.repository.CreateUser(data)
 }
 
+// FindUser returns the user with the username or the email. It is nil if there is no such user
+func (service *Service) FindUser(identifier string) *VisibleUser {
+	if strings.Contains(identifier, "@") {
+		return service.repository.GetUserByEmail(identifier)
+	}
+	return service.repository.GetUserByUsername(identifier)
+}
+
+// GetUser returns the user with the id. It is nil if there is no such user
+func (service *Service) GetUser(userId int64) *VisibleUser {
+	return service.repository.GetUserById(userId)
+}
+
+func (service *Service) GetPreferences(userId int64) (*Preferences, error) {
+	return service.repository.GetPreferences(userId)
+}
+
+func (service *Service) UpdatePreferences(userId int64, data *UpdatePreferencesData) (*Preferences, error) {
+	if data.NotifyOnAssignment == nil {
+		return nil, ErrNoPreference
+	}
+	return service.repository.UpdatePreferences(userId, data)
+}
+
 func (service *Service) Login(data *LoginUserData) (string, error) {
 	if data.Password == nil {
 		return "", ErrPasswordLength
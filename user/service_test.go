@@ -47,6 +47,30 @@ func (m *MockRepository) UpdateUserPassword(userId int64, newPassword string) er
 	return args.Error(0)
 }
 
+func (m *MockRepository) GetUserById(userId int64) *VisibleUser {
+	args := m.Called(userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*VisibleUser)
+	}
+	return nil
+}
+
+func (m *MockRepository) GetPreferences(userId int64) (*Preferences, error) {
+	args := m.Called(userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Preferences), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) UpdatePreferences(userId int64, data *UpdatePreferencesData) (*Preferences, error) {
+	args := m.Called(userId, data)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Preferences), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
 func TestCreateUser(t *testing.T) {
 	mockRepo := new(MockRepository)
 	service := NewUserService(mockRepo)
@@ -278,3 +302,20 @@ func TestLogin(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdatePreferences(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewUserService(mockRepo)
+
+	_, err := service.UpdatePreferences(1, &UpdatePreferencesData{})
+	assert.Equal(t, ErrNoPreference, err)
+
+	notify := false
+	data := &UpdatePreferencesData{NotifyOnAssignment: &notify}
+	mockRepo.On("UpdatePreferences", int64(1), data).Return(&Preferences{NotifyOnAssignment: false}, nil)
+
+	preferences, err := service.UpdatePreferences(1, data)
+	assert.Nil(t, err)
+	assert.False(t, preferences.NotifyOnAssignment)
+	mockRepo.AssertExpectations(t)
+}
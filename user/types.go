@@ -40,6 +40,16 @@ type UserParams struct {
 	CreatedAt  time.Time `json:"created_at"`
 }
 
+// Preferences are the settings of a user
+type Preferences struct {
+	// NotifyOnAssignment sends an email when someone assigns a todo to the user
+	NotifyOnAssignment bool `json:"notifyOnAssignment"`
+}
+
+type UpdatePreferencesData struct {
+	NotifyOnAssignment *bool `json:"notifyOnAssignment,omitempty"`
+}
+
 type ResetPasswordRequest struct {
 	Email string `json:"email"`
 }
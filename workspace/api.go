@@ -0,0 +1,273 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/umtdemr/go-todo/server"
+	"github.com/umtdemr/go-todo/user"
+	"net/http"
+	"strconv"
+)
+
+type APIRoute struct {
+	Route   string
+	Service *Service
+}
+
+func NewWorkspaceAPIRoute(service *Service) *APIRoute {
+	return &APIRoute{Route: "workspace", Service: service}
+}
+
+// RegisterRoutes registers the routes for the workspace API.
+// The routes with a workspace in their path are only available to its members
+func (s *APIRoute) RegisterRoutes(router *mux.Router, userService user.Service) {
+	router.Handle("/workspace", userService.AuthMiddleware(http.HandlerFunc(s.handleList)))
+	router.Handle("/workspace/create", userService.AuthMiddleware(http.HandlerFunc(s.handleAdd)))
+	router.Handle("/workspace/invitations/accept", userService.AuthMiddleware(http.HandlerFunc(s.handleAccept)))
+	router.Handle("/workspace/{workspaceId}", userService.AuthMiddleware(
+		s.Service.RequireRole(RoleMember, http.HandlerFunc(s.handleFetchUpdateAndDelete)),
+	))
+	router.Handle("/workspace/{workspaceId}/members", userService.AuthMiddleware(
+		s.Service.RequireRole(RoleMember, http.HandlerFunc(s.handleMemberList)),
+	))
+	router.Handle("/workspace/{workspaceId}/members/{userId}", userService.AuthMiddleware(
+		s.Service.RequireRole(RoleMember, http.HandlerFunc(s.handleMemberUpdateAndDelete)),
+	))
+	router.Handle("/workspace/{workspaceId}/invitations", userService.AuthMiddleware(
+		s.Service.RequireRole(RoleAdmin, http.HandlerFunc(s.handleInvitationListAndAdd)),
+	))
+	router.Handle("/workspace/{workspaceId}/invitations/{invitationId}", userService.AuthMiddleware(
+		s.Service.RequireRole(RoleAdmin, http.HandlerFunc(s.handleInvitationDelete)),
+	))
+}
+
+// respondWithWorkspaceError responds with the fields that caused the error if the error is a WorkspaceError
+func respondWithWorkspaceError(w http.ResponseWriter, msg string, err error) {
+	var e WorkspaceError
+	if errors.As(err, &e) {
+		server.RespondWithErrorFields(w, fmt.Sprintf("validation error: %v", e.Error()), http.StatusBadRequest, e.fields)
+		return
+	}
+	server.RespondWithError(w, fmt.Sprintf("%s: %s", msg, err), http.StatusBadRequest)
+}
+
+// handleList lists the workspaces of the user
+func (s *APIRoute) handleList(w http.ResponseWriter, r *http.Request) {
+	// only GET methods are allowed
+	if r.Method != http.MethodGet {
+		err := server.ErrNotValidMethod.With("only GET methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+	workspaces, err := s.Service.GetAllWorkspaces(authenticatedUser.Id)
+	if err != nil {
+		respondWithWorkspaceError(w, "error while getting the workspaces", err)
+		return
+	}
+	server.RespondOK(w, workspaces)
+}
+
+// handleAdd creates a workspace that is owned by the user
+func (s *APIRoute) handleAdd(w http.ResponseWriter, r *http.Request) {
+	// only POST methods are allowed
+	if r.Method != http.MethodPost {
+		err := server.ErrNotValidMethod.With("only POST methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var createData CreateWorkspaceData
+	if err := server.DecodeBody(r, &createData); err != nil {
+		server.RespondWithError(w, fmt.Sprintf("parsing error: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+	created, err := s.Service.CreateWorkspace(&createData, authenticatedUser.Id)
+	if err != nil {
+		respondWithWorkspaceError(w, "error while creating the workspace", err)
+		return
+	}
+	server.RespondCreated(w, created)
+}
+
+// handleAccept adds the user to the workspace of the invitation token
+func (s *APIRoute) handleAccept(w http.ResponseWriter, r *http.Request) {
+	// only POST methods are allowed
+	if r.Method != http.MethodPost {
+		err := server.ErrNotValidMethod.With("only POST methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var acceptData AcceptInvitationData
+	if err := server.DecodeBody(r, &acceptData); err != nil {
+		server.RespondWithError(w, fmt.Sprintf("parsing error: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+	joined, err := s.Service.AcceptInvitation(&acceptData, authenticatedUser)
+	if err != nil {
+		respondWithWorkspaceError(w, "error while accepting the invitation", err)
+		return
+	}
+	server.RespondOK(w, joined)
+}
+
+// handleFetchUpdateAndDelete fetches the workspace with GET, renames it with POST and deletes it with DELETE
+func (s *APIRoute) handleFetchUpdateAndDelete(w http.ResponseWriter, r *http.Request) {
+	ws := FromContext(r.Context())
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+
+	switch r.Method {
+	case http.MethodGet:
+		server.RespondOK(w, ws)
+	case http.MethodPost:
+		var updateData UpdateWorkspaceData
+		if err := server.DecodeBody(r, &updateData); err != nil {
+			server.RespondWithError(w, fmt.Sprintf("error while parsing: %s", err), http.StatusBadRequest)
+			return
+		}
+
+		updated, err := s.Service.UpdateWorkspace(ws, &updateData, authenticatedUser.Id)
+		if err != nil {
+			respondWithWorkspaceError(w, "error while updating the workspace", err)
+			return
+		}
+		server.RespondOK(w, updated)
+	case http.MethodDelete:
+		removed, err := s.Service.RemoveWorkspace(ws, authenticatedUser.Id)
+		if err != nil {
+			respondWithWorkspaceError(w, "error while deleting the workspace", err)
+			return
+		}
+		server.RespondNoContent(w, removed)
+	default:
+		err := server.ErrNotValidMethod.With("only GET, POST and DELETE methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// handleMemberList lists the members of the workspace
+func (s *APIRoute) handleMemberList(w http.ResponseWriter, r *http.Request) {
+	// only GET methods are allowed
+	if r.Method != http.MethodGet {
+		err := server.ErrNotValidMethod.With("only GET methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	members, err := s.Service.GetMembers(FromContext(r.Context()))
+	if err != nil {
+		respondWithWorkspaceError(w, "error while getting the members", err)
+		return
+	}
+	server.RespondOK(w, members)
+}
+
+// handleMemberUpdateAndDelete changes the role of the member with POST and removes the member with DELETE
+func (s *APIRoute) handleMemberUpdateAndDelete(w http.ResponseWriter, r *http.Request) {
+	// only POST and DELETE methods are allowed
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		err := server.ErrNotValidMethod.With("only POST and DELETE methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	memberId, parseErr := strconv.ParseInt(mux.Vars(r)["userId"], 10, 64)
+	if parseErr != nil {
+		err := server.ErrInvalidRequest.With("need a numeric value for the user id")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ws := FromContext(r.Context())
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+
+	if r.Method == http.MethodDelete {
+		removed, err := s.Service.RemoveMember(ws, memberId, authenticatedUser.Id)
+		if err != nil {
+			respondWithWorkspaceError(w, "error while removing the member", err)
+			return
+		}
+		server.RespondNoContent(w, removed)
+		return
+	}
+
+	var updateData UpdateMemberData
+	if err := server.DecodeBody(r, &updateData); err != nil {
+		server.RespondWithError(w, fmt.Sprintf("error while parsing: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	updated, err := s.Service.UpdateMember(ws, memberId, &updateData)
+	if err != nil {
+		respondWithWorkspaceError(w, "error while updating the member", err)
+		return
+	}
+	server.RespondOK(w, updated)
+}
+
+// handleInvitationListAndAdd lists the pending invitations with GET and invites an email with POST
+func (s *APIRoute) handleInvitationListAndAdd(w http.ResponseWriter, r *http.Request) {
+	// only GET and POST methods are allowed
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		err := server.ErrNotValidMethod.With("only GET and POST methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ws := FromContext(r.Context())
+
+	if r.Method == http.MethodGet {
+		invitations, err := s.Service.GetInvitations(ws)
+		if err != nil {
+			respondWithWorkspaceError(w, "error while getting the invitations", err)
+			return
+		}
+		server.RespondOK(w, invitations)
+		return
+	}
+
+	var inviteData InviteData
+	if err := server.DecodeBody(r, &inviteData); err != nil {
+		server.RespondWithError(w, fmt.Sprintf("parsing error: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+	invitation, err := s.Service.Invite(ws, &inviteData, authenticatedUser)
+	if err != nil {
+		respondWithWorkspaceError(w, "error while inviting", err)
+		return
+	}
+	server.RespondCreated(w, invitation)
+}
+
+// handleInvitationDelete revokes the invitation
+func (s *APIRoute) handleInvitationDelete(w http.ResponseWriter, r *http.Request) {
+	// only DELETE methods are allowed
+	if r.Method != http.MethodDelete {
+		err := server.ErrNotValidMethod.With("only DELETE methods are allowed")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	invitationId, parseErr := strconv.Atoi(mux.Vars(r)["invitationId"])
+	if parseErr != nil {
+		err := server.ErrInvalidRequest.With("need a numeric value for the invitation id")
+		server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	removed, err := s.Service.RevokeInvitation(FromContext(r.Context()), invitationId)
+	if err != nil {
+		respondWithWorkspaceError(w, "error while revoking the invitation", err)
+		return
+	}
+	server.RespondNoContent(w, removed)
+}
@@ -0,0 +1,85 @@
+package workspace
+
+type errKind int
+
+const (
+	_ errKind = iota
+	nameRequired
+	nameLength
+	roleNotValid
+	emailNotValid
+	noFieldToUpdate
+	workspaceNotFound
+	workspaceNotEmpty
+	memberNotFound
+	alreadyMember
+	invitationNotFound
+	invitationNotValid
+	invitationEmailMismatch
+	notAllowed
+	ownerCantLeave
+)
+
+type WorkspaceError struct {
+	kind   errKind
+	fields []string
+}
+
+type Fields []string
+
+func (e WorkspaceError) Error() string {
+	switch e.kind {
+	case nameRequired:
+		return "name is required"
+	case nameLength:
+		return "name can't be longer than 255 characters"
+	case roleNotValid:
+		return "role should be either member or admin"
+	case emailNotValid:
+		return "email is not valid"
+	case noFieldToUpdate:
+		return "no field is provided"
+	case workspaceNotFound:
+		return "workspace not found"
+	case workspaceNotEmpty:
+		return "workspace still has todos or projects"
+	case memberNotFound:
+		return "member not found"
+	case alreadyMember:
+		return "user is already a member of the workspace"
+	case invitationNotFound:
+		return "invitation not found"
+	case invitationNotValid:
+		return "invitation is not valid or has expired"
+	case invitationEmailMismatch:
+		return "invitation was sent to another email"
+	case notAllowed:
+		return "your role in the workspace doesn't allow this"
+	case ownerCantLeave:
+		return "owner can't leave the workspace or change their role"
+	}
+	return "error in workspace"
+}
+
+// Is reports whether the target is a WorkspaceError of the same kind so that errors.Is can be used
+func (e WorkspaceError) Is(target error) bool {
+	t, ok := target.(WorkspaceError)
+	return ok && t.kind == e.kind
+}
+
+var (
+	ErrNameRequired            = WorkspaceError{kind: nameRequired, fields: Fields{"name"}}
+	ErrNameLength              = WorkspaceError{kind: nameLength, fields: Fields{"name"}}
+	ErrRoleNotValid            = WorkspaceError{kind: roleNotValid, fields: Fields{"role"}}
+	ErrEmailNotValid           = WorkspaceError{kind: emailNotValid, fields: Fields{"email"}}
+	ErrNoFieldToUpdate         = WorkspaceError{kind: noFieldToUpdate}
+	ErrWorkspaceNotFound       = WorkspaceError{kind: workspaceNotFound}
+	ErrWorkspaceNotEmpty       = WorkspaceError{kind: workspaceNotEmpty}
+	ErrMemberNotFound          = WorkspaceError{kind: memberNotFound}
+	ErrAlreadyMember           = WorkspaceError{kind: alreadyMember, fields: Fields{"email"}}
+	ErrInvitationNotFound      = WorkspaceError{kind: invitationNotFound}
+	ErrInvitationNotValid      = WorkspaceError{kind: invitationNotValid, fields: Fields{"token"}}
+	ErrInvitationEmailMismatch = WorkspaceError{kind: invitationEmailMismatch, fields: Fields{"token"}}
+	ErrNotAllowed              = WorkspaceError{kind: notAllowed}
+	ErrOwnerCantLeave          = WorkspaceError{kind: ownerCantLeave}
+)
@@ -0,0 +1,79 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/umtdemr/go-todo/server"
+	"github.com/umtdemr/go-todo/user"
+	"net/http"
+	"strconv"
+)
+
+// HeaderName is the header that selects the active workspace on the routes without a workspace in their path
+const HeaderName = "X-Workspace-Id"
+
+// FromContext returns the active workspace that Middleware added to the context.
+// It is nil when the request is made in the personal space of the user
+func FromContext(ctx context.Context) *Workspace {
+	ws, _ := ctx.Value("workspace").(*Workspace)
+	return ws
+}
+
+// Middleware resolves the active workspace from the workspaceId path variable or the X-Workspace-Id header
+// If there is a workspace, the user needs to be a member of it, and it is added to the context with the role of the user
+// If there is not any, the request is made in the personal space of the user
+// It needs to run after user.AuthMiddleware since it looks up the membership of the authenticated user
+func (service *Service) Middleware(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value, ok := mux.Vars(r)["workspaceId"]
+		if !ok {
+			value = r.Header.Get(HeaderName)
+		}
+
+		if value == "" {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		workspaceId, err := strconv.Atoi(value)
+		if err != nil {
+			err := server.ErrInvalidRequest.With("need a numeric value for the workspace id")
+			server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		authenticatedUser := r.Context().Value("user").(*user.VisibleUser)
+		ws, err := service.GetWorkspace(workspaceId, authenticatedUser.Id)
+		if errors.Is(err, ErrWorkspaceNotFound) {
+			server.RespondWithError(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if err != nil {
+			server.RespondWithError(w, "error while getting the workspace", http.StatusBadRequest)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), "workspace", ws)
+		handler.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequireRole resolves the active workspace like Middleware does, but the workspace is required
+// and the user needs to have at least the given role in it. Otherwise, it responds with status code 403
+func (service *Service) RequireRole(role Role, handler http.Handler) http.Handler {
+	return service.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws := FromContext(r.Context())
+		if ws == nil {
+			err := server.ErrInvalidRequest.With("workspace is required")
+			server.RespondWithError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if !ws.Role.Includes(role) {
+			server.RespondWithError(w, ErrNotAllowed.Error(), http.StatusForbidden)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+}
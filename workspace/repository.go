@@ -0,0 +1,358 @@
+package workspace
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"time"
+)
+
+type IRepository interface {
+	CreateWorkspace(data *CreateWorkspaceData, userId int64) (*Workspace, error)
+	GetAllWorkspaces(userId int64) ([]Workspace, error)
+	GetWorkspace(workspaceId int, userId int64) (*Workspace, error)
+	UpdateWorkspace(workspaceId int, data *UpdateWorkspaceData, userId int64) (*Workspace, error)
+	RemoveWorkspace(workspaceId int, userId int64) (*Workspace, error)
+	HasContent(workspaceId int) (bool, error)
+	GetMembers(workspaceId int) ([]Member, error)
+	GetMember(workspaceId int, memberId int64) (*Member, error)
+	UpdateMember(workspaceId int, memberId int64, role Role) (*Member, error)
+	RemoveMember(workspaceId int, memberId int64) (*Member, error)
+	IsMemberEmail(workspaceId int, email string) (bool, error)
+	SaveInvitation(workspaceId int, email string, role Role, invitedBy int64, expiresAt time.Time) (*Invitation, error)
+	GetInvitations(workspaceId int) ([]Invitation, error)
+	GetInvitation(invitationId int) (*Invitation, error)
+	RemoveInvitation(workspaceId int, invitationId int) (*Invitation, error)
+	AcceptInvitation(invitationId int, userId int64) (*Workspace, error)
+}
+
+type Repository struct {
+	DB *pgxpool.Pool
+}
+
+func NewWorkspaceRepository(dbConn *pgxpool.Pool) (*Repository, error) {
+	return &Repository{dbConn}, nil
+}
+
+// Init creates the workspace tables. It needs to run before the todo and the project tables are migrated
+// since they reference the workspaces
+func (store *Repository) Init() error {
+	for _, query := range workspaceQueries {
+		if _, err := store.DB.Exec(context.Background(), query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// workspaceQueries create the workspace tables. They need to be idempotent since they run on every start
+var workspaceQueries = []string{
+	`CREATE TABLE IF NOT EXISTS "workspace" (
+		id serial PRIMARY KEY,
+		name varchar(255) NOT NULL,
+		created_at timestamp DEFAULT now(),
+		updated_at timestamp DEFAULT now()
+	)`,
+	// the owner is a member with the owner role, so the access checks only need to look at this table
+	`CREATE TABLE IF NOT EXISTS "workspace_member" (
+		workspace_id integer NOT NULL REFERENCES "workspace"(id) ON DELETE CASCADE,
+		user_id integer NOT NULL REFERENCES "user"(id) ON DELETE CASCADE,
+		role varchar(10) NOT NULL CHECK (role IN ('owner', 'admin', 'member')),
+		created_at timestamp DEFAULT now(),
+		PRIMARY KEY (workspace_id, user_id)
+	)`,
+	`CREATE INDEX IF NOT EXISTS workspace_member_user_id_idx ON "workspace_member" (user_id)`,
+	`CREATE TABLE IF NOT EXISTS "workspace_invitation" (
+		id serial PRIMARY KEY,
+		workspace_id integer NOT NULL REFERENCES "workspace"(id) ON DELETE CASCADE,
+		email varchar(255) NOT NULL,
+		role varchar(10) NOT NULL CHECK (role IN ('admin', 'member')),
+		invited_by integer REFERENCES "user"(id) ON DELETE SET NULL,
+		expires_at timestamptz NOT NULL,
+		created_at timestamp DEFAULT now(),
+		UNIQUE (workspace_id, email)
+	)`,
+}
+
+// CreateWorkspace creates the workspace and adds the user as its owner
+func (store *Repository) CreateWorkspace(data *CreateWorkspaceData, userId int64) (*Workspace, error) {
+	ctx := context.Background()
+	tx, err := store.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `WITH w AS (
+			INSERT INTO "workspace"(name) VALUES (@name) RETURNING id, name, created_at, updated_at
+		), m AS (
+			INSERT INTO "workspace_member"(workspace_id, user_id, role) SELECT id, @userId, 'owner' FROM w RETURNING role
+		)
+		SELECT ` + workspaceColumns + ` FROM w, m`
+	args := pgx.NamedArgs{
+		"name":   data.Name,
+		"userId": userId,
+	}
+
+	created, err := ScanWorkspace(tx.QueryRow(ctx, query, args))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// GetAllWorkspaces returns the workspaces that the user is a member of
+func (store *Repository) GetAllWorkspaces(userId int64) ([]Workspace, error) {
+	query := `SELECT ` + workspaceColumns + ` FROM "workspace" w
+		JOIN "workspace_member" m ON m.workspace_id = w.id AND m.user_id = @userId
+		ORDER BY w.name, w.id`
+	args := pgx.NamedArgs{"userId": userId}
+
+	rows, err := store.DB.Query(context.Background(), query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	workspaces := []Workspace{}
+	for rows.Next() {
+		w, err := ScanWorkspace(rows)
+		if err != nil {
+			return nil, err
+		}
+		workspaces = append(workspaces, *w)
+	}
+	return workspaces, rows.Err()
+}
+
+// GetWorkspace returns the workspace with the role of the user if the user is a member of it
+func (store *Repository) GetWorkspace(workspaceId int, userId int64) (*Workspace, error) {
+	query := `SELECT ` + workspaceColumns + ` FROM "workspace" w
+		JOIN "workspace_member" m ON m.workspace_id = w.id AND m.user_id = @userId
+		WHERE w.id = @workspaceId`
+	args := pgx.NamedArgs{
+		"workspaceId": workspaceId,
+		"userId":      userId,
+	}
+
+	return ScanWorkspace(store.DB.QueryRow(context.Background(), query, args))
+}
+
+func (store *Repository) UpdateWorkspace(workspaceId int, data *UpdateWorkspaceData, userId int64) (*Workspace, error) {
+	query := `UPDATE "workspace" w SET name = @name, updated_at = now()
+		FROM "workspace_member" m
+		WHERE w.id = @workspaceId AND m.workspace_id = w.id AND m.user_id = @userId
+		RETURNING ` + workspaceColumns
+	args := pgx.NamedArgs{
+		"workspaceId": workspaceId,
+		"name":        *data.Name,
+		"userId":      userId,
+	}
+
+	return ScanWorkspace(store.DB.QueryRow(context.Background(), query, args))
+}
+
+// RemoveWorkspace deletes the workspace together with its members and invitations
+func (store *Repository) RemoveWorkspace(workspaceId int, userId int64) (*Workspace, error) {
+	query := `DELETE FROM "workspace" w USING "workspace_member" m
+		WHERE w.id = @workspaceId AND m.workspace_id = w.id AND m.user_id = @userId
+		RETURNING ` + workspaceColumns
+	args := pgx.NamedArgs{
+		"workspaceId": workspaceId,
+		"userId":      userId,
+	}
+
+	return ScanWorkspace(store.DB.QueryRow(context.Background(), query, args))
+}
+
+// HasContent reports whether the workspace has any project or todo, including the todos in the trash
+func (store *Repository) HasContent(workspaceId int) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM "project" WHERE workspace_id = @workspaceId)
+		OR EXISTS (SELECT 1 FROM "todo" WHERE workspace_id = @workspaceId)`
+	args := pgx.NamedArgs{"workspaceId": workspaceId}
+
+	var hasContent bool
+	err := store.DB.QueryRow(context.Background(), query, args).Scan(&hasContent)
+	return hasContent, err
+}
+
+// GetMembers returns the members of the workspace in the order they joined
+func (store *Repository) GetMembers(workspaceId int) ([]Member, error) {
+	query := `SELECT ` + memberColumns + ` FROM "workspace_member" m JOIN "user" u ON u.id = m.user_id
+		WHERE m.workspace_id = @workspaceId ORDER BY m.created_at, u.id`
+	args := pgx.NamedArgs{"workspaceId": workspaceId}
+
+	rows, err := store.DB.Query(context.Background(), query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []Member{}
+	for rows.Next() {
+		m, err := ScanMember(rows)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, *m)
+	}
+	return members, rows.Err()
+}
+
+func (store *Repository) GetMember(workspaceId int, memberId int64) (*Member, error) {
+	query := `SELECT ` + memberColumns + ` FROM "workspace_member" m JOIN "user" u ON u.id = m.user_id
+		WHERE m.workspace_id = @workspaceId AND m.user_id = @memberId`
+	args := pgx.NamedArgs{
+		"workspaceId": workspaceId,
+		"memberId":    memberId,
+	}
+
+	return ScanMember(store.DB.QueryRow(context.Background(), query, args))
+}
+
+func (store *Repository) UpdateMember(workspaceId int, memberId int64, role Role) (*Member, error) {
+	query := `UPDATE "workspace_member" m SET role = @role FROM "user" u
+		WHERE u.id = m.user_id AND m.workspace_id = @workspaceId AND m.user_id = @memberId
+		RETURNING ` + memberColumns
+	args := pgx.NamedArgs{
+		"workspaceId": workspaceId,
+		"memberId":    memberId,
+		"role":        role,
+	}
+
+	return ScanMember(store.DB.QueryRow(context.Background(), query, args))
+}
+
+// RemoveMember removes the member from the workspace. The todos that the member created stay in the workspace
+func (store *Repository) RemoveMember(workspaceId int, memberId int64) (*Member, error) {
+	query := `DELETE FROM "workspace_member" m USING "user" u
+		WHERE u.id = m.user_id AND m.workspace_id = @workspaceId AND m.user_id = @memberId
+		RETURNING ` + memberColumns
+	args := pgx.NamedArgs{
+		"workspaceId": workspaceId,
+		"memberId":    memberId,
+	}
+
+	return ScanMember(store.DB.QueryRow(context.Background(), query, args))
+}
+
+// IsMemberEmail reports whether the user with the email is already a member of the workspace
+func (store *Repository) IsMemberEmail(workspaceId int, email string) (bool, error) {
+	query := `SELECT EXISTS (
+		SELECT 1 FROM "workspace_member" m JOIN "user" u ON u.id = m.user_id
+		WHERE m.workspace_id = @workspaceId AND lower(u.email) = lower(@email)
+	)`
+	args := pgx.NamedArgs{
+		"workspaceId": workspaceId,
+		"email":       email,
+	}
+
+	var isMember bool
+	err := store.DB.QueryRow(context.Background(), query, args).Scan(&isMember)
+	return isMember, err
+}
+
+// SaveInvitation invites the email to the workspace. Inviting the same email again renews the invitation
+func (store *Repository) SaveInvitation(workspaceId int, email string, role Role, invitedBy int64, expiresAt time.Time) (*Invitation, error) {
+	query := `INSERT INTO "workspace_invitation"(workspace_id, email, role, invited_by, expires_at)
+		VALUES (@workspaceId, @email, @role, @invitedBy, @expiresAt)
+		ON CONFLICT (workspace_id, email) DO UPDATE
+		SET role = EXCLUDED.role, invited_by = EXCLUDED.invited_by, expires_at = EXCLUDED.expires_at, created_at = now()
+		RETURNING ` + invitationColumns
+	args := pgx.NamedArgs{
+		"workspaceId": workspaceId,
+		"email":       email,
+		"role":        role,
+		"invitedBy":   invitedBy,
+		"expiresAt":   expiresAt,
+	}
+
+	return ScanInvitation(store.DB.QueryRow(context.Background(), query, args))
+}
+
+// GetInvitations returns the invitations of the workspace that haven't expired yet
+func (store *Repository) GetInvitations(workspaceId int) ([]Invitation, error) {
+	query := `SELECT ` + invitationColumns + ` FROM "workspace_invitation"
+		WHERE workspace_id = @workspaceId AND expires_at > now() ORDER BY created_at, id`
+	args := pgx.NamedArgs{"workspaceId": workspaceId}
+
+	rows, err := store.DB.Query(context.Background(), query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invitations := []Invitation{}
+	for rows.Next() {
+		i, err := ScanInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, *i)
+	}
+	return invitations, rows.Err()
+}
+
+func (store *Repository) GetInvitation(invitationId int) (*Invitation, error) {
+	query := `SELECT ` + invitationColumns + ` FROM "workspace_invitation" WHERE id = @invitationId`
+	args := pgx.NamedArgs{"invitationId": invitationId}
+
+	return ScanInvitation(store.DB.QueryRow(context.Background(), query, args))
+}
+
+// RemoveInvitation revokes the invitation so that its token can't be used anymore
+func (store *Repository) RemoveInvitation(workspaceId int, invitationId int) (*Invitation, error) {
+	query := `DELETE FROM "workspace_invitation" WHERE id = @invitationId AND workspace_id = @workspaceId
+		RETURNING ` + invitationColumns
+	args := pgx.NamedArgs{
+		"workspaceId":  workspaceId,
+		"invitationId": invitationId,
+	}
+
+	return ScanInvitation(store.DB.QueryRow(context.Background(), query, args))
+}
+
+// AcceptInvitation adds the user to the workspace with the role of the invitation and removes the invitation
+func (store *Repository) AcceptInvitation(invitationId int, userId int64) (*Workspace, error) {
+	ctx := context.Background()
+	tx, err := store.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	args := pgx.NamedArgs{
+		"invitationId": invitationId,
+		"userId":       userId,
+	}
+
+	joinQuery := `WITH i AS (
+			DELETE FROM "workspace_invitation" WHERE id = @invitationId AND expires_at > now() RETURNING workspace_id, role
+		)
+		INSERT INTO "workspace_member"(workspace_id, user_id, role) SELECT workspace_id, @userId, role FROM i
+		RETURNING workspace_id`
+
+	var workspaceId int
+	if err := tx.QueryRow(ctx, joinQuery, args).Scan(&workspaceId); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + workspaceColumns + ` FROM "workspace" w
+		JOIN "workspace_member" m ON m.workspace_id = w.id AND m.user_id = @userId
+		WHERE w.id = @workspaceId`
+	args["workspaceId"] = workspaceId
+
+	joined, err := ScanWorkspace(tx.QueryRow(ctx, query, args))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return joined, nil
+}
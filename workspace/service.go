@@ -0,0 +1,305 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/umtdemr/go-todo/email"
+	"github.com/umtdemr/go-todo/logger"
+	"github.com/umtdemr/go-todo/user"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultInvitationTTL is how long an invitation can be accepted when the expiry is not configured
+const DefaultInvitationTTL = 7 * 24 * time.Hour
+
+var emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
+
+type Service struct {
+	Repository IRepository
+	// InvitationTTL is how long the invitations can be accepted after they are sent
+	InvitationTTL time.Duration
+	// SendEmail sends the invitation emails. It is email.Send unless it is replaced in the tests
+	SendEmail func(data email.SendEmailData) error
+}
+
+func NewWorkspaceService(repo IRepository) *Service {
+	return &Service{Repository: repo, InvitationTTL: DefaultInvitationTTL, SendEmail: email.Send}
+}
+
+// checkRole makes sure that the user has at least the needed role in the workspace
+func checkRole(ws *Workspace, needed Role) error {
+	if !ws.Role.Includes(needed) {
+		return ErrNotAllowed
+	}
+	return nil
+}
+
+// validateName trims the name and checks its length
+func validateName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", ErrNameRequired
+	}
+	if len(name) > 255 {
+		return "", ErrNameLength
+	}
+	return name, nil
+}
+
+// CreateWorkspace creates a workspace that is owned by the user
+func (service *Service) CreateWorkspace(data *CreateWorkspaceData, userId int64) (*Workspace, error) {
+	name, err := validateName(data.Name)
+	if err != nil {
+		return nil, err
+	}
+	data.Name = name
+
+	return service.Repository.CreateWorkspace(data, userId)
+}
+
+// GetAllWorkspaces lists the workspaces that the user is a member of
+func (service *Service) GetAllWorkspaces(userId int64) ([]Workspace, error) {
+	return service.Repository.GetAllWorkspaces(userId)
+}
+
+// GetWorkspace returns the workspace with the role of the user. It is not found if the user isn't a member of it
+func (service *Service) GetWorkspace(workspaceId int, userId int64) (*Workspace, error) {
+	ws, err := service.Repository.GetWorkspace(workspaceId, userId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrWorkspaceNotFound
+	}
+	return ws, err
+}
+
+// UpdateWorkspace renames the workspace. Only the admins can change it
+func (service *Service) UpdateWorkspace(ws *Workspace, data *UpdateWorkspaceData, userId int64) (*Workspace, error) {
+	if data.Name == nil {
+		return nil, ErrNoFieldToUpdate
+	}
+
+	name, err := validateName(*data.Name)
+	if err != nil {
+		return nil, err
+	}
+	data.Name = &name
+
+	if err := checkRole(ws, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	updated, err := service.Repository.UpdateWorkspace(ws.Id, data, userId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrWorkspaceNotFound
+	}
+	return updated, err
+}
+
+// RemoveWorkspace deletes the workspace. Only the owner can delete it, and only after its todos and projects are gone
+func (service *Service) RemoveWorkspace(ws *Workspace, userId int64) (*Workspace, error) {
+	if err := checkRole(ws, RoleOwner); err != nil {
+		return nil, err
+	}
+
+	hasContent, err := service.Repository.HasContent(ws.Id)
+	if err != nil {
+		return nil, err
+	}
+	if hasContent {
+		return nil, ErrWorkspaceNotEmpty
+	}
+
+	removed, err := service.Repository.RemoveWorkspace(ws.Id, userId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrWorkspaceNotFound
+	}
+	return removed, err
+}
+
+// GetMembers lists the members of the workspace. Every member can see the list
+func (service *Service) GetMembers(ws *Workspace) ([]Member, error) {
+	return service.Repository.GetMembers(ws.Id)
+}
+
+// getMember returns the member that is going to be changed. The owner can't be changed by anyone
+func (service *Service) getMember(ws *Workspace, memberId int64) (*Member, error) {
+	member, err := service.Repository.GetMember(ws.Id, memberId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrMemberNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if member.Role == RoleOwner {
+		return nil, ErrOwnerCantLeave
+	}
+	return member, nil
+}
+
+// UpdateMember changes the role of the member. Only the admins can change the roles
+func (service *Service) UpdateMember(ws *Workspace, memberId int64, data *UpdateMemberData) (*Member, error) {
+	if data.Role == nil {
+		return nil, ErrNoFieldToUpdate
+	}
+
+	if err := validateMemberRole(*data.Role); err != nil {
+		return nil, err
+	}
+
+	if err := checkRole(ws, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	if _, err := service.getMember(ws, memberId); err != nil {
+		return nil, err
+	}
+
+	updated, err := service.Repository.UpdateMember(ws.Id, memberId, *data.Role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrMemberNotFound
+	}
+	return updated, err
+}
+
+// RemoveMember removes the member from the workspace. Admins can remove anyone but the owner, and members can leave
+func (service *Service) RemoveMember(ws *Workspace, memberId int64, userId int64) (*Member, error) {
+	needed := RoleAdmin
+	if memberId == userId {
+		needed = RoleMember
+	}
+
+	if err := checkRole(ws, needed); err != nil {
+		return nil, err
+	}
+
+	if _, err := service.getMember(ws, memberId); err != nil {
+		return nil, err
+	}
+
+	removed, err := service.Repository.RemoveMember(ws.Id, memberId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrMemberNotFound
+	}
+	return removed, err
+}
+
+// Invite invites the email to the workspace and emails the invitation token to it.
+// The token is returned with the invitation if the email couldn't be sent
+func (service *Service) Invite(ws *Workspace, data *InviteData, inviter *user.VisibleUser) (*Invitation, error) {
+	address := strings.TrimSpace(data.Email)
+	if !emailRegex.MatchString(address) {
+		return nil, ErrEmailNotValid
+	}
+
+	role := data.Role
+	if role == "" {
+		role = RoleMember
+	}
+	if err := validateMemberRole(role); err != nil {
+		return nil, err
+	}
+
+	if err := checkRole(ws, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	isMember, err := service.Repository.IsMemberEmail(ws.Id, address)
+	if err != nil {
+		return nil, err
+	}
+	if isMember {
+		return nil, ErrAlreadyMember
+	}
+
+	invitation, err := service.Repository.SaveInvitation(ws.Id, address, role, inviter.Id, time.Now().Add(service.InvitationTTL))
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := user.GenerateInvitationToken(invitation.Id, invitation.Email, invitation.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	sendErr := service.SendEmail(email.SendEmailData{
+		To:      []string{invitation.Email},
+		Subject: fmt.Sprintf("%s invited you to %s", inviter.Username, ws.Name),
+		Message: fmt.Sprintf(
+			"%s invited you to join %s as %s.\r\n\r\nYour invitation token is: %s",
+			inviter.Username, ws.Name, invitation.Role, token,
+		),
+	})
+	if sendErr != nil {
+		if !errors.Is(sendErr, email.ErrServiceNotEnabled) {
+			log := logger.Get()
+			log.Error().Err(sendErr).Int("invitationId", invitation.Id).Msg("Couldn't email the invitation")
+		}
+		invitation.Token = token
+	}
+	return invitation, nil
+}
+
+// GetInvitations lists the invitations of the workspace that can still be accepted. Only the admins can see them
+func (service *Service) GetInvitations(ws *Workspace) ([]Invitation, error) {
+	if err := checkRole(ws, RoleAdmin); err != nil {
+		return nil, err
+	}
+	return service.Repository.GetInvitations(ws.Id)
+}
+
+// RevokeInvitation removes the invitation so that its token stops working. Only the admins can revoke invitations
+func (service *Service) RevokeInvitation(ws *Workspace, invitationId int) (*Invitation, error) {
+	if err := checkRole(ws, RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	removed, err := service.Repository.RemoveInvitation(ws.Id, invitationId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrInvitationNotFound
+	}
+	return removed, err
+}
+
+// AcceptInvitation adds the user to the workspace of the invitation in the token.
+// The invitation needs to be sent to the email of the user
+func (service *Service) AcceptInvitation(data *AcceptInvitationData, accepting *user.VisibleUser) (*Workspace, error) {
+	if data.Token == "" {
+		return nil, ErrInvitationNotValid
+	}
+
+	invitationId, invitedEmail, tokenErr := user.ValidateInvitationToken(data.Token)
+	if tokenErr != nil {
+		return nil, ErrInvitationNotValid
+	}
+
+	// revoked invitations don't exist anymore, so their tokens stop working
+	invitation, err := service.Repository.GetInvitation(invitationId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrInvitationNotValid
+	}
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(invitation.ExpiresAt) {
+		return nil, ErrInvitationNotValid
+	}
+
+	if !strings.EqualFold(invitation.Email, invitedEmail) || !strings.EqualFold(invitation.Email, accepting.Email) {
+		return nil, ErrInvitationEmailMismatch
+	}
+
+	if _, err := service.GetWorkspace(invitation.WorkspaceId, accepting.Id); err == nil {
+		return nil, ErrAlreadyMember
+	} else if !errors.Is(err, ErrWorkspaceNotFound) {
+		return nil, err
+	}
+
+	joined, err := service.Repository.AcceptInvitation(invitationId, accepting.Id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrInvitationNotValid
+	}
+	return joined, err
+}
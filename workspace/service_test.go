@@ -0,0 +1,377 @@
+package workspace
+
+import (
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/umtdemr/go-todo/email"
+	"github.com/umtdemr/go-todo/user"
+	"testing"
+	"time"
+)
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) CreateWorkspace(data *CreateWorkspaceData, userId int64) (*Workspace, error) {
+	args := m.Called(data, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Workspace), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) GetAllWorkspaces(userId int64) ([]Workspace, error) {
+	args := m.Called(userId)
+	return args.Get(0).([]Workspace), args.Error(1)
+}
+
+func (m *MockRepository) GetWorkspace(workspaceId int, userId int64) (*Workspace, error) {
+	args := m.Called(workspaceId, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Workspace), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) UpdateWorkspace(workspaceId int, data *UpdateWorkspaceData, userId int64) (*Workspace, error) {
+	args := m.Called(workspaceId, data, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Workspace), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) RemoveWorkspace(workspaceId int, userId int64) (*Workspace, error) {
+	args := m.Called(workspaceId, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Workspace), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) HasContent(workspaceId int) (bool, error) {
+	args := m.Called(workspaceId)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) GetMembers(workspaceId int) ([]Member, error) {
+	args := m.Called(workspaceId)
+	return args.Get(0).([]Member), args.Error(1)
+}
+
+func (m *MockRepository) GetMember(workspaceId int, memberId int64) (*Member, error) {
+	args := m.Called(workspaceId, memberId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Member), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) UpdateMember(workspaceId int, memberId int64, role Role) (*Member, error) {
+	args := m.Called(workspaceId, memberId, role)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Member), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) RemoveMember(workspaceId int, memberId int64) (*Member, error) {
+	args := m.Called(workspaceId, memberId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Member), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) IsMemberEmail(workspaceId int, email string) (bool, error) {
+	args := m.Called(workspaceId, email)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) SaveInvitation(workspaceId int, email string, role Role, invitedBy int64, expiresAt time.Time) (*Invitation, error) {
+	args := m.Called(workspaceId, email, role, invitedBy, expiresAt)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Invitation), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) GetInvitations(workspaceId int) ([]Invitation, error) {
+	args := m.Called(workspaceId)
+	return args.Get(0).([]Invitation), args.Error(1)
+}
+
+func (m *MockRepository) GetInvitation(invitationId int) (*Invitation, error) {
+	args := m.Called(invitationId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Invitation), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) RemoveInvitation(workspaceId int, invitationId int) (*Invitation, error) {
+	args := m.Called(workspaceId, invitationId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Invitation), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) AcceptInvitation(invitationId int, userId int64) (*Workspace, error) {
+	args := m.Called(invitationId, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*Workspace), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func TestRoleIncludes(t *testing.T) {
+	assert.True(t, RoleOwner.Includes(RoleAdmin))
+	assert.True(t, RoleAdmin.Includes(RoleMember))
+	assert.False(t, RoleMember.Includes(RoleAdmin))
+	assert.False(t, RoleAdmin.Includes(RoleOwner))
+	assert.False(t, Role("").Includes(RoleMember))
+}
+
+func TestInvite(t *testing.T) {
+	inviter := &user.VisibleUser{Id: 1, Username: "umit"}
+	expiresAt := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name          string
+		role          Role
+		data          InviteData
+		sendErr       error
+		setupMock     func(repo *MockRepository)
+		expectedError error
+		expectToken   bool
+	}{
+		{
+			name:          "Email is not valid",
+			role:          RoleAdmin,
+			data:          InviteData{Email: "deniz"},
+			setupMock:     func(repo *MockRepository) {},
+			expectedError: ErrEmailNotValid,
+		},
+		{
+			name:          "Nobody can be invited as the owner",
+			role:          RoleOwner,
+			data:          InviteData{Email: "deniz@example.com", Role: RoleOwner},
+			setupMock:     func(repo *MockRepository) {},
+			expectedError: ErrRoleNotValid,
+		},
+		{
+			name:          "Members can't invite",
+			role:          RoleMember,
+			data:          InviteData{Email: "deniz@example.com"},
+			setupMock:     func(repo *MockRepository) {},
+			expectedError: ErrNotAllowed,
+		},
+		{
+			name: "Email is already a member",
+			role: RoleAdmin,
+			data: InviteData{Email: "deniz@example.com"},
+			setupMock: func(repo *MockRepository) {
+				repo.On("IsMemberEmail", 5, "deniz@example.com").Return(true, nil)
+			},
+			expectedError: ErrAlreadyMember,
+		},
+		{
+			name: "Invitation is emailed",
+			role: RoleAdmin,
+			data: InviteData{Email: " deniz@example.com ", Role: RoleAdmin},
+			setupMock: func(repo *MockRepository) {
+				repo.On("IsMemberEmail", 5, "deniz@example.com").Return(false, nil)
+				repo.On("SaveInvitation", 5, "deniz@example.com", RoleAdmin, int64(1), mock.Anything).
+					Return(&Invitation{Id: 9, Email: "deniz@example.com", Role: RoleAdmin, ExpiresAt: expiresAt}, nil)
+			},
+		},
+		{
+			name:    "Token is returned when the email service is not enabled",
+			role:    RoleOwner,
+			data:    InviteData{Email: "deniz@example.com"},
+			sendErr: email.ErrServiceNotEnabled,
+			setupMock: func(repo *MockRepository) {
+				repo.On("IsMemberEmail", 5, "deniz@example.com").Return(false, nil)
+				repo.On("SaveInvitation", 5, "deniz@example.com", RoleMember, int64(1), mock.Anything).
+					Return(&Invitation{Id: 9, Email: "deniz@example.com", Role: RoleMember, ExpiresAt: expiresAt}, nil)
+			},
+			expectToken: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			service := NewWorkspaceService(mockRepo)
+			service.SendEmail = func(data email.SendEmailData) error {
+				return tc.sendErr
+			}
+			tc.setupMock(mockRepo)
+
+			ws := &Workspace{Id: 5, Name: "Team", Role: tc.role}
+			invitation, err := service.Invite(ws, &tc.data, inviter)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+			} else {
+				assert.Nil(t, err)
+				assert.Equal(t, tc.expectToken, invitation.Token != "")
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAcceptInvitation(t *testing.T) {
+	accepting := &user.VisibleUser{Id: 2, Username: "deniz", Email: "Deniz@example.com"}
+	token, _ := user.GenerateInvitationToken(9, "deniz@example.com", time.Now().Add(time.Hour))
+	otherToken, _ := user.GenerateInvitationToken(9, "other@example.com", time.Now().Add(time.Hour))
+	invitation := &Invitation{Id: 9, WorkspaceId: 5, Email: "deniz@example.com", Role: RoleMember, ExpiresAt: time.Now().Add(time.Hour)}
+
+	tests := []struct {
+		name          string
+		token         string
+		setupMock     func(repo *MockRepository)
+		expectedError error
+	}{
+		{
+			name:          "Token is not valid",
+			token:         "invalid.token.string",
+			setupMock:     func(repo *MockRepository) {},
+			expectedError: ErrInvitationNotValid,
+		},
+		{
+			name:  "Invitation is revoked",
+			token: token,
+			setupMock: func(repo *MockRepository) {
+				repo.On("GetInvitation", 9).Return(nil, pgx.ErrNoRows)
+			},
+			expectedError: ErrInvitationNotValid,
+		},
+		{
+			name:  "Invitation is renewed for another email",
+			token: otherToken,
+			setupMock: func(repo *MockRepository) {
+				repo.On("GetInvitation", 9).Return(invitation, nil)
+			},
+			expectedError: ErrInvitationEmailMismatch,
+		},
+		{
+			name:  "User is already a member",
+			token: token,
+			setupMock: func(repo *MockRepository) {
+				repo.On("GetInvitation", 9).Return(invitation, nil)
+				repo.On("GetWorkspace", 5, int64(2)).Return(&Workspace{Id: 5, Role: RoleMember}, nil)
+			},
+			expectedError: ErrAlreadyMember,
+		},
+		{
+			name:  "User joins the workspace",
+			token: token,
+			setupMock: func(repo *MockRepository) {
+				repo.On("GetInvitation", 9).Return(invitation, nil)
+				repo.On("GetWorkspace", 5, int64(2)).Return(nil, pgx.ErrNoRows)
+				repo.On("AcceptInvitation", 9, int64(2)).Return(&Workspace{Id: 5, Role: RoleMember}, nil)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			service := NewWorkspaceService(mockRepo)
+			tc.setupMock(mockRepo)
+
+			_, err := service.AcceptInvitation(&AcceptInvitationData{Token: tc.token}, accepting)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+			} else {
+				assert.Nil(t, err)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRemoveMember(t *testing.T) {
+	tests := []struct {
+		name          string
+		role          Role
+		memberId      int64
+		setupMock     func(repo *MockRepository)
+		expectedError error
+	}{
+		{
+			name:          "Members can't remove others",
+			role:          RoleMember,
+			memberId:      3,
+			setupMock:     func(repo *MockRepository) {},
+			expectedError: ErrNotAllowed,
+		},
+		{
+			name:     "Members can leave",
+			role:     RoleMember,
+			memberId: 2,
+			setupMock: func(repo *MockRepository) {
+				repo.On("GetMember", 5, int64(2)).Return(&Member{Id: 2, Role: RoleMember}, nil)
+				repo.On("RemoveMember", 5, int64(2)).Return(&Member{Id: 2}, nil)
+			},
+		},
+		{
+			name:     "Owner can't be removed",
+			role:     RoleAdmin,
+			memberId: 1,
+			setupMock: func(repo *MockRepository) {
+				repo.On("GetMember", 5, int64(1)).Return(&Member{Id: 1, Role: RoleOwner}, nil)
+			},
+			expectedError: ErrOwnerCantLeave,
+		},
+		{
+			name:     "Member doesn't exist",
+			role:     RoleAdmin,
+			memberId: 4,
+			setupMock: func(repo *MockRepository) {
+				repo.On("GetMember", 5, int64(4)).Return(nil, pgx.ErrNoRows)
+			},
+			expectedError: ErrMemberNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			service := NewWorkspaceService(mockRepo)
+			tc.setupMock(mockRepo)
+
+			_, err := service.RemoveMember(&Workspace{Id: 5, Role: tc.role}, tc.memberId, 2)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+			} else {
+				assert.Nil(t, err)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRemoveWorkspace(t *testing.T) {
+	t.Run("Admins can't delete the workspace", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		service := NewWorkspaceService(mockRepo)
+
+		_, err := service.RemoveWorkspace(&Workspace{Id: 5, Role: RoleAdmin}, 2)
+		assert.ErrorIs(t, err, ErrNotAllowed)
+	})
+
+	t.Run("Workspace still has todos", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		service := NewWorkspaceService(mockRepo)
+		mockRepo.On("HasContent", 5).Return(true, nil)
+
+		_, err := service.RemoveWorkspace(&Workspace{Id: 5, Role: RoleOwner}, 1)
+		assert.ErrorIs(t, err, ErrWorkspaceNotEmpty)
+		mockRepo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,136 @@
+package workspace
+
+import (
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+// Role is the role of a member in a workspace
+type Role string
+
+const (
+	// RoleMember can see the workspace and edit the todos and the projects in it
+	RoleMember Role = "member"
+	// RoleAdmin can invite and remove members and owns the todos and the projects in the workspace
+	RoleAdmin Role = "admin"
+	// RoleOwner is the user who created the workspace. Only the owner can delete it
+	RoleOwner Role = "owner"
+)
+
+func (r Role) rank() int {
+	switch r {
+	case RoleMember:
+		return 1
+	case RoleAdmin:
+		return 2
+	case RoleOwner:
+		return 3
+	}
+	return 0
+}
+
+// Includes reports whether the role allows everything that the other role allows
+func (r Role) Includes(other Role) bool {
+	return r.rank() > 0 && other.rank() > 0 && r.rank() >= other.rank()
+}
+
+type Workspace struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+	// Role is the role of the user in the workspace
+	Role      Role      `json:"role"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type Member struct {
+	Id       int64     `json:"id"`
+	Username string    `json:"username"`
+	Role     Role      `json:"role"`
+	JoinedAt time.Time `json:"joinedAt"`
+}
+
+// Invitation is an invitation to the workspace that is waiting for the invited email to accept it
+type Invitation struct {
+	Id          int       `json:"id"`
+	WorkspaceId int       `json:"workspaceId"`
+	Email       string    `json:"email"`
+	Role        Role      `json:"role"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+	CreatedAt   time.Time `json:"createdAt"`
+	// Token is only returned when the invitation couldn't be emailed
+	Token string `json:"token,omitempty"`
+}
+
+type CreateWorkspaceData struct {
+	Name string `json:"name"`
+}
+
+type UpdateWorkspaceData struct {
+	Name *string `json:"name,omitempty"`
+}
+
+type InviteData struct {
+	Email string `json:"email"`
+	// Role is member if it is empty
+	Role Role `json:"role"`
+}
+
+type UpdateMemberData struct {
+	Role *Role `json:"role,omitempty"`
+}
+
+type AcceptInvitationData struct {
+	Token string `json:"token"`
+}
+
+// workspaceColumns are the columns selected for scanning a workspace with ScanWorkspace.
+// The queries using it need to join the membership of the user as m
+const workspaceColumns = `w.id, w.name, m.role, w.created_at, w.updated_at`
+
+func ScanWorkspace(row pgx.Row) (*Workspace, error) {
+	w := new(Workspace)
+	var role string
+	err := row.Scan(&w.Id, &w.Name, &role, &w.CreatedAt, &w.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	w.Role = Role(role)
+	return w, nil
+}
+
+// memberColumns are the columns selected for scanning a member with ScanMember.
+// The queries using it need to join the user as u and the membership as m
+const memberColumns = `u.id, u.username, m.role, m.created_at`
+
+func ScanMember(row pgx.Row) (*Member, error) {
+	m := new(Member)
+	var role string
+	err := row.Scan(&m.Id, &m.Username, &role, &m.JoinedAt)
+	if err != nil {
+		return nil, err
+	}
+	m.Role = Role(role)
+	return m, nil
+}
+
+const invitationColumns = `id, workspace_id, email, role, expires_at, created_at`
+
+func ScanInvitation(row pgx.Row) (*Invitation, error) {
+	i := new(Invitation)
+	var role string
+	err := row.Scan(&i.Id, &i.WorkspaceId, &i.Email, &role, &i.ExpiresAt, &i.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	i.Role = Role(role)
+	return i, nil
+}
+
+// validateMemberRole checks the role that can be given to a member. There is only one owner
+func validateMemberRole(role Role) error {
+	if role != RoleMember && role != RoleAdmin {
+		return ErrRoleNotValid
+	}
+	return nil
+}